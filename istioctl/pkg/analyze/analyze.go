@@ -18,10 +18,12 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/mattn/go-isatty"
@@ -36,6 +38,7 @@ import (
 	"istio.io/istio/pkg/cluster"
 	"istio.io/istio/pkg/config/analysis"
 	"istio.io/istio/pkg/config/analysis/analyzers"
+	"istio.io/istio/pkg/config/analysis/analyzers/plugin"
 	"istio.io/istio/pkg/config/analysis/diag"
 	"istio.io/istio/pkg/config/analysis/local"
 	"istio.io/istio/pkg/config/analysis/msg"
@@ -82,6 +85,10 @@ var (
 	revisionSpecified string
 	remoteContexts    []string
 	selectedAnalyzers []string
+	analyzerPlugins   []string
+	fix               bool
+	skipConfirmation  bool
+	watch             bool
 
 	fileExtensions = []string{".json", ".yaml", ".yml"}
 )
@@ -117,7 +124,16 @@ func Analyze(ctx cli.Context) *cobra.Command {
   istioctl analyze -L
   
   # Run specific analyzer
-  istioctl analyze --analyzer "gateway.ConflictingGatewayAnalyzer"`,
+  istioctl analyze --analyzer "gateway.ConflictingGatewayAnalyzer"
+
+  # Fix the issues found in yaml files, after reviewing the diff
+  istioctl analyze --use-kube=false --fix a.yaml b.yaml
+
+  # Run the analyzers declared in a plugin file, in addition to the built-in analyzers
+  istioctl analyze --analyzer-plugin acme-analyzers.yaml
+
+  # Keep analyzing the cluster and the yaml files, printing the issues which appear or are resolved
+  istioctl analyze --watch a.yaml b.yaml`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			msgOutputFormat = strings.ToLower(msgOutputFormat)
 			_, ok := formatting.MsgOutputFormats[msgOutputFormat]
@@ -126,9 +142,23 @@ func Analyze(ctx cli.Context) *cobra.Command {
 					Err: fmt.Errorf("%s not a valid option for format. See istioctl analyze --help", msgOutputFormat),
 				}
 			}
+			if watch && msgOutputFormat != formatting.LogFormat {
+				return util.CommandParseError{
+					Err: fmt.Errorf("--watch only supports the %s output format", formatting.LogFormat),
+				}
+			}
+
+			plugins, err := plugin.Load(analyzerPlugins...)
+			if err != nil {
+				return fmt.Errorf("failed to load analyzer plugins: %v", err)
+			}
+			allAnalyzers := analyzers.All()
+			for _, p := range plugins {
+				allAnalyzers = append(allAnalyzers, p)
+			}
 
 			if listAnalyzers {
-				fmt.Print(AnalyzersAsString(analyzers.All()))
+				fmt.Print(AnalyzersAsString(allAnalyzers))
 				return nil
 			}
 
@@ -172,9 +202,9 @@ func Analyze(ctx cli.Context) *cobra.Command {
 				selectedNamespace = metav1.NamespaceDefault
 			}
 
-			combinedAnalyzers := analyzers.AllCombined()
+			combinedAnalyzers := analysis.Combine("all", allAnalyzers...)
 			if len(selectedAnalyzers) != 0 {
-				combinedAnalyzers = analyzers.NamedCombined(selectedAnalyzers...)
+				combinedAnalyzers = analyzers.NamedCombinedOf(allAnalyzers, selectedAnalyzers...)
 			}
 
 			sa := local.NewIstiodAnalyzer(combinedAnalyzers,
@@ -197,6 +227,12 @@ func Analyze(ctx cli.Context) *cobra.Command {
 						break
 					}
 				}
+				for _, p := range plugins {
+					if p.MessageType().Code() == parts[0] {
+						codeIsValid = true
+						break
+					}
+				}
 
 				if !codeIsValid {
 					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: Supplied message code '%s' is an unknown message code and will not have any effect.\n", parts[0])
@@ -322,6 +358,31 @@ func Analyze(ctx cli.Context) *cobra.Command {
 				}
 			}
 
+			if fix {
+				// Keep machine readable output formats parsable
+				w := cmd.OutOrStdout()
+				if msgOutputFormat != formatting.LogFormat {
+					w = cmd.ErrOrStderr()
+				}
+				if err := applyFixes(w, collectFixes(allAnalyzers, result), skipConfirmation); err != nil {
+					return err
+				}
+			}
+
+			if watch {
+				stop, cancelWatch := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+				defer cancelWatch()
+				var files []string
+				for _, r := range readers {
+					if r.Name != "-" {
+						files = append(files, r.Name)
+					}
+				}
+				fmt.Fprintln(cmd.ErrOrStderr(), "Watching for changes, press Ctrl+C to stop.")
+				w := newAnalysisWatcher(sa, cmd.OutOrStdout(), colorize, outputThreshold.Level, result)
+				return w.run(files, useKube, stop.Done())
+			}
+
 			// Return code is based on the unfiltered validation message list/parse errors
 			// We're intentionally keeping failure threshold and output threshold decoupled for now
 			var returnError error
@@ -371,6 +432,17 @@ func Analyze(ctx cli.Context) *cobra.Command {
 	analysisCmd.PersistentFlags().StringArrayVarP(&selectedAnalyzers, "analyzer", "", []string{},
 		"Select specific analyzers to run. Can be repeated. If not specified, all analyzers are run. "+
 			"(e.g. istioctl analyze --analyzer \"gateway.ConflictingGatewayAnalyzer\")")
+	analysisCmd.PersistentFlags().StringArrayVar(&analyzerPlugins, "analyzer-plugin", []string{},
+		"Files declaring additional analyzers, whose rules are CEL expressions. Can be repeated. "+
+			"The declared analyzers run with the built-in ones, and can be selected with --analyzer.")
+	analysisCmd.PersistentFlags().BoolVar(&fix, "fix", false,
+		"Fix the issues which have an automatic remediation. Resources read from files are fixed in place, after "+
+			"showing the diff of the files. For cluster resources, the kubectl commands patching them are printed.")
+	analysisCmd.PersistentFlags().BoolVarP(&skipConfirmation, "skip-confirmation", "y", false,
+		"Apply the fixes to files without asking for confirmation.")
+	analysisCmd.PersistentFlags().BoolVar(&watch, "watch", false,
+		"Keep watching the files, and the cluster with --use-kube, and re-analyze the changed resources. "+
+			"Only the issues which appear or are resolved are printed.")
 	return analysisCmd
 }
 
@@ -381,7 +453,7 @@ func gatherFiles(cmd *cobra.Command, args []string) ([]local.ReaderSource, error
 
 		// Handle "-" as stdin as a special case.
 		if f == "-" {
-			if isatty.IsTerminal(os.Stdin.Fd()) && !isJSONorYAMLOutputFormat() {
+			if isatty.IsTerminal(os.Stdin.Fd()) && !isMachineReadableOutputFormat() {
 				fmt.Fprint(cmd.OutOrStdout(), "Reading from stdin:\n")
 			}
 			r = os.Stdin
@@ -514,9 +586,11 @@ func analyzeTargetAsString() string {
 	return fmt.Sprintf("namespace: %s", selectedNamespace)
 }
 
+// isMachineReadableOutputFormat returns whether the output is a report, such as JSON, YAML, SARIF or JUnit, which must
+// not be mixed with progress text.
 // TODO: Refactor output writer so that it is smart enough to know when to output what.
-func isJSONorYAMLOutputFormat() bool {
-	return msgOutputFormat == formatting.JSONFormat || msgOutputFormat == formatting.YAMLFormat
+func isMachineReadableOutputFormat() bool {
+	return msgOutputFormat != formatting.LogFormat
 }
 
 type Client struct {
@@ -21,6 +21,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/util/formatting"
 	"istio.io/istio/istioctl/pkg/util/testutil"
 	"istio.io/istio/pkg/config/analysis/diag"
 )
@@ -123,3 +124,15 @@ func TestRunSpecificAnalyzer(t *testing.T) {
 		})
 	}
 }
+
+func TestIsMachineReadableOutputFormat(t *testing.T) {
+	g := NewWithT(t)
+	defer func(f string) { msgOutputFormat = f }(msgOutputFormat)
+
+	for _, f := range []string{formatting.JSONFormat, formatting.YAMLFormat, formatting.SARIFFormat, formatting.JUnitFormat} {
+		msgOutputFormat = f
+		g.Expect(isMachineReadableOutputFormat()).To(BeTrue(), f)
+	}
+	msgOutputFormat = formatting.LogFormat
+	g.Expect(isMachineReadableOutputFormat()).To(BeFalse())
+}
@@ -0,0 +1,401 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyze
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	yamlv3 "sigs.k8s.io/yaml/goyaml.v3"
+
+	"istio.io/istio/istioctl/pkg/util"
+	"istio.io/istio/pkg/config/analysis"
+	legacykube "istio.io/istio/pkg/config/analysis/legacy/source/kube"
+	"istio.io/istio/pkg/config/analysis/local"
+	"istio.io/istio/pkg/config/resource"
+)
+
+// resourceFix is the fix of the issues found in a resource.
+type resourceFix struct {
+	resource *resource.Instance
+	patch    []analysis.PatchOperation
+}
+
+// file returns the file the resource was read from, or an empty string for cluster resources.
+func (f *resourceFix) file() string {
+	p, ok := f.resource.Origin.Reference().(*legacykube.Position)
+	if !ok || p == nil || p.Filename == "-" {
+		return ""
+	}
+	return p.Filename
+}
+
+// collectFixes returns the fixes of the issues reported by the analyzers which can remediate them.
+func collectFixes(analyzers []analysis.Analyzer, result local.AnalysisResult) []*resourceFix {
+	remediators := map[string]analysis.Remediator{}
+	for _, a := range analyzers {
+		if r, ok := a.(analysis.Remediator); ok {
+			remediators[a.Metadata().Name] = r
+		}
+	}
+	names := make([]string, 0, len(result.MappedMessages))
+	for name := range result.MappedMessages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var fixes []*resourceFix
+	byResource := map[string]*resourceFix{}
+	for _, name := range names {
+		r, ok := remediators[name]
+		if !ok {
+			continue
+		}
+		for _, m := range result.MappedMessages[name] {
+			patch := r.Remediate(m)
+			if len(patch) == 0 {
+				continue
+			}
+			key := m.Resource.Origin.FriendlyName() + "@" + m.Resource.Origin.ClusterName().String()
+			if ref := m.Resource.Origin.Reference(); ref != nil {
+				key += "@" + ref.String()
+			}
+			f, ok := byResource[key]
+			if !ok {
+				f = &resourceFix{resource: m.Resource}
+				byResource[key] = f
+				fixes = append(fixes, f)
+			}
+			for _, op := range patch {
+				if !containsOperation(f.patch, op) {
+					f.patch = append(f.patch, op)
+				}
+			}
+		}
+	}
+	return fixes
+}
+
+func containsOperation(patch []analysis.PatchOperation, op analysis.PatchOperation) bool {
+	for _, o := range patch {
+		if o.Op == op.Op && o.Path == op.Path {
+			return true
+		}
+	}
+	return false
+}
+
+// applyFixes fixes the files the resources were read from, after showing the diff and asking for confirmation, and
+// prints the patches of cluster resources.
+func applyFixes(w io.Writer, fixes []*resourceFix, skipConfirmation bool) error {
+	if len(fixes) == 0 {
+		fmt.Fprintln(w, "No fixes are available for the issues found.")
+		return nil
+	}
+
+	byFile := map[string][]*resourceFix{}
+	var files []string
+	var clusterFixes []*resourceFix
+	for _, f := range fixes {
+		file := f.file()
+		// JSON files would be rewritten as YAML, so they are handled as cluster resources
+		if file == "" || filepath.Ext(file) == ".json" {
+			clusterFixes = append(clusterFixes, f)
+			continue
+		}
+		if _, ok := byFile[file]; !ok {
+			files = append(files, file)
+		}
+		byFile[file] = append(byFile[file], f)
+	}
+	sort.Strings(files)
+
+	fixed := map[string][]byte{}
+	for _, file := range files {
+		before, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		after, unmatched, err := fixFile(before, byFile[file])
+		if err != nil {
+			return fmt.Errorf("failed to fix %s: %v", file, err)
+		}
+		// Resources which could not be found in the file are patched like cluster resources
+		clusterFixes = append(clusterFixes, unmatched...)
+		if bytes.Equal(before, after) {
+			continue
+		}
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(before)),
+			B:        difflib.SplitLines(string(after)),
+			FromFile: "a/" + file,
+			ToFile:   "b/" + file,
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(w, diff)
+		fixed[file] = after
+	}
+
+	if len(fixed) > 0 {
+		if !skipConfirmation && !util.Confirm("Apply these fixes? (y/N)", w) {
+			fmt.Fprintln(w, "Fixes not applied.")
+		} else {
+			for _, file := range files {
+				if b, ok := fixed[file]; ok {
+					info, err := os.Stat(file)
+					if err != nil {
+						return err
+					}
+					if err := os.WriteFile(file, b, info.Mode().Perm()); err != nil {
+						return err
+					}
+					fmt.Fprintf(w, "Fixed %s\n", file)
+				}
+			}
+		}
+	}
+
+	for _, f := range clusterFixes {
+		cmd, err := patchCommand(f)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "# Fix %s:\n%s\n", f.resource.Origin.FriendlyName(), cmd)
+	}
+	return nil
+}
+
+// patchCommand returns the kubectl command patching a cluster resource.
+func patchCommand(f *resourceFix) (string, error) {
+	patch, err := json.Marshal(f.patch)
+	if err != nil {
+		return "", err
+	}
+	s := f.resource.Metadata.Schema
+	kind := s.Plural()
+	if s.Group() != "" {
+		kind += "." + s.Group()
+	}
+	name := f.resource.Metadata.FullName
+	cmd := fmt.Sprintf("kubectl patch %s %s", kind, name.Name)
+	if !s.IsClusterScoped() {
+		cmd += " -n " + name.Namespace.String()
+	}
+	return cmd + fmt.Sprintf(" --type=json -p '%s'", patch), nil
+}
+
+var documentSeparator = regexp.MustCompile(`(?m)^---[ \t]*(#.*)?$`)
+
+// fixFile applies the fixes to the YAML documents of the resources in content. Only the fixed documents are
+// re-encoded, keeping their comments. The fixes of resources not found in content are returned.
+func fixFile(content []byte, fixes []*resourceFix) ([]byte, []*resourceFix, error) {
+	// Split the documents, keeping the separators to join them back unchanged
+	var docs [][]byte
+	start := 0
+	for _, loc := range documentSeparator.FindAllIndex(content, -1) {
+		docs = append(docs, content[start:loc[0]], content[loc[0]:loc[1]])
+		start = loc[1]
+	}
+	docs = append(docs, content[start:])
+
+	var unmatched []*resourceFix
+	for _, f := range fixes {
+		found := false
+		for i := 0; i < len(docs); i += 2 {
+			node := &yamlv3.Node{}
+			if err := yamlv3.Unmarshal(docs[i], node); err != nil || len(node.Content) == 0 {
+				continue
+			}
+			if !matchesResource(node.Content[0], f.resource) {
+				continue
+			}
+			found = true
+			for _, op := range f.patch {
+				if err := applyOperation(node.Content[0], op); err != nil {
+					return nil, nil, fmt.Errorf("%s: %v", f.resource.Origin.FriendlyName(), err)
+				}
+			}
+			fixedDoc, err := encodeDocument(node, hasCompactSequences(docs[i]))
+			if err != nil {
+				return nil, nil, err
+			}
+			// Keep the blank line between the separator and the document
+			leading := docs[i][:len(docs[i])-len(bytes.TrimLeft(docs[i], "\n"))]
+			docs[i] = append(append([]byte{}, leading...), fixedDoc...)
+			break
+		}
+		if !found {
+			unmatched = append(unmatched, f)
+		}
+	}
+	return bytes.Join(docs, nil), unmatched, nil
+}
+
+// hasCompactSequences returns true if the sequences of the YAML document are indented like their parent key.
+func hasCompactSequences(doc []byte) bool {
+	lines := strings.Split(string(doc), "\n")
+	for i := 1; i < len(lines); i++ {
+		item := strings.TrimLeft(lines[i], " ")
+		if !strings.HasPrefix(item, "- ") {
+			continue
+		}
+		parent := strings.TrimLeft(lines[i-1], " ")
+		if strings.HasSuffix(parent, ":") && len(lines[i-1])-len(parent) == len(lines[i])-len(item) {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeDocument(node *yamlv3.Node, compact bool) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if compact {
+		enc.CompactSeqIndent()
+	}
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// matchesResource returns true if the YAML object is the resource.
+func matchesResource(obj *yamlv3.Node, r *resource.Instance) bool {
+	value := func(path ...string) string {
+		n := obj
+		for _, p := range path {
+			if n = mappingValue(n, p); n == nil {
+				return ""
+			}
+		}
+		return n.Value
+	}
+	s := r.Metadata.Schema
+	group, _, _ := strings.Cut(value("apiVersion"), "/")
+	if !strings.Contains(value("apiVersion"), "/") {
+		group = ""
+	}
+	if value("kind") != s.Kind() || group != s.Group() || value("metadata", "name") != r.Metadata.FullName.Name.String() {
+		return false
+	}
+	ns := value("metadata", "namespace")
+	return s.IsClusterScoped() || ns == "" || ns == r.Metadata.FullName.Namespace.String()
+}
+
+func mappingValue(n *yamlv3.Node, key string) *yamlv3.Node {
+	if n.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// applyOperation applies a JSON patch operation to a YAML object.
+func applyOperation(obj *yamlv3.Node, op analysis.PatchOperation) error {
+	tokens := strings.Split(strings.TrimPrefix(op.Path, "/"), "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	parent := obj
+	for _, t := range tokens[:len(tokens)-1] {
+		parent = child(parent, t)
+		if parent == nil {
+			return fmt.Errorf("path %s not found", op.Path)
+		}
+	}
+	last := tokens[len(tokens)-1]
+
+	var value *yamlv3.Node
+	if op.Op == "add" || op.Op == "replace" {
+		value = &yamlv3.Node{}
+		if err := value.Encode(op.Value); err != nil {
+			return err
+		}
+	}
+
+	switch parent.Kind {
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(parent.Content); i += 2 {
+			if parent.Content[i].Value != last {
+				continue
+			}
+			if op.Op == "remove" {
+				parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			} else {
+				// Keep the comments of the replaced value
+				old := parent.Content[i+1]
+				value.HeadComment, value.LineComment, value.FootComment = old.HeadComment, old.LineComment, old.FootComment
+				parent.Content[i+1] = value
+			}
+			return nil
+		}
+		if op.Op != "add" {
+			return fmt.Errorf("path %s not found", op.Path)
+		}
+		parent.Content = append(parent.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: last}, value)
+	case yamlv3.SequenceNode:
+		idx := len(parent.Content)
+		if last != "-" {
+			var err error
+			if idx, err = strconv.Atoi(last); err != nil || idx < 0 || idx > len(parent.Content) {
+				return fmt.Errorf("invalid index in path %s", op.Path)
+			}
+		}
+		switch {
+		case op.Op == "add":
+			parent.Content = append(parent.Content[:idx], append([]*yamlv3.Node{value}, parent.Content[idx:]...)...)
+		case idx == len(parent.Content):
+			return fmt.Errorf("path %s not found", op.Path)
+		case op.Op == "remove":
+			parent.Content = append(parent.Content[:idx], parent.Content[idx+1:]...)
+		default:
+			parent.Content[idx] = value
+		}
+	default:
+		return fmt.Errorf("path %s not found", op.Path)
+	}
+	return nil
+}
+
+func child(n *yamlv3.Node, token string) *yamlv3.Node {
+	if n.Kind == yamlv3.SequenceNode {
+		idx, err := strconv.Atoi(token)
+		if err != nil || idx < 0 || idx >= len(n.Content) {
+			return nil
+		}
+		return n.Content[idx]
+	}
+	return mappingValue(n, token)
+}
@@ -0,0 +1,142 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyze
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/pkg/config/analysis"
+	legacykube "istio.io/istio/pkg/config/analysis/legacy/source/kube"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+func newFix(schema string, namespace, name, file string, patch ...analysis.PatchOperation) *resourceFix {
+	s := collections.VirtualService
+	if schema == "Service" {
+		s = collections.Service
+	}
+	fullName := resource.NewFullName(resource.Namespace(namespace), resource.LocalName(name))
+	return &resourceFix{
+		resource: &resource.Instance{
+			Metadata: resource.Metadata{Schema: s, FullName: fullName},
+			Origin: &legacykube.Origin{
+				Type:     s.GroupVersionKind(),
+				FullName: fullName,
+				Ref:      &legacykube.Position{Filename: file, Line: 1},
+			},
+		},
+		patch: patch,
+	}
+}
+
+func TestFixFile(t *testing.T) {
+	g := NewWithT(t)
+
+	content := `# Reviews routing
+apiVersion: networking.istio.io/v1
+kind: VirtualService
+metadata:
+  name: reviews
+  namespace: default
+spec:
+  hosts:
+  - reviews # the reviews service
+  http:
+  - route:
+    - destination:
+        host: reviews
+        subset: v3
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: reviews
+spec:
+    ports:
+    - port: 80 # unnamed
+`
+	fixes := []*resourceFix{
+		newFix("VirtualService", "default", "reviews", "reviews.yaml",
+			analysis.PatchOperation{Op: "remove", Path: "/spec/http/0/route/0/destination/subset"}),
+		newFix("Service", "default", "reviews", "reviews.yaml",
+			analysis.PatchOperation{Op: "add", Path: "/spec/ports/0/name", Value: "http"}),
+		newFix("VirtualService", "default", "ratings", "reviews.yaml",
+			analysis.PatchOperation{Op: "remove", Path: "/spec/http/0/route/0/destination/subset"}),
+	}
+
+	fixed, unmatched, err := fixFile([]byte(content), fixes)
+	g.Expect(err).To(BeNil())
+	g.Expect(string(fixed)).To(Equal(`# Reviews routing
+apiVersion: networking.istio.io/v1
+kind: VirtualService
+metadata:
+  name: reviews
+  namespace: default
+spec:
+  hosts:
+  - reviews # the reviews service
+  http:
+  - route:
+    - destination:
+        host: reviews
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: reviews
+spec:
+  ports:
+  - port: 80 # unnamed
+    name: http
+`))
+	g.Expect(unmatched).To(Equal(fixes[2:]))
+}
+
+func TestFixFileInvalidPath(t *testing.T) {
+	g := NewWithT(t)
+
+	content := `apiVersion: networking.istio.io/v1
+kind: VirtualService
+metadata:
+  name: reviews
+spec:
+  hosts:
+  - reviews
+`
+	_, _, err := fixFile([]byte(content), []*resourceFix{
+		newFix("VirtualService", "default", "reviews", "reviews.yaml",
+			analysis.PatchOperation{Op: "remove", Path: "/spec/http/0/route/0/destination/subset"}),
+	})
+	g.Expect(err).To(MatchError(ContainSubstring("path /spec/http/0/route/0/destination/subset not found")))
+}
+
+func TestPatchCommand(t *testing.T) {
+	g := NewWithT(t)
+
+	cmd, err := patchCommand(newFix("VirtualService", "default", "reviews", "",
+		analysis.PatchOperation{Op: "remove", Path: "/spec/http/0/route/0/destination/subset"}))
+	g.Expect(err).To(BeNil())
+	g.Expect(cmd).To(Equal(`kubectl patch virtualservices.networking.istio.io reviews -n default --type=json ` +
+		`-p '[{"op":"remove","path":"/spec/http/0/route/0/destination/subset"}]'`))
+
+	cmd, err = patchCommand(newFix("Service", "default", "reviews", "",
+		analysis.PatchOperation{Op: "add", Path: "/spec/ports/0/name", Value: "http"}))
+	g.Expect(err).To(BeNil())
+	g.Expect(cmd).To(Equal(`kubectl patch services reviews -n default --type=json ` +
+		`-p '[{"op":"add","path":"/spec/ports/0/name","value":"http"}]'`))
+}
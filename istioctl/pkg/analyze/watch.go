@@ -0,0 +1,190 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyze
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"istio.io/istio/istioctl/pkg/util/formatting"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/analysis/local"
+	"istio.io/istio/pkg/filewatcher"
+	"istio.io/istio/pkg/util/concurrent"
+	"istio.io/istio/pkg/util/sets"
+)
+
+const (
+	// Changes are re-analyzed once quiet for watchDebounceMin, so that saving several files is analyzed at once.
+	watchDebounceMin = 300 * time.Millisecond
+	watchDebounceMax = 3 * time.Second
+)
+
+// analysisWatcher re-analyzes the resources whose kinds changed, printing the messages which appear and the ones
+// which are resolved.
+type analysisWatcher struct {
+	sa        *local.IstiodAnalyzer
+	out       io.Writer
+	colorize  bool
+	threshold diag.Level
+
+	// messages are the messages of the last analysis, by analyzer and by key.
+	messages map[string]map[string]diag.Message
+}
+
+func newAnalysisWatcher(sa *local.IstiodAnalyzer, out io.Writer, colorize bool, threshold diag.Level,
+	result local.AnalysisResult,
+) *analysisWatcher {
+	w := &analysisWatcher{
+		sa:        sa,
+		out:       out,
+		colorize:  colorize,
+		threshold: threshold,
+		messages:  map[string]map[string]diag.Message{},
+	}
+	for analyzer, msgs := range result.MappedMessages {
+		w.messages[analyzer] = w.index(msgs)
+	}
+	return w
+}
+
+// messageKey identifies a message independently of its line, which changes with unrelated edits of its file.
+func messageKey(m diag.Message) string {
+	var origin string
+	if m.Resource != nil {
+		origin = m.Resource.Origin.FriendlyName() + "@" + m.Resource.Origin.ClusterName().String()
+	}
+	return m.Type.Code() + "/" + origin + "/" + fmt.Sprintf(m.Type.Template(), m.Parameters...)
+}
+
+func (w *analysisWatcher) index(msgs diag.Messages) map[string]diag.Message {
+	res := map[string]diag.Message{}
+	for _, m := range msgs.FilterOutLowerThan(w.threshold) {
+		res[messageKey(m)] = m
+	}
+	return res
+}
+
+// run watches the files, and the cluster resources if watchCluster is set, until stop is closed.
+func (w *analysisWatcher) run(files []string, watchCluster bool, stop <-chan struct{}) error {
+	kinds := make(chan config.GroupVersionKind, 100)
+	if watchCluster {
+		for _, s := range w.sa.Schemas().All() {
+			kind := s.GroupVersionKind()
+			w.sa.RegisterEventHandler(kind, func(config.Config, config.Config, model.Event) {
+				kinds <- kind
+			})
+		}
+	}
+
+	fw := filewatcher.NewWatcher()
+	defer fw.Close()
+	for _, f := range files {
+		if err := fw.Add(f); err != nil {
+			return fmt.Errorf("failed to watch %s: %v", f, err)
+		}
+		events, errors := fw.Events(f), fw.Errors(f)
+		go func() {
+			for {
+				select {
+				case <-stop:
+					return
+				case _, ok := <-events:
+					if !ok {
+						return
+					}
+					w.reload(f, kinds)
+				case err, ok := <-errors:
+					if !ok {
+						return
+					}
+					fmt.Fprintf(w.out, "Error watching %s: %v\n", f, err)
+				}
+			}
+		}()
+	}
+
+	db := concurrent.Debouncer[config.GroupVersionKind]{}
+	db.Run(kinds, stop, watchDebounceMin, watchDebounceMax, func(changed sets.Set[config.GroupVersionKind]) {
+		w.reanalyze(changed, stop)
+	})
+	return nil
+}
+
+// reload applies the new content of a file, and queues the kinds of the changed resources for re-analysis.
+func (w *analysisWatcher) reload(file string, kinds chan<- config.GroupVersionKind) {
+	// A removed file has no resources
+	b, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(w.out, "Error reading %s: %v\n", file, err)
+		return
+	}
+	changed, err := w.sa.UpdateReaderKubeSource([]local.ReaderSource{{Name: file, Reader: bytes.NewReader(b)}})
+	if err != nil {
+		fmt.Fprintf(w.out, "Error(s) adding %s: %v\n", file, err)
+	}
+	for k := range changed {
+		kinds <- k
+	}
+}
+
+// reanalyze runs the analyzers using the changed kinds, and prints the messages which appeared and were resolved.
+func (w *analysisWatcher) reanalyze(changed sets.Set[config.GroupVersionKind], stop <-chan struct{}) {
+	result, err := w.sa.ReAnalyzeSubset(changed, stop)
+	if err != nil {
+		fmt.Fprintf(w.out, "Error analyzing changes: %v\n", err)
+		return
+	}
+
+	var appeared, resolved diag.Messages
+	for _, analyzer := range result.ExecutedAnalyzers {
+		msgs := w.index(result.MappedMessages[analyzer])
+		for k, m := range msgs {
+			if _, f := w.messages[analyzer][k]; !f {
+				appeared = append(appeared, m)
+			}
+		}
+		for k, m := range w.messages[analyzer] {
+			if _, f := msgs[k]; !f {
+				resolved = append(resolved, m)
+			}
+		}
+		w.messages[analyzer] = msgs
+	}
+
+	kinds := make([]string, 0, len(changed))
+	for k := range changed {
+		kinds = append(kinds, k.Kind)
+	}
+	sort.Strings(kinds)
+	fmt.Fprintf(w.out, "[%s] Analyzed changes to %s: %d new, %d resolved\n",
+		time.Now().Format(time.TimeOnly), strings.Join(kinds, ", "), len(appeared), len(resolved))
+	w.print("+ ", appeared.SortedDedupedCopy())
+	w.print("- ", resolved.SortedDedupedCopy())
+}
+
+func (w *analysisWatcher) print(prefix string, msgs diag.Messages) {
+	for _, m := range msgs {
+		s, _ := formatting.Print(diag.Messages{m}, formatting.LogFormat, w.colorize)
+		fmt.Fprintln(w.out, prefix+s)
+	}
+}
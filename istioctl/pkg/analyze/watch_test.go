@@ -0,0 +1,120 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyze
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/virtualservice"
+	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/analysis/local"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/sets"
+)
+
+const watchedVirtualService = `apiVersion: networking.istio.io/v1
+kind: VirtualService
+metadata:
+  name: reviews
+  namespace: default
+spec:
+  hosts:
+  - reviews
+  http:
+  - route:
+    - destination:
+        host: reviews
+        subset: v2
+`
+
+const watchedDestinationRule = `apiVersion: networking.istio.io/v1
+kind: DestinationRule
+metadata:
+  name: reviews
+  namespace: default
+spec:
+  host: reviews
+  subsets:
+  - name: %s
+    labels:
+      version: %s
+`
+
+func TestAnalysisWatcher(t *testing.T) {
+	dir := t.TempDir()
+	vsFile := filepath.Join(dir, "vs.yaml")
+	drFile := filepath.Join(dir, "dr.yaml")
+	dr := func(subset string) string {
+		return strings.ReplaceAll(watchedDestinationRule, "%s", subset)
+	}
+	assert.NoError(t, os.WriteFile(vsFile, []byte(watchedVirtualService), 0o644))
+	assert.NoError(t, os.WriteFile(drFile, []byte(dr("v1")), 0o644))
+
+	sa := local.NewIstiodAnalyzer(analysis.Combine("test", &virtualservice.DestinationRuleAnalyzer{}), "", "istio-system", nil)
+	readers := []local.ReaderSource{}
+	for _, f := range []string{vsFile, drFile} {
+		r, err := os.Open(f)
+		assert.NoError(t, err)
+		defer r.Close()
+		readers = append(readers, local.ReaderSource{Name: f, Reader: r})
+	}
+	assert.NoError(t, sa.AddReaderKubeSource(readers))
+	stop := make(chan struct{})
+	defer close(stop)
+	result, err := sa.Analyze(stop)
+	assert.NoError(t, err)
+	assert.Equal(t, len(result.Messages), 1)
+
+	out := &bytes.Buffer{}
+	w := newAnalysisWatcher(sa, out, false, diag.Info, result)
+	update := func(content string) (sets.Set[config.GroupVersionKind], string) {
+		t.Helper()
+		out.Reset()
+		assert.NoError(t, os.WriteFile(drFile, []byte(content), 0o644))
+		kinds := make(chan config.GroupVersionKind, 10)
+		w.reload(drFile, kinds)
+		close(kinds)
+		changed := sets.New[config.GroupVersionKind]()
+		for k := range kinds {
+			changed.Insert(k)
+		}
+		if len(changed) > 0 {
+			w.reanalyze(changed, stop)
+		}
+		return changed, out.String()
+	}
+
+	// Fixing the subset resolves the message
+	changed, got := update(dr("v2"))
+	assert.Equal(t, changed, sets.New(gvk.DestinationRule))
+	assert.Equal(t, strings.Contains(got, "Analyzed changes to DestinationRule: 0 new, 1 resolved"), true)
+	assert.Equal(t, strings.Contains(got, `- Error [IST0101] (VirtualService default/reviews `), true)
+
+	// Unrelated changes of the file do not report the message again
+	_, got = update("# reviews\n" + dr("v2"))
+	assert.Equal(t, strings.Contains(got, "0 new, 0 resolved"), true)
+
+	// Breaking it again reports it as new
+	_, got = update(dr("v3"))
+	assert.Equal(t, strings.Contains(got, "1 new, 0 resolved"), true)
+	assert.Equal(t, strings.Contains(got, `+ Error [IST0101] (VirtualService default/reviews `), true)
+}
@@ -0,0 +1,204 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caaudit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/security/pkg/server/ca/audit"
+)
+
+// maxRecordSize is the max size of a record line.
+const maxRecordSize = 1024 * 1024
+
+// Cmd returns the ca-audit command.
+func Cmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ca-audit",
+		Short: "Inspect the certificate issuance audit log of the Istio CA",
+		Long: `Inspect the certificate issuance audit log written by istiod, when the CA_AUDIT_SINKS environment variable ` +
+			`has a 'file:<path>' sink.`,
+		Args: cobra.NoArgs,
+	}
+	cmd.AddCommand(searchCmd())
+	return cmd
+}
+
+type searchOptions struct {
+	identity string
+	serial   string
+	outcome  string
+	output   string
+}
+
+func searchCmd() *cobra.Command {
+	opts := searchOptions{}
+	cmd := &cobra.Command{
+		Use:   "search FILE...",
+		Short: "Search the certificate issuance audit log by identity or serial number",
+		Long: `Search the certificate issuance audit log by identity or serial number.
+An identity matches the records where it is the caller, the impersonated identity, or a requested or granted SAN.`,
+		Example: `  # Find the certificate requests of a service account, including in rotated files
+  istioctl experimental ca-audit search ca-audit*.log --identity spiffe://cluster.local/ns/default/sa/productpage
+
+  # Find the request which issued a certificate, with the serial number as printed by openssl
+  istioctl experimental ca-audit search ca-audit.log --serial 5F:3A:91:0C
+
+  # Print the denied requests as JSON
+  istioctl experimental ca-audit search ca-audit.log --outcome denied -o json`,
+		Args: cobra.MinimumNArgs(1),
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if opts.output != "table" && opts.output != "json" {
+				return fmt.Errorf("unknown output format %q, must be table or json", opts.output)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, files []string) error {
+			var records []*audit.Record
+			for _, f := range files {
+				r, err := readRecords(f, opts.matches, cmd.ErrOrStderr())
+				if err != nil {
+					return err
+				}
+				records = append(records, r...)
+			}
+			if opts.output == "json" {
+				return printJSON(cmd.OutOrStdout(), records)
+			}
+			printTable(cmd.OutOrStdout(), records)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opts.identity, "identity", "", "Only show the records involving this identity")
+	cmd.Flags().StringVar(&opts.serial, "serial", "", "Only show the record of the certificate with this serial number, in hex")
+	cmd.Flags().StringVar(&opts.outcome, "outcome", "", "Only show the records with this outcome: issued, denied or failed")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", "table", "Output format: table or json")
+	return cmd
+}
+
+// matches returns whether a record matches the search options.
+func (o searchOptions) matches(r *audit.Record) bool {
+	if o.outcome != "" && !strings.EqualFold(o.outcome, string(r.Outcome)) {
+		return false
+	}
+	if o.serial != "" && normalizeSerial(o.serial) != normalizeSerial(r.SerialNumber) {
+		return false
+	}
+	if o.identity != "" {
+		found := false
+		for _, id := range r.Identities() {
+			if id == o.identity {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeSerial normalizes a hex serial number, which may be colon separated with leading zeros.
+func normalizeSerial(s string) string {
+	s = strings.ToLower(strings.ReplaceAll(s, ":", ""))
+	s = strings.TrimLeft(s, "0")
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+// readRecords reads the records of file matching match. Lines which are not records are reported to warn, and skipped.
+func readRecords(file string, match func(*audit.Record) bool, warn io.Writer) ([]*audit.Record, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var records []*audit.Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		r := &audit.Record{}
+		if err := json.Unmarshal(scanner.Bytes(), r); err != nil {
+			_, _ = fmt.Fprintf(warn, "warning: %s:%d: invalid record: %v\n", file, line, err)
+			continue
+		}
+		if match(r) {
+			records = append(records, r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return records, nil
+}
+
+func printJSON(w io.Writer, records []*audit.Record) error {
+	for _, r := range records {
+		b, err := audit.Marshal(r)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printTable(w io.Writer, records []*audit.Record) {
+	tw := new(tabwriter.Writer).Init(w, 0, 8, 3, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "TIME\tOUTCOME\tSERIAL\tSANS\tTTL\tCALLER\tREASON")
+	for _, r := range records {
+		sans := r.GrantedSANs
+		if len(sans) == 0 {
+			sans = r.RequestedSANs
+		}
+		caller := strings.Join(r.CallerIdentities, ",")
+		if r.ImpersonatedIdentity != "" {
+			caller += " (for " + r.ImpersonatedIdentity + ")"
+		}
+		ttl := r.GrantedTTL
+		if ttl == "" {
+			ttl = r.RequestedTTL
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Time.Format("2006-01-02T15:04:05Z07:00"), r.Outcome,
+			valueOrNone(r.SerialNumber), valueOrNone(strings.Join(sans, ",")), valueOrNone(ttl), valueOrNone(caller),
+			r.Reason)
+	}
+	_ = tw.Flush()
+}
+
+func valueOrNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,130 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caaudit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/security/pkg/server/ca/audit"
+)
+
+const (
+	appIdentity = "spiffe://cluster.local/ns/default/sa/app"
+	nodeAgent   = "spiffe://cluster.local/ns/istio-system/sa/ztunnel"
+)
+
+func writeLog(t *testing.T, records ...*audit.Record) string {
+	var b []byte
+	for _, r := range records {
+		line, err := audit.Marshal(r)
+		assert.NoError(t, err)
+		b = append(b, line...)
+	}
+	// Invalid lines are skipped
+	b = append(b, []byte("not a record\n")...)
+	path := filepath.Join(t.TempDir(), "ca-audit.log")
+	assert.NoError(t, os.WriteFile(path, b, 0o600))
+	return path
+}
+
+func TestSearch(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	path := writeLog(t,
+		&audit.Record{
+			Time: now, Outcome: audit.Issued, CallerIdentities: []string{appIdentity},
+			GrantedSANs: []string{appIdentity}, GrantedTTL: "24h0m0s", SerialNumber: "5f3a910c",
+		},
+		&audit.Record{
+			Time: now, Outcome: audit.Issued, CallerIdentities: []string{nodeAgent}, ImpersonatedIdentity: appIdentity,
+			GrantedSANs: []string{appIdentity}, GrantedTTL: "24h0m0s", SerialNumber: "a1",
+		},
+		&audit.Record{
+			Time: now, Outcome: audit.Denied, Reason: "authentication failure",
+			RequestedSANs: []string{"spiffe://cluster.local/ns/other/sa/app"}, RequestedTTL: "24h0m0s",
+		},
+	)
+	cases := []struct {
+		name    string
+		args    []string
+		serials []string
+	}{
+		{name: "all", args: nil, serials: []string{"5f3a910c", "a1", ""}},
+		{name: "caller identity", args: []string{"--identity", nodeAgent}, serials: []string{"a1"}},
+		{name: "granted identity", args: []string{"--identity", appIdentity}, serials: []string{"5f3a910c", "a1"}},
+		{name: "openssl serial", args: []string{"--serial", "5F:3A:91:0C"}, serials: []string{"5f3a910c"}},
+		{name: "leading zeros", args: []string{"--serial", "00:A1"}, serials: []string{"a1"}},
+		{name: "outcome", args: []string{"--outcome", "denied"}, serials: []string{""}},
+		{name: "no match", args: []string{"--serial", "ff"}, serials: nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := Cmd()
+			out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+			cmd.SetOut(out)
+			cmd.SetErr(errOut)
+			cmd.SetArgs(append([]string{"search", path, "-o", "json"}, tc.args...))
+			assert.NoError(t, cmd.Execute())
+			assert.Equal(t, strings.Contains(errOut.String(), "ca-audit.log:4: invalid record"), true)
+
+			var serials []string
+			for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+				if line == "" {
+					continue
+				}
+				r := &audit.Record{}
+				assert.NoError(t, json.Unmarshal([]byte(line), r))
+				serials = append(serials, r.SerialNumber)
+			}
+			assert.Equal(t, serials, tc.serials)
+		})
+	}
+}
+
+func TestSearchTable(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	path := writeLog(t, &audit.Record{
+		Time: now, Outcome: audit.Issued, CallerIdentities: []string{nodeAgent}, ImpersonatedIdentity: appIdentity,
+		GrantedSANs: []string{appIdentity}, GrantedTTL: "24h0m0s", SerialNumber: "a1",
+	})
+	cmd := Cmd()
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"search", path})
+	assert.NoError(t, cmd.Execute())
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, len(lines), 2)
+	assert.Equal(t, strings.Fields(lines[0]), []string{"TIME", "OUTCOME", "SERIAL", "SANS", "TTL", "CALLER", "REASON"})
+	assert.Equal(t, strings.Fields(lines[1]), []string{
+		"2026-01-02T03:04:05Z", "ISSUED", "a1", appIdentity, "24h0m0s", nodeAgent, "(for", appIdentity + ")",
+	})
+}
+
+func TestSearchInvalidOutput(t *testing.T) {
+	cmd := Cmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"search", "ca-audit.log", "-o", "yaml"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "unknown output format") {
+		t.Fatalf("expected output format error, got %v", err)
+	}
+}
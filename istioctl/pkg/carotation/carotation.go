@@ -0,0 +1,136 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package carotation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/security/pkg/pki/ca"
+)
+
+// Cmd returns the ca-rotation command.
+func Cmd(ctx cli.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ca-rotation",
+		Short: "Inspect the rotation of the roots of the plugged-in Istio CA certs",
+		Long: `Inspect the rotation of the roots of the plugged-in Istio CA certs, when istiod is run with ` +
+			`PLUGGED_CA_ROOT_ROTATION enabled.`,
+		Args: cobra.NoArgs,
+	}
+	cmd.AddCommand(statusCmd(ctx))
+	return cmd
+}
+
+type replicaStatus struct {
+	Replica string `json:"replica"`
+	ca.RootRotationStatus
+}
+
+func statusCmd(ctx cli.Context) *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the progress of the root rotation of each istiod replica",
+		Long: `Show the progress of the root rotation of each istiod replica.
+
+A rotation goes through the stages:
+  Distributing  the previous and new roots are published, the CA signs with the previous signing cert
+  Retiring      all proxies acknowledged the new roots, the CA signs with the new signing cert
+  Completed     the previous roots were removed`,
+		Example: `  # Show the progress of the rotation
+  istioctl experimental ca-rotation status
+
+  # Show the progress of the rotation, with the proxies which did not acknowledge the new roots
+  istioctl experimental ca-rotation status -o json`,
+		Args: cobra.NoArgs,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("unknown output format %q, must be table or json", output)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			kubeClient, err := ctx.CLIClient()
+			if err != nil {
+				return err
+			}
+			cm, err := kubeClient.Kube().CoreV1().ConfigMaps(ctx.IstioNamespace()).Get(context.TODO(),
+				ca.RootRotationStatusConfigMap, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get the root rotation status: %v", err)
+			}
+			var statuses []replicaStatus
+			for replica, data := range cm.Data {
+				s := replicaStatus{Replica: replica}
+				if err := json.Unmarshal([]byte(data), &s.RootRotationStatus); err != nil {
+					return fmt.Errorf("invalid root rotation status of replica %s: %v", replica, err)
+				}
+				statuses = append(statuses, s)
+			}
+			sort.Slice(statuses, func(i, j int) bool {
+				return statuses[i].Replica < statuses[j].Replica
+			})
+			if output == "json" {
+				b, err := json.MarshalIndent(statuses, "", "  ")
+				if err != nil {
+					return err
+				}
+				_, err = fmt.Fprintln(cmd.OutOrStdout(), string(b))
+				return err
+			}
+			printTable(cmd.OutOrStdout(), statuses, time.Now())
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format: table or json")
+	return cmd
+}
+
+func printTable(w io.Writer, statuses []replicaStatus, now time.Time) {
+	tw := new(tabwriter.Writer).Init(w, 0, 8, 3, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "REPLICA\tSTAGE\tROOTS\tPROXIES SYNCED\tPENDING\tUNTRACKED\tRETIRE AT\tUPDATED\tMESSAGE")
+	for _, s := range statuses {
+		retire := "-"
+		if s.RetireTime != nil {
+			retire = s.RetireTime.Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%s\t%s ago\t%s\n", s.Replica, s.Stage, shortFingerprints(s.TargetRoots),
+			s.SyncedProxies, s.PendingProxies, s.UntrackedProxies, retire, now.Sub(s.LastUpdate).Round(time.Second), s.Message)
+	}
+	_ = tw.Flush()
+}
+
+// shortFingerprints abbreviates root fingerprints, which are distinct enough to compare replicas.
+func shortFingerprints(fingerprints []string) string {
+	if len(fingerprints) == 0 {
+		return "-"
+	}
+	short := make([]string, 0, len(fingerprints))
+	for _, f := range fingerprints {
+		short = append(short, f[:min(len(f), 12)])
+	}
+	return strings.Join(short, ",")
+}
@@ -0,0 +1,98 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package carotation
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/security/pkg/pki/ca"
+)
+
+const root = "11c9202c6be8ea5fcfb46d8591a8cf83b20eb97ade97234840851af8763ae428"
+
+func statusConfigMap(t *testing.T, statuses map[string]ca.RootRotationStatus) runtime.Object {
+	data := map[string]string{}
+	for replica, s := range statuses {
+		b, err := json.Marshal(s)
+		assert.NoError(t, err)
+		data[replica] = string(b)
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ca.RootRotationStatusConfigMap, Namespace: "istio-system"},
+		Data:       data,
+	}
+}
+
+func runStatus(t *testing.T, objects []runtime.Object, args ...string) (string, error) {
+	ctx := cli.NewFakeContext(&cli.NewFakeContextOption{IstioNamespace: "istio-system", Objects: objects})
+	cmd := Cmd(ctx)
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(append([]string{"status"}, args...))
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestStatus(t *testing.T) {
+	retire := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	objects := []runtime.Object{statusConfigMap(t, map[string]ca.RootRotationStatus{
+		"istiod-b": {
+			Stage: ca.RootRotationDistributing, TargetRoots: []string{root}, SyncedProxies: 8, PendingProxies: 2,
+			PendingProxyIDs: []string{"a.default", "b.default"}, LastUpdate: time.Now(),
+			Message: "waiting for 2 proxies to acknowledge the new roots",
+		},
+		"istiod-a": {
+			Stage: ca.RootRotationRetiring, TargetRoots: []string{root}, SyncedProxies: 10, UntrackedProxies: 1,
+			RetireTime: &retire, LastUpdate: time.Now(), Message: "signing with the new signing cert",
+		},
+	})}
+
+	out, err := runStatus(t, objects)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	assert.Equal(t, len(lines), 3)
+	assert.Equal(t, strings.Fields(lines[1])[:7], []string{
+		"istiod-a", "Retiring", "11c9202c6be8", "10", "0", "1", "2026-01-02T03:04:05Z",
+	})
+	assert.Equal(t, strings.Fields(lines[2])[:7], []string{
+		"istiod-b", "Distributing", "11c9202c6be8", "8", "2", "0", "-",
+	})
+
+	out, err = runStatus(t, objects, "-o", "json")
+	assert.NoError(t, err)
+	var statuses []replicaStatus
+	assert.NoError(t, json.Unmarshal([]byte(out), &statuses))
+	assert.Equal(t, len(statuses), 2)
+	assert.Equal(t, statuses[1].Replica, "istiod-b")
+	assert.Equal(t, statuses[1].PendingProxyIDs, []string{"a.default", "b.default"})
+}
+
+func TestStatusNotFound(t *testing.T) {
+	_, err := runStatus(t, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to get the root rotation status") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
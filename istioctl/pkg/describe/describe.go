@@ -223,6 +223,9 @@ func Cmd(ctx cli.Context) *cobra.Command {
 
 	describeCmd.AddCommand(podDescribeCmd(ctx))
 	describeCmd.AddCommand(svcDescribeCmd(ctx))
+	describeCmd.AddCommand(gatewayDescribeCmd(ctx))
+	describeCmd.AddCommand(httpRouteDescribeCmd(ctx))
+	describeCmd.AddCommand(waypointDescribeCmd(ctx))
 	return describeCmd
 }
 
@@ -0,0 +1,620 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package describe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	gateway "sigs.k8s.io/gateway-api/apis/v1"
+
+	"istio.io/api/label"
+	typev1beta1 "istio.io/api/type/v1beta1"
+	securityclient "istio.io/client-go/pkg/apis/security/v1"
+	"istio.io/istio/istioctl/pkg/cli"
+	istioctlutil "istio.io/istio/istioctl/pkg/util"
+	"istio.io/istio/istioctl/pkg/util/handlers"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/ptr"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+)
+
+func gatewayDescribeCmd(ctx cli.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gateway <gateway>",
+		Short: "Describe Gateway API gateways and their Istio configuration [kube-only]",
+		Long: `Analyzes a Gateway API Gateway, and reports its status, the Deployment and Service running it,
+the routes attached to it, and the authorization policies applied to it.`,
+		Example: `  istioctl experimental describe gateway bookinfo-gateway -n istio-ingress`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("expecting gateway name")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			describeNamespace = ctx.NamespaceOrDefault(ctx.Namespace())
+			name, ns := handlers.InferPodInfo(args[0], describeNamespace)
+			client, err := ctx.CLIClient()
+			if err != nil {
+				return err
+			}
+			gw, err := client.GatewayAPI().GatewayV1().Gateways(ns).Get(context.TODO(), name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			writer := cmd.OutOrStdout()
+
+			printGateway(writer, gw)
+			fmt.Fprintf(writer, "--------------------\n")
+			deployment, err := printGatewayWorkload(writer, client, gw)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(writer, "--------------------\n")
+			routes, err := listRoutes(client)
+			if err != nil {
+				return err
+			}
+			printAttachedRoutes(writer, routes, func(ref gateway.ParentReference, routeNamespace string) bool {
+				return refersTo(ref, routeNamespace, gvk.KubernetesGateway.Kind, gw.Name, gw.Namespace)
+			})
+			fmt.Fprintf(writer, "--------------------\n")
+
+			var podLabels klabels.Set
+			if deployment != nil {
+				podLabels = deployment.Spec.Template.Labels
+			}
+			policies, err := listAuthorizationPolicies(client, gw.Namespace, rootNamespace(ctx, client))
+			if err != nil {
+				return err
+			}
+			printAuthorizationPolicies(writer, slices.FilterInPlace(policies, func(ap *securityclient.AuthorizationPolicy) bool {
+				return policyTargetsGateway(ap, gw) || policySelects(ap, podLabels)
+			}))
+			return nil
+		},
+	}
+	cmd.Long += "\n\n" + istioctlutil.ExperimentalMsg
+	return cmd
+}
+
+func httpRouteDescribeCmd(ctx cli.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "httproute <route>",
+		Short: "Describe Gateway API HTTPRoutes and their status [kube-only]",
+		Long: `Analyzes a Gateway API HTTPRoute, and reports its parents, whether they accepted the route,
+and its rules and backends.`,
+		Example: `  istioctl experimental describe httproute reviews`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("expecting route name")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			describeNamespace = ctx.NamespaceOrDefault(ctx.Namespace())
+			name, ns := handlers.InferPodInfo(args[0], describeNamespace)
+			client, err := ctx.CLIClient()
+			if err != nil {
+				return err
+			}
+			hr, err := client.GatewayAPI().GatewayV1().HTTPRoutes(ns).Get(context.TODO(), name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			return printHTTPRoute(cmd.OutOrStdout(), client, hr)
+		},
+	}
+	cmd.Long += "\n\n" + istioctlutil.ExperimentalMsg
+	return cmd
+}
+
+func waypointDescribeCmd(ctx cli.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "waypoint <waypoint>",
+		Short: "Describe waypoints and the workloads using them [kube-only]",
+		Long: `Analyzes a waypoint, and reports its status, the Deployment and Service running it,
+the namespaces, services and workloads using it, the routes attached to its services, and
+the authorization policies applied to it.`,
+		Example: `  istioctl experimental describe waypoint waypoint -n default`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("expecting waypoint name")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			describeNamespace = ctx.NamespaceOrDefault(ctx.Namespace())
+			name, ns := handlers.InferPodInfo(args[0], describeNamespace)
+			client, err := ctx.CLIClient()
+			if err != nil {
+				return err
+			}
+			gw, err := client.GatewayAPI().GatewayV1().Gateways(ns).Get(context.TODO(), name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			if gw.Spec.GatewayClassName != constants.WaypointGatewayClassName {
+				return fmt.Errorf("gateway %s is not a waypoint: its class is %q, not %q",
+					kname(gw.ObjectMeta), gw.Spec.GatewayClassName, constants.WaypointGatewayClassName)
+			}
+			writer := cmd.OutOrStdout()
+
+			printGateway(writer, gw)
+			trafficType := gw.Labels[label.IoIstioWaypointFor.Name]
+			if trafficType == "" {
+				trafficType = constants.ServiceTraffic
+			}
+			fmt.Fprintf(writer, "%sTraffic type: %s\n", printSpaces(printLevel1), trafficType)
+			fmt.Fprintf(writer, "--------------------\n")
+			if _, err := printGatewayWorkload(writer, client, gw); err != nil {
+				return err
+			}
+			fmt.Fprintf(writer, "--------------------\n")
+			users, err := findWaypointUsers(client, gw)
+			if err != nil {
+				return err
+			}
+			printWaypointUsers(writer, users)
+			fmt.Fprintf(writer, "--------------------\n")
+			routes, err := listRoutes(client)
+			if err != nil {
+				return err
+			}
+			printAttachedRoutes(writer, routes, func(ref gateway.ParentReference, routeNamespace string) bool {
+				if refersTo(ref, routeNamespace, gvk.KubernetesGateway.Kind, gw.Name, gw.Namespace) {
+					return true
+				}
+				// Routes for the services using the waypoint are applied by the waypoint
+				if string(ptr.OrEmpty(ref.Kind)) != gvk.Service.Kind || ptr.OrEmpty(ref.Group) != "" {
+					return false
+				}
+				return users.services.Contains(string(ref.Name) + "." + string(ptr.OrDefault(ref.Namespace, gateway.Namespace(routeNamespace))))
+			})
+			fmt.Fprintf(writer, "--------------------\n")
+			policies, err := listAuthorizationPolicies(client, gw.Namespace, rootNamespace(ctx, client))
+			if err != nil {
+				return err
+			}
+			printAuthorizationPolicies(writer, slices.FilterInPlace(policies, func(ap *securityclient.AuthorizationPolicy) bool {
+				if policyTargetsGateway(ap, gw) {
+					return true
+				}
+				for _, ref := range targetRefs(ap) {
+					if ref.GetKind() == gvk.Service.Kind && users.services.Contains(ref.GetName()+"."+ap.Namespace) {
+						return true
+					}
+				}
+				return false
+			}))
+			return nil
+		},
+	}
+	cmd.Long += "\n\n" + istioctlutil.ExperimentalMsg
+	return cmd
+}
+
+// rootNamespace returns the root namespace of the mesh. If the mesh config cannot be read, the Istio namespace,
+// which is the default root namespace, is returned.
+func rootNamespace(ctx cli.Context, client kube.CLIClient) string {
+	meshCfg, err := getMeshConfig(client, ctx.IstioNamespace())
+	if err != nil {
+		return ctx.IstioNamespace()
+	}
+	return meshCfg.RootNamespace
+}
+
+func printGateway(writer io.Writer, gw *gateway.Gateway) {
+	fmt.Fprintf(writer, "Gateway: %s\n", kname(gw.ObjectMeta))
+	fmt.Fprintf(writer, "%sClass: %s\n", printSpaces(printLevel1), gw.Spec.GatewayClassName)
+	if len(gw.Status.Addresses) > 0 {
+		addresses := slices.Map(gw.Status.Addresses, func(a gateway.GatewayStatusAddress) string {
+			return a.Value
+		})
+		fmt.Fprintf(writer, "%sAddresses: %s\n", printSpaces(printLevel1), strings.Join(addresses, ", "))
+	}
+	printConditions(writer, printLevel1, gw.Status.Conditions)
+	if len(gw.Spec.Listeners) == 0 {
+		return
+	}
+	fmt.Fprintf(writer, "%sListeners:\n", printSpaces(printLevel1))
+	for _, l := range gw.Spec.Listeners {
+		var hostname string
+		if l.Hostname != nil {
+			hostname = fmt.Sprintf(", hostname %s", *l.Hostname)
+		}
+		var attached string
+		var conditions []metav1.Condition
+		for _, ls := range gw.Status.Listeners {
+			if ls.Name == l.Name {
+				attached = fmt.Sprintf(", %d attached route(s)", ls.AttachedRoutes)
+				conditions = ls.Conditions
+			}
+		}
+		fmt.Fprintf(writer, "%s%s: %d/%s%s%s\n", printSpaces(printLevel2), l.Name, l.Port, l.Protocol, hostname, attached)
+		printConditions(writer, printLevel2+3, conditions)
+	}
+}
+
+// printConditions prints the status conditions, as reported by the gateway controller.
+func printConditions(writer io.Writer, initPrintNum int, conditions []metav1.Condition) {
+	for _, c := range conditions {
+		msg := fmt.Sprintf("%s=%s", c.Type, c.Status)
+		if c.Reason != "" && c.Reason != c.Type {
+			msg += fmt.Sprintf(" (%s)", c.Reason)
+		}
+		if c.Message != "" {
+			msg += ": " + c.Message
+		}
+		fmt.Fprintf(writer, "%s%s\n", printSpaces(initPrintNum), msg)
+	}
+}
+
+// printGatewayWorkload prints the Deployment and Service deployed for gw, and returns the Deployment, if any.
+func printGatewayWorkload(writer io.Writer, client kube.CLIClient, gw *gateway.Gateway) (*appsv1.Deployment, error) {
+	selector := klabels.Set{label.IoK8sNetworkingGatewayGatewayName.Name: gw.Name}.String()
+	deployments, err := client.Kube().AppsV1().Deployments(gw.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	services, err := client.Kube().CoreV1().Services(gw.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	var deployment *appsv1.Deployment
+	if len(deployments.Items) == 0 {
+		fmt.Fprintf(writer, "No Deployment found for gateway %s (it may be deployed manually)\n", kname(gw.ObjectMeta))
+	}
+	for i, d := range deployments.Items {
+		if i == 0 {
+			deployment = &deployments.Items[i]
+		}
+		fmt.Fprintf(writer, "Deployment: %s (%d/%d replicas ready)\n", kname(d.ObjectMeta), d.Status.ReadyReplicas, ptr.OrDefault(d.Spec.Replicas, 1))
+	}
+	for _, svc := range services.Items {
+		fmt.Fprintf(writer, "Service: %s (%s)\n", kname(svc.ObjectMeta), svc.Spec.Type)
+		for _, port := range svc.Spec.Ports {
+			fmt.Fprintf(writer, "%sPort: %s %d/%s targets pod port %s\n",
+				printSpaces(printLevel1), port.Name, port.Port, findProtocolForPort(&port), port.TargetPort.String())
+		}
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			fmt.Fprintf(writer, "%sLoad balancer: %s%s\n", printSpaces(printLevel1), ingress.IP, ingress.Hostname)
+		}
+	}
+	return deployment, nil
+}
+
+// attachedRoute is the common view of the route kinds which can attach to gateways.
+type attachedRoute struct {
+	kind    string
+	meta    metav1.ObjectMeta
+	parents []gateway.ParentReference
+	status  gateway.RouteStatus
+}
+
+func listRoutes(client kube.CLIClient) ([]attachedRoute, error) {
+	var routes []attachedRoute
+	httpRoutes, err := client.GatewayAPI().GatewayV1().HTTPRoutes(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list HTTPRoutes: %v", err)
+	}
+	for _, r := range httpRoutes.Items {
+		routes = append(routes, attachedRoute{gvk.HTTPRoute.Kind, r.ObjectMeta, r.Spec.ParentRefs, r.Status.RouteStatus})
+	}
+	grpcRoutes, err := client.GatewayAPI().GatewayV1().GRPCRoutes(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list GRPCRoutes: %v", err)
+	}
+	for _, r := range grpcRoutes.Items {
+		routes = append(routes, attachedRoute{gvk.GRPCRoute.Kind, r.ObjectMeta, r.Spec.ParentRefs, r.Status.RouteStatus})
+	}
+	return routes, nil
+}
+
+// refersTo returns whether ref, from a route in routeNamespace, refers to the kind name.namespace.
+func refersTo(ref gateway.ParentReference, routeNamespace string, kind string, name string, namespace string) bool {
+	refKind := string(ptr.OrDefault(ref.Kind, gateway.Kind(gvk.KubernetesGateway.Kind)))
+	refGroup := string(ptr.OrDefault(ref.Group, gateway.Group(gvk.KubernetesGateway.Group)))
+	if kind == gvk.Service.Kind {
+		if refGroup != "" {
+			return false
+		}
+	} else if refGroup != gvk.KubernetesGateway.Group {
+		return false
+	}
+	return refKind == kind && string(ref.Name) == name &&
+		string(ptr.OrDefault(ref.Namespace, gateway.Namespace(routeNamespace))) == namespace
+}
+
+// printAttachedRoutes prints the routes having a parent matched by isParent, with the conditions reported for it.
+func printAttachedRoutes(writer io.Writer, routes []attachedRoute, isParent func(ref gateway.ParentReference, routeNamespace string) bool) {
+	attached := 0
+	for _, r := range routes {
+		refs := slices.Filter(r.parents, func(ref gateway.ParentReference) bool {
+			return isParent(ref, r.meta.Namespace)
+		})
+		if len(refs) == 0 {
+			continue
+		}
+		if attached == 0 {
+			fmt.Fprintf(writer, "Attached routes:\n")
+		}
+		attached++
+		fmt.Fprintf(writer, "%s%s %s\n", printSpaces(printLevel1), r.kind, kname(r.meta))
+		printParentStatus(writer, printLevel2, r, refs)
+	}
+	if attached == 0 {
+		fmt.Fprintf(writer, "No routes attached\n")
+	}
+}
+
+// printParentStatus prints the conditions reported for the parents refs of the route.
+func printParentStatus(writer io.Writer, initPrintNum int, r attachedRoute, refs []gateway.ParentReference) {
+	for _, ref := range refs {
+		if ref.SectionName != nil {
+			fmt.Fprintf(writer, "%sSection: %s\n", printSpaces(initPrintNum), *ref.SectionName)
+		}
+		reported := false
+		for _, ps := range r.status.Parents {
+			if parentRefEqual(ps.ParentRef, ref, r.meta.Namespace) {
+				reported = true
+				printConditions(writer, initPrintNum, ps.Conditions)
+			}
+		}
+		if !reported {
+			fmt.Fprintf(writer, "%sNo status reported: the parent may not exist, or not be managed by Istio\n", printSpaces(initPrintNum))
+		}
+	}
+}
+
+func parentRefEqual(a, b gateway.ParentReference, routeNamespace string) bool {
+	ns := gateway.Namespace(routeNamespace)
+	kind, group := gateway.Kind(gvk.KubernetesGateway.Kind), gateway.Group(gvk.KubernetesGateway.Group)
+	return ptr.OrDefault(a.Kind, kind) == ptr.OrDefault(b.Kind, kind) &&
+		ptr.OrDefault(a.Group, group) == ptr.OrDefault(b.Group, group) &&
+		a.Name == b.Name &&
+		ptr.OrDefault(a.Namespace, ns) == ptr.OrDefault(b.Namespace, ns) &&
+		ptr.OrEmpty(a.SectionName) == ptr.OrEmpty(b.SectionName) &&
+		ptr.OrEmpty(a.Port) == ptr.OrEmpty(b.Port)
+}
+
+func printHTTPRoute(writer io.Writer, client kube.CLIClient, hr *gateway.HTTPRoute) error {
+	fmt.Fprintf(writer, "HTTPRoute: %s\n", kname(hr.ObjectMeta))
+	if len(hr.Spec.Hostnames) > 0 {
+		hostnames := slices.Map(hr.Spec.Hostnames, func(h gateway.Hostname) string {
+			return string(h)
+		})
+		fmt.Fprintf(writer, "%sHostnames: %s\n", printSpaces(printLevel1), strings.Join(hostnames, ", "))
+	}
+	fmt.Fprintf(writer, "%sParents:\n", printSpaces(printLevel1))
+	r := attachedRoute{gvk.HTTPRoute.Kind, hr.ObjectMeta, hr.Spec.ParentRefs, hr.Status.RouteStatus}
+	for _, ref := range hr.Spec.ParentRefs {
+		ns := ptr.OrDefault(ref.Namespace, gateway.Namespace(hr.Namespace))
+		fmt.Fprintf(writer, "%s%s %s\n", printSpaces(printLevel2),
+			ptr.OrDefault(ref.Kind, gateway.Kind(gvk.KubernetesGateway.Kind)), kname(metav1.ObjectMeta{Name: string(ref.Name), Namespace: string(ns)}))
+		printParentStatus(writer, printLevel2+3, r, []gateway.ParentReference{ref})
+	}
+
+	fmt.Fprintf(writer, "%sRules:\n", printSpaces(printLevel1))
+	for _, rule := range hr.Spec.Rules {
+		matches := slices.Map(rule.Matches, renderHTTPRouteMatch)
+		if len(matches) == 0 {
+			matches = []string{"PathPrefix /"}
+		}
+		fmt.Fprintf(writer, "%sMatch: %s\n", printSpaces(printLevel2), strings.Join(matches, " OR "))
+		for _, backend := range rule.BackendRefs {
+			msg, err := renderBackend(client, backend.BackendRef, hr.Namespace)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(writer, "%sBackend: %s\n", printSpaces(printLevel2+3), msg)
+		}
+		if len(rule.BackendRefs) == 0 {
+			fmt.Fprintf(writer, "%sNo backends\n", printSpaces(printLevel2+3))
+		}
+	}
+	return nil
+}
+
+func renderHTTPRouteMatch(m gateway.HTTPRouteMatch) string {
+	var parts []string
+	if m.Path != nil {
+		parts = append(parts, fmt.Sprintf("%s %s", ptr.OrDefault(m.Path.Type, gateway.PathMatchPathPrefix), ptr.OrDefault(m.Path.Value, "/")))
+	}
+	if m.Method != nil {
+		parts = append(parts, "Method "+string(*m.Method))
+	}
+	for _, h := range m.Headers {
+		parts = append(parts, fmt.Sprintf("Header %s=%s", h.Name, h.Value))
+	}
+	for _, q := range m.QueryParams {
+		parts = append(parts, fmt.Sprintf("QueryParam %s=%s", q.Name, q.Value))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// renderBackend renders a backend of a route, checking that the Service it refers to exists.
+func renderBackend(client kube.CLIClient, backend gateway.BackendRef, routeNamespace string) (string, error) {
+	kind := string(ptr.OrDefault(backend.Kind, gateway.Kind(gvk.Service.Kind)))
+	ns := string(ptr.OrDefault(backend.Namespace, gateway.Namespace(routeNamespace)))
+	msg := fmt.Sprintf("%s %s", kind, kname(metav1.ObjectMeta{Name: string(backend.Name), Namespace: ns}))
+	if backend.Port != nil {
+		msg += fmt.Sprintf(":%d", *backend.Port)
+	}
+	if backend.Weight != nil {
+		msg += fmt.Sprintf(" (weight %d)", *backend.Weight)
+	}
+	if kind != gvk.Service.Kind || ptr.OrEmpty(backend.Group) != "" {
+		return msg, nil
+	}
+	_, err := client.Kube().CoreV1().Services(ns).Get(context.TODO(), string(backend.Name), metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return msg + " - WARNING: Service not found", nil
+	}
+	return msg, err
+}
+
+// waypointUsers are the resources using a waypoint, as name.namespace for services and workloads.
+type waypointUsers struct {
+	namespaces sets.String
+	services   sets.String
+	workloads  sets.String
+}
+
+// useWaypoint returns the waypoint labels refer to, as name and namespace.
+func useWaypoint(labels map[string]string, namespace string) (string, string, bool) {
+	name, f := labels[label.IoIstioUseWaypoint.Name]
+	if !f {
+		return "", "", false
+	}
+	if ns := labels[label.IoIstioUseWaypointNamespace.Name]; ns != "" {
+		namespace = ns
+	}
+	return name, namespace, true
+}
+
+// findWaypointUsers finds the resources using gw. The waypoint of a service or workload is set by its labels,
+// or else by the labels of its namespace.
+func findWaypointUsers(client kube.CLIClient, gw *gateway.Gateway) (waypointUsers, error) {
+	users := waypointUsers{namespaces: sets.New[string](), services: sets.New[string](), workloads: sets.New[string]()}
+	namespaces, err := client.Kube().CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return users, err
+	}
+	// Labels of the namespaces, used by the resources which are not labeled themselves
+	nsLabels := map[string]map[string]string{}
+	for _, ns := range namespaces.Items {
+		nsLabels[ns.Name] = ns.Labels
+		if name, wns, ok := useWaypoint(ns.Labels, ns.Name); ok && name == gw.Name && wns == gw.Namespace {
+			users.namespaces.Insert(ns.Name)
+		}
+	}
+	uses := func(meta metav1.ObjectMeta) bool {
+		name, wns, ok := useWaypoint(meta.Labels, meta.Namespace)
+		if !ok {
+			name, wns, _ = useWaypoint(nsLabels[meta.Namespace], meta.Namespace)
+		}
+		return name == gw.Name && wns == gw.Namespace
+	}
+
+	services, err := client.Kube().CoreV1().Services(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return users, err
+	}
+	for _, svc := range services.Items {
+		if uses(svc.ObjectMeta) {
+			users.services.Insert(svc.Name + "." + svc.Namespace)
+		}
+	}
+	pods, err := client.Kube().CoreV1().Pods(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return users, err
+	}
+	for _, pod := range pods.Items {
+		// The waypoint itself does not use a waypoint
+		if pod.Labels[label.IoK8sNetworkingGatewayGatewayName.Name] == gw.Name && pod.Namespace == gw.Namespace {
+			continue
+		}
+		if uses(pod.ObjectMeta) {
+			users.workloads.Insert(pod.Name + "." + pod.Namespace)
+		}
+	}
+	return users, nil
+}
+
+func printWaypointUsers(writer io.Writer, users waypointUsers) {
+	print := func(kind string, names sets.String) {
+		if names.IsEmpty() {
+			fmt.Fprintf(writer, "No %s use the waypoint\n", kind)
+			return
+		}
+		fmt.Fprintf(writer, "%s using the waypoint:\n", strings.ToUpper(kind[:1])+kind[1:])
+		for _, n := range sets.SortedList(names) {
+			fmt.Fprintf(writer, "%s%s\n", printSpaces(printLevel1), n)
+		}
+	}
+	print("namespaces", users.namespaces)
+	print("services", users.services)
+	print("workloads", users.workloads)
+}
+
+func listAuthorizationPolicies(client kube.CLIClient, namespace string, rootNamespace string) ([]*securityclient.AuthorizationPolicy, error) {
+	var policies []*securityclient.AuthorizationPolicy
+	for _, ns := range sets.SortedList(sets.New(namespace, rootNamespace)) {
+		list, err := client.Istio().SecurityV1().AuthorizationPolicies(ns).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch AuthorizationPolicies in namespace %s: %v", ns, err)
+		}
+		policies = append(policies, list.Items...)
+	}
+	return policies, nil
+}
+
+func targetRefs(ap *securityclient.AuthorizationPolicy) []*typev1beta1.PolicyTargetReference {
+	if ref := ap.Spec.GetTargetRef(); ref != nil {
+		return append([]*typev1beta1.PolicyTargetReference{ref}, ap.Spec.GetTargetRefs()...)
+	}
+	return ap.Spec.GetTargetRefs()
+}
+
+// policyTargetsGateway returns whether ap targets gw, or its class.
+func policyTargetsGateway(ap *securityclient.AuthorizationPolicy, gw *gateway.Gateway) bool {
+	for _, ref := range targetRefs(ap) {
+		if ref.GetGroup() != gvk.KubernetesGateway.Group {
+			continue
+		}
+		if ref.GetKind() == gvk.KubernetesGateway.Kind && ref.GetName() == gw.Name && ap.Namespace == gw.Namespace {
+			return true
+		}
+		if ref.GetKind() == gvk.GatewayClass.Kind && ref.GetName() == string(gw.Spec.GatewayClassName) {
+			return true
+		}
+	}
+	return false
+}
+
+// policySelects returns whether ap, without target references, selects the pods with podLabels.
+func policySelects(ap *securityclient.AuthorizationPolicy, podLabels klabels.Set) bool {
+	if podLabels == nil || len(targetRefs(ap)) > 0 {
+		return false
+	}
+	// Policies without selector apply to the whole namespace, or to the whole mesh in the root namespace
+	return klabels.SelectorFromSet(ap.Spec.GetSelector().GetMatchLabels()).Matches(podLabels)
+}
+
+func printAuthorizationPolicies(writer io.Writer, policies []*securityclient.AuthorizationPolicy) {
+	if len(policies) == 0 {
+		fmt.Fprintf(writer, "No AuthorizationPolicies applied\n")
+		return
+	}
+	fmt.Fprintf(writer, "Effective AuthorizationPolicies:\n")
+	for _, ap := range policies {
+		fmt.Fprintf(writer, "%s%s (%s)\n", printSpaces(printLevel1), kname(ap.ObjectMeta), ap.Spec.GetAction())
+	}
+}
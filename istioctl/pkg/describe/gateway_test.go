@@ -0,0 +1,300 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package describe
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	gateway "sigs.k8s.io/gateway-api/apis/v1"
+
+	"istio.io/api/label"
+	securityapi "istio.io/api/security/v1beta1"
+	typev1beta1 "istio.io/api/type/v1beta1"
+	securityclient "istio.io/client-go/pkg/apis/security/v1"
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/ptr"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func setupGatewayAPIObjects(t *testing.T, ctx cli.Context) {
+	client, err := ctx.CLIClient()
+	assert.NoError(t, err)
+	gwLabels := map[string]string{label.IoK8sNetworkingGatewayGatewayName.Name: "gw"}
+	gateways := []*gateway.Gateway{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "gw", Namespace: "istio-ingress"},
+			Spec: gateway.GatewaySpec{
+				GatewayClassName: "istio",
+				Listeners:        []gateway.Listener{{Name: "http", Port: 80, Protocol: gateway.HTTPProtocolType}},
+			},
+			Status: gateway.GatewayStatus{
+				Addresses: []gateway.GatewayStatusAddress{{Value: "1.2.3.4"}},
+				Conditions: []metav1.Condition{{
+					Type: "Programmed", Status: metav1.ConditionTrue, Reason: "Programmed", Message: "Resource programmed",
+				}},
+				Listeners: []gateway.ListenerStatus{{
+					Name: "http", AttachedRoutes: 1,
+					Conditions: []metav1.Condition{{Type: "Accepted", Status: metav1.ConditionTrue, Reason: "Accepted"}},
+				}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "waypoint", Namespace: "default"},
+			Spec:       gateway.GatewaySpec{GatewayClassName: constants.WaypointGatewayClassName},
+		},
+	}
+	for _, gw := range gateways {
+		_, err := client.GatewayAPI().GatewayV1().Gateways(gw.Namespace).Create(context.TODO(), gw, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	routes := []*gateway.HTTPRoute{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "reviews", Namespace: "default"},
+			Spec: gateway.HTTPRouteSpec{
+				CommonRouteSpec: gateway.CommonRouteSpec{ParentRefs: []gateway.ParentReference{{
+					Name:      "gw",
+					Namespace: ptr.Of(gateway.Namespace("istio-ingress")),
+				}}},
+				Hostnames: []gateway.Hostname{"reviews.example.com"},
+				Rules: []gateway.HTTPRouteRule{{
+					Matches: []gateway.HTTPRouteMatch{{Path: &gateway.HTTPPathMatch{
+						Type:  ptr.Of(gateway.PathMatchPathPrefix),
+						Value: ptr.Of("/reviews"),
+					}}},
+					BackendRefs: []gateway.HTTPBackendRef{{BackendRef: gateway.BackendRef{BackendObjectReference: gateway.BackendObjectReference{
+						Name: "reviews",
+						Port: ptr.Of(gateway.PortNumber(9080)),
+					}}}},
+				}},
+			},
+			Status: gateway.HTTPRouteStatus{RouteStatus: gateway.RouteStatus{Parents: []gateway.RouteParentStatus{{
+				ParentRef: gateway.ParentReference{
+					Name:      "gw",
+					Namespace: ptr.Of(gateway.Namespace("istio-ingress")),
+				},
+				ControllerName: "istio.io/gateway-controller",
+				Conditions: []metav1.Condition{
+					{Type: "Accepted", Status: metav1.ConditionTrue, Reason: "Accepted"},
+					{Type: "ResolvedRefs", Status: metav1.ConditionFalse, Reason: "BackendNotFound", Message: "backend(reviews.default.svc.cluster.local) not found"},
+				},
+			}}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ratings", Namespace: "default"},
+			Spec: gateway.HTTPRouteSpec{
+				CommonRouteSpec: gateway.CommonRouteSpec{ParentRefs: []gateway.ParentReference{{
+					Group: ptr.Of(gateway.Group("")),
+					Kind:  ptr.Of(gateway.Kind("Service")),
+					Name:  "productpage",
+				}}},
+			},
+		},
+	}
+	for _, r := range routes {
+		_, err := client.GatewayAPI().GatewayV1().HTTPRoutes(r.Namespace).Create(context.TODO(), r, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	namespaces := []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: map[string]string{label.IoIstioUseWaypoint.Name: "waypoint"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+	}
+	for _, ns := range namespaces {
+		_, err := client.Kube().CoreV1().Namespaces().Create(context.TODO(), ns, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	services := []*corev1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "gw-istio", Namespace: "istio-ingress", Labels: gwLabels},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{{Name: "http", Port: 80, TargetPort: intstr.FromInt32(8080)}},
+			},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "productpage", Namespace: "default"}},
+		{ObjectMeta: metav1.ObjectMeta{
+			Name: "ratings", Namespace: "default",
+			Labels: map[string]string{label.IoIstioUseWaypoint.Name: "none"},
+		}},
+		{ObjectMeta: metav1.ObjectMeta{
+			Name: "details", Namespace: "other",
+			Labels: map[string]string{label.IoIstioUseWaypoint.Name: "waypoint", label.IoIstioUseWaypointNamespace.Name: "default"},
+		}},
+	}
+	for _, svc := range services {
+		_, err := client.Kube().CoreV1().Services(svc.Namespace).Create(context.TODO(), svc, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	pods := []*corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "productpage-v1", Namespace: "default"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "details-v1", Namespace: "other"}},
+	}
+	for _, pod := range pods {
+		_, err := client.Kube().CoreV1().Pods(pod.Namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	_, err = client.Kube().AppsV1().Deployments("istio-ingress").Create(context.TODO(), &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw-istio", Namespace: "istio-ingress", Labels: gwLabels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.Of(int32(2)),
+			Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: gwLabels}},
+		},
+		Status: appsv1.DeploymentStatus{ReadyReplicas: 2},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	policies := []*securityclient.AuthorizationPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "deny-gw", Namespace: "istio-ingress"},
+			Spec: securityapi.AuthorizationPolicy{
+				TargetRefs: []*typev1beta1.PolicyTargetReference{{Group: "gateway.networking.k8s.io", Kind: "Gateway", Name: "gw"}},
+				Action:     securityapi.AuthorizationPolicy_DENY,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "mesh-wide", Namespace: "istio-system"},
+			Spec:       securityapi.AuthorizationPolicy{},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-workload", Namespace: "istio-ingress"},
+			Spec: securityapi.AuthorizationPolicy{
+				Selector: &typev1beta1.WorkloadSelector{MatchLabels: map[string]string{"app": "other"}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "productpage-viewer", Namespace: "default"},
+			Spec: securityapi.AuthorizationPolicy{
+				TargetRefs: []*typev1beta1.PolicyTargetReference{{Kind: "Service", Name: "productpage"}},
+			},
+		},
+	}
+	for _, ap := range policies {
+		_, err := client.Istio().SecurityV1().AuthorizationPolicies(ap.Namespace).Create(context.TODO(), ap, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+}
+
+func TestDescribeGatewayAPI(t *testing.T) {
+	cases := []struct {
+		name       string
+		args       []string
+		namespace  string
+		expected   []string
+		unexpected []string
+		wantErr    string
+	}{
+		{
+			name:      "gateway",
+			args:      []string{"gateway", "gw.istio-ingress"},
+			namespace: "default",
+			expected: []string{
+				"Gateway: gw.istio-ingress\n   Class: istio\n   Addresses: 1.2.3.4\n   Programmed=True: Resource programmed\n",
+				"      http: 80/HTTP, 1 attached route(s)\n         Accepted=True\n",
+				"Deployment: gw-istio.istio-ingress (2/2 replicas ready)\n",
+				"Service: gw-istio.istio-ingress (LoadBalancer)\n   Port: http 80/HTTP targets pod port 8080\n",
+				"Attached routes:\n   HTTPRoute reviews\n      Accepted=True\n" +
+					"      ResolvedRefs=False (BackendNotFound): backend(reviews.default.svc.cluster.local) not found\n",
+				"Effective AuthorizationPolicies:\n   deny-gw.istio-ingress (DENY)\n   mesh-wide.istio-system (ALLOW)\n",
+			},
+			unexpected: []string{"other-workload", "ratings.default"},
+		},
+		{
+			name:      "httproute",
+			args:      []string{"httproute", "reviews"},
+			namespace: "default",
+			expected: []string{
+				"HTTPRoute: reviews\n   Hostnames: reviews.example.com\n",
+				"   Parents:\n      Gateway gw.istio-ingress\n         Accepted=True\n",
+				"   Rules:\n      Match: PathPrefix /reviews\n         Backend: Service reviews:9080 - WARNING: Service not found\n",
+			},
+		},
+		{
+			name:      "httproute without status",
+			args:      []string{"httproute", "ratings"},
+			namespace: "default",
+			expected: []string{
+				"      Service productpage\n         No status reported",
+			},
+		},
+		{
+			name:      "waypoint",
+			args:      []string{"waypoint", "waypoint"},
+			namespace: "default",
+			expected: []string{
+				"Gateway: waypoint\n   Class: istio-waypoint\n   Traffic type: service\n",
+				"No Deployment found for gateway waypoint (it may",
+				"Namespaces using the waypoint:\n   default\n",
+				"Services using the waypoint:\n   details.other\n   productpage.default\n",
+				"Workloads using the waypoint:\n   productpage-v1.default\n",
+				"Attached routes:\n   HTTPRoute ratings\n",
+				"Effective AuthorizationPolicies:\n   productpage-viewer (ALLOW)\n",
+			},
+			unexpected: []string{"details-v1", "mesh-wide"},
+		},
+		{
+			name:      "not a waypoint",
+			args:      []string{"waypoint", "gw.istio-ingress"},
+			namespace: "default",
+			wantErr:   `gateway gw.istio-ingress is not a waypoint: its class is "istio", not "istio-waypoint"`,
+		},
+		{
+			name:    "missing name",
+			args:    []string{"gateway"},
+			wantErr: "expecting gateway name",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := cli.NewFakeContext(&cli.NewFakeContextOption{
+				Namespace:      c.namespace,
+				IstioNamespace: "istio-system",
+			})
+			setupGatewayAPIObjects(t, ctx)
+
+			var out bytes.Buffer
+			cmd := Cmd(ctx)
+			cmd.SetArgs(c.args)
+			cmd.SetOut(&out)
+			cmd.SetErr(&out)
+			err := cmd.Execute()
+			output := out.String()
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Fatalf("expected error %q, got %v", c.wantErr, err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			for _, e := range c.expected {
+				if !strings.Contains(output, e) {
+					t.Errorf("output does not contain %q:\n%s", e, output)
+				}
+			}
+			for _, e := range c.unexpected {
+				if strings.Contains(output, e) {
+					t.Errorf("output unexpectedly contains %q:\n%s", e, output)
+				}
+			}
+		})
+	}
+}
@@ -101,7 +101,9 @@ func Cmd(ctx cli.Context) *cobra.Command {
 				message := " No issues found when checking the cluster. Istio is safe to install or upgrade!"
 				message += "\n  To get started, check out https://istio.io/latest/docs/setup/getting-started/."
 				_, _ = fmt.Fprintln(cmd.ErrOrStderr(), color.New(color.FgGreen).Sprint("✔")+message)
-			} else {
+			}
+			// Reports are expected by CI systems even when there are no issues
+			if len(outputMsgs) > 0 || msgOutputFormat == formatting.SARIFFormat || msgOutputFormat == formatting.JUnitFormat {
 				_, _ = fmt.Fprintln(cmd.OutOrStdout(), output)
 			}
 			for _, m := range msgs {
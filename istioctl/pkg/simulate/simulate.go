@@ -0,0 +1,455 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/util/configdump"
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/kube"
+	"istio.io/istio/pilot/pkg/simulation"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/mesh"
+)
+
+const (
+	summaryOutput = "short"
+	jsonOutput    = "json"
+)
+
+type options struct {
+	files      []string
+	configDump string
+
+	proxyType      string
+	proxyNamespace string
+	proxyIP        string
+	proxyLabels    map[string]string
+
+	address  string
+	port     int
+	path     string
+	host     string
+	protocol string
+	tls      string
+	sni      string
+	alpn     string
+	mode     string
+	headers  []string
+
+	output string
+}
+
+func Cmd(ctx cli.Context) *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Simulate a request against generated proxy configuration",
+		Long: `Simulate a request against the listeners, filter chains, routes and clusters of a proxy, without a cluster.
+
+The proxy configuration is either generated from a set of Istio configuration and Kubernetes Service files, or
+read from a config dump produced by 'istioctl proxy-config all -o json'. When generating, Services selecting the
+proxy labels in the proxy namespace get the proxy as their endpoint.`,
+		Example: `  # Simulate an outbound HTTP call from a sidecar in the default namespace
+  istioctl x simulate -f services.yaml -f virtualservices.yaml --host reviews.default.svc.cluster.local --port 9080 --path /reviews
+
+  # Simulate a TLS call to an ingress gateway
+  istioctl x simulate -f gateway.yaml --proxy-type router --proxy-namespace istio-system \
+    --proxy-labels istio=ingressgateway --mode gateway --port 443 --tls tls --host example.com
+
+  # Simulate a call against the configuration of a running proxy
+  istioctl proxy-config all productpage-v1-123.default -o json > dump.json
+  istioctl x simulate --config-dump dump.json --host reviews:9080 --port 9080`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("simulate does not take positional arguments")
+			}
+			if (len(o.files) == 0) == (o.configDump == "") {
+				return fmt.Errorf("exactly one of --file or --config-dump must be set")
+			}
+			if o.port == 0 {
+				return fmt.Errorf("--port must be set")
+			}
+			if o.output != summaryOutput && o.output != jsonOutput {
+				return fmt.Errorf("unknown output format %q, allowed formats are: %s,%s", o.output, summaryOutput, jsonOutput)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			call, err := o.call()
+			if err != nil {
+				return err
+			}
+			if o.proxyNamespace == "" {
+				o.proxyNamespace = ctx.NamespaceOrDefault(ctx.Namespace())
+			}
+			var res simulation.Result
+			if o.configDump != "" {
+				data, err := readFile(o.configDump)
+				if err != nil {
+					return err
+				}
+				res, err = runFromConfigDump(data, call)
+				if err != nil {
+					return err
+				}
+			} else {
+				configs, err := readFiles(o.files)
+				if err != nil {
+					return err
+				}
+				res, err = runFromConfig(configs, o.proxy(), call)
+				if err != nil {
+					return err
+				}
+			}
+			return printResult(cmd.OutOrStdout(), o.output, res)
+		},
+	}
+
+	cmd.PersistentFlags().StringSliceVarP(&o.files, "file", "f", nil,
+		"Istio configuration and Kubernetes Service files to generate proxy configuration from. Use '-' for stdin")
+	cmd.PersistentFlags().StringVar(&o.configDump, "config-dump", "",
+		"Envoy config dump JSON file, as produced by 'istioctl proxy-config all -o json'. Use '-' for stdin")
+
+	cmd.PersistentFlags().StringVar(&o.proxyType, "proxy-type", string(model.SidecarProxy),
+		"Type of proxy to generate configuration for when using --file: sidecar or router")
+	cmd.PersistentFlags().StringVar(&o.proxyNamespace, "proxy-namespace", "",
+		"Namespace of the proxy to generate configuration for when using --file. Defaults to the current namespace")
+	cmd.PersistentFlags().StringVar(&o.proxyIP, "proxy-ip", "1.1.1.1",
+		"IP address of the proxy to generate configuration for when using --file")
+	cmd.PersistentFlags().StringToStringVar(&o.proxyLabels, "proxy-labels", nil,
+		"Labels of the proxy to generate configuration for when using --file, such as istio=ingressgateway")
+
+	cmd.PersistentFlags().StringVar(&o.address, "address", "", "Destination IP address of the call")
+	cmd.PersistentFlags().IntVar(&o.port, "port", 0, "Destination port of the call")
+	cmd.PersistentFlags().StringVar(&o.path, "path", "/", "Request path of the call, for HTTP protocols")
+	cmd.PersistentFlags().StringVar(&o.host, "host", "", "Host header of the call. Also used as the SNI for TLS calls if --sni is not set")
+	cmd.PersistentFlags().StringVar(&o.protocol, "protocol", string(simulation.HTTP), "Protocol of the call: http, http2 or tcp")
+	cmd.PersistentFlags().StringVar(&o.tls, "tls", string(simulation.Plaintext), "TLS mode of the call: plaintext, tls or mtls")
+	cmd.PersistentFlags().StringVar(&o.sni, "sni", "", "SNI of the call")
+	cmd.PersistentFlags().StringVar(&o.alpn, "alpn", "", "ALPN of the call. Only valid for TLS calls")
+	cmd.PersistentFlags().StringVar(&o.mode, "mode", string(simulation.CallModeOutbound),
+		"How the call reaches the proxy: outbound (redirected to 15001), inbound (redirected to 15006) or gateway (no redirection)")
+	cmd.PersistentFlags().StringArrayVar(&o.headers, "header", nil, "Request header of the call in the form key=value. May be repeated")
+	cmd.PersistentFlags().StringVarP(&o.output, "output", "o", summaryOutput, "Output format: one of json|short")
+	return cmd
+}
+
+func (o *options) call() (simulation.Call, error) {
+	protocol := simulation.Protocol(o.protocol)
+	switch protocol {
+	case simulation.HTTP, simulation.HTTP2, simulation.TCP:
+	default:
+		return simulation.Call{}, fmt.Errorf("unknown protocol %q", o.protocol)
+	}
+	tlsMode := simulation.TLSMode(o.tls)
+	switch tlsMode {
+	case simulation.Plaintext, simulation.TLS, simulation.MTLS:
+	default:
+		return simulation.Call{}, fmt.Errorf("unknown tls mode %q", o.tls)
+	}
+	mode := simulation.CallMode(o.mode)
+	switch mode {
+	case simulation.CallModeOutbound, simulation.CallModeInbound, simulation.CallModeGateway:
+	default:
+		return simulation.Call{}, fmt.Errorf("unknown mode %q", o.mode)
+	}
+	if o.address != "" {
+		if _, err := netip.ParseAddr(o.address); err != nil {
+			return simulation.Call{}, fmt.Errorf("invalid --address %q: must be an IP address", o.address)
+		}
+	}
+	headers := http.Header{}
+	for _, h := range o.headers {
+		k, v, ok := strings.Cut(h, "=")
+		if !ok {
+			return simulation.Call{}, fmt.Errorf("invalid header %q, expected key=value", h)
+		}
+		headers.Add(k, v)
+	}
+	return simulation.Call{
+		Address:    o.address,
+		Port:       o.port,
+		Path:       o.path,
+		Protocol:   protocol,
+		TLS:        tlsMode,
+		Alpn:       o.alpn,
+		HostHeader: o.host,
+		Headers:    headers,
+		Sni:        o.sni,
+		CallMode:   mode,
+	}, nil
+}
+
+func (o *options) proxy() *model.Proxy {
+	return &model.Proxy{
+		Type:            model.NodeType(o.proxyType),
+		ConfigNamespace: o.proxyNamespace,
+		IPAddresses:     []string{o.proxyIP},
+		Labels:          o.proxyLabels,
+		Metadata: &model.NodeMetadata{
+			Labels:    o.proxyLabels,
+			Namespace: o.proxyNamespace,
+		},
+	}
+}
+
+// runFromConfig generates the proxy configuration from the given configuration and simulates the call against it.
+func runFromConfig(configs string, proxy *model.Proxy, call simulation.Call) (simulation.Result, error) {
+	if proxy.Type != model.SidecarProxy && proxy.Type != model.Router {
+		return simulation.Result{}, fmt.Errorf("unsupported proxy type %q", proxy.Type)
+	}
+	opts, err := parseConfigs(configs, proxy)
+	if err != nil {
+		return simulation.Result{}, err
+	}
+	sim, err := simulation.NewSimulationFromConfig(opts, proxy)
+	if err != nil {
+		return simulation.Result{}, err
+	}
+	return sim.Run(call), nil
+}
+
+// runFromConfigDump simulates the call against the dynamic resources of an Envoy config dump.
+func runFromConfigDump(data []byte, call simulation.Call) (simulation.Result, error) {
+	cd := &configdump.Wrapper{}
+	if err := cd.UnmarshalJSON(data); err != nil {
+		return simulation.Result{}, fmt.Errorf("failed to parse config dump: %v", err)
+	}
+	listeners, err := extractListeners(cd)
+	if err != nil {
+		return simulation.Result{}, err
+	}
+	clusters, err := extractClusters(cd)
+	if err != nil {
+		return simulation.Result{}, err
+	}
+	routes, err := extractRoutes(cd)
+	if err != nil {
+		return simulation.Result{}, err
+	}
+	return simulation.NewSimulationFromResources(listeners, clusters, routes).Run(call), nil
+}
+
+func extractListeners(cd *configdump.Wrapper) ([]*listener.Listener, error) {
+	dump, err := cd.GetDynamicListenerDump(true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read listeners from config dump: %v", err)
+	}
+	res := make([]*listener.Listener, 0, len(dump.DynamicListeners))
+	for _, dl := range dump.DynamicListeners {
+		l := &listener.Listener{}
+		if err := dl.GetActiveState().GetListener().UnmarshalTo(l); err != nil {
+			return nil, err
+		}
+		res = append(res, l)
+	}
+	return res, nil
+}
+
+func extractClusters(cd *configdump.Wrapper) ([]*cluster.Cluster, error) {
+	dump, err := cd.GetDynamicClusterDump(true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clusters from config dump: %v", err)
+	}
+	res := make([]*cluster.Cluster, 0, len(dump.DynamicActiveClusters))
+	for _, dc := range dump.DynamicActiveClusters {
+		c := &cluster.Cluster{}
+		if err := dc.GetCluster().UnmarshalTo(c); err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+func extractRoutes(cd *configdump.Wrapper) ([]*route.RouteConfiguration, error) {
+	dump, err := cd.GetDynamicRouteDump(true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routes from config dump: %v", err)
+	}
+	res := make([]*route.RouteConfiguration, 0, len(dump.DynamicRouteConfigs))
+	for _, dr := range dump.DynamicRouteConfigs {
+		r := &route.RouteConfiguration{}
+		if err := dr.GetRouteConfig().UnmarshalTo(r); err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+// parseConfigs parses Istio configuration and Kubernetes Services. Services selecting the proxy
+// get an instance for it, so inbound calls to the proxy match the Service ports.
+func parseConfigs(configs string, proxy *model.Proxy) (simulation.ConfigOptions, error) {
+	opts := simulation.ConfigOptions{}
+	meshConfig := mesh.DefaultMeshConfig()
+	now := time.Now()
+	for _, doc := range strings.Split(configs, "\n---") {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		cfgs, others, err := crd.ParseInputs(doc)
+		if err != nil {
+			return opts, err
+		}
+		for _, c := range cfgs {
+			if c.Namespace == "" {
+				c.Namespace = "default"
+			}
+			// Use the same creation timestamp for all configs, so their precedence is consistent.
+			if c.CreationTimestamp.IsZero() {
+				c.CreationTimestamp = now
+			}
+			opts.Configs = append(opts.Configs, c)
+		}
+		for _, o := range others {
+			if o.APIVersion != "v1" || o.Kind != "Service" {
+				return opts, fmt.Errorf("unsupported kind %v %v/%v", o.GroupVersionKind(), o.Namespace, o.Name)
+			}
+			svc := corev1.Service{}
+			if err := yaml.Unmarshal([]byte(doc), &svc); err != nil {
+				return opts, fmt.Errorf("failed to parse Service %v/%v: %v", o.Namespace, o.Name, err)
+			}
+			if svc.Namespace == "" {
+				svc.Namespace = "default"
+			}
+			ms := kube.ConvertService(svc, constants.DefaultClusterLocalDomain, constants.DefaultClusterName, meshConfig)
+			opts.Services = append(opts.Services, ms)
+			opts.Instances = append(opts.Instances, proxyInstances(svc, ms, proxy)...)
+		}
+	}
+	return opts, nil
+}
+
+// proxyInstances returns an instance of the proxy for each port of the Service, if the Service selects the proxy.
+func proxyInstances(svc corev1.Service, ms *model.Service, proxy *model.Proxy) []*model.ServiceInstance {
+	if len(svc.Spec.Selector) == 0 || svc.Namespace != proxy.ConfigNamespace ||
+		!labels.Instance(svc.Spec.Selector).SubsetOf(proxy.Labels) {
+		return nil
+	}
+	var instances []*model.ServiceInstance
+	for _, port := range svc.Spec.Ports {
+		sp, f := ms.Ports.GetByPort(int(port.Port))
+		if !f {
+			continue
+		}
+		targetPort := port.TargetPort.IntValue()
+		if targetPort == 0 {
+			targetPort = int(port.Port)
+		}
+		instances = append(instances, &model.ServiceInstance{
+			Service:     ms,
+			ServicePort: sp,
+			Endpoint: &model.IstioEndpoint{
+				Addresses:       proxy.IPAddresses,
+				EndpointPort:    uint32(targetPort),
+				ServicePortName: sp.Name,
+				Labels:          proxy.Labels,
+				Namespace:       svc.Namespace,
+				TLSMode:         model.IstioMutualTLSModeLabel,
+			},
+		})
+	}
+	return instances
+}
+
+type result struct {
+	Listener    string `json:"listener,omitempty"`
+	FilterChain string `json:"filterChain,omitempty"`
+	RouteConfig string `json:"routeConfig,omitempty"`
+	VirtualHost string `json:"virtualHost,omitempty"`
+	Route       string `json:"route,omitempty"`
+	Cluster     string `json:"cluster,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+func toResult(r simulation.Result) result {
+	res := result{
+		Listener:    r.ListenerMatched,
+		FilterChain: r.FilterChainMatched,
+		RouteConfig: r.RouteConfigMatched,
+		VirtualHost: r.VirtualHostMatched,
+		Route:       r.RouteMatched,
+		Cluster:     r.ClusterMatched,
+	}
+	if r.Error != nil {
+		res.Error = r.Error.Error()
+	}
+	return res
+}
+
+func printResult(w io.Writer, format string, r simulation.Result) error {
+	res := toResult(r)
+	if format == jsonOutput {
+		out, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	}
+	printField := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(w, "%-14s%s\n", name+":", value)
+		}
+	}
+	printField("Listener", res.Listener)
+	printField("Filter chain", res.FilterChain)
+	printField("Route config", res.RouteConfig)
+	printField("Virtual host", res.VirtualHost)
+	printField("Route", res.Route)
+	printField("Cluster", res.Cluster)
+	printField("Error", res.Error)
+	return nil
+}
+
+func readFiles(files []string) (string, error) {
+	contents := make([]string, 0, len(files))
+	for _, f := range files {
+		data, err := readFile(f)
+		if err != nil {
+			return "", err
+		}
+		contents = append(contents, string(data))
+	}
+	return strings.Join(contents, "\n---\n"), nil
+}
+
+func readFile(filename string) ([]byte, error) {
+	if filename == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(filename)
+}
@@ -0,0 +1,211 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulate
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/simulation"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+const config = `
+apiVersion: networking.istio.io/v1
+kind: ServiceEntry
+metadata:
+  name: se
+spec:
+  hosts:
+  - example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: DNS
+---
+apiVersion: networking.istio.io/v1
+kind: VirtualService
+metadata:
+  name: vs
+spec:
+  hosts:
+  - example.com
+  http:
+  - name: api
+    match:
+    - uri:
+        prefix: /api
+    route:
+    - destination:
+        host: example.com
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: reviews
+  namespace: default
+spec:
+  clusterIP: 1.2.3.4
+  selector:
+    app: reviews
+  ports:
+  - name: http
+    port: 9080
+    targetPort: 8080
+`
+
+func TestRunFromConfig(t *testing.T) {
+	proxy := func() *model.Proxy {
+		o := &options{proxyType: string(model.SidecarProxy), proxyNamespace: "default", proxyIP: "1.1.1.1"}
+		return o.proxy()
+	}
+	cases := []struct {
+		name string
+		call simulation.Call
+		want simulation.Result
+	}{
+		{
+			name: "matched route",
+			call: simulation.Call{Port: 80, HostHeader: "example.com", Path: "/api/foo", Protocol: simulation.HTTP},
+			want: simulation.Result{
+				ListenerMatched: "0.0.0.0_80",
+				RouteMatched:    "api",
+				ClusterMatched:  "outbound|80||example.com",
+			},
+		},
+		{
+			name: "no route",
+			call: simulation.Call{Port: 80, HostHeader: "example.com", Path: "/other", Protocol: simulation.HTTP},
+			want: simulation.Result{
+				ListenerMatched: "0.0.0.0_80",
+				Error:           simulation.ErrNoRoute,
+			},
+		},
+		{
+			name: "kubernetes service",
+			call: simulation.Call{Port: 9080, HostHeader: "reviews.default.svc.cluster.local", Protocol: simulation.HTTP},
+			want: simulation.Result{
+				ClusterMatched: "outbound|9080||reviews.default.svc.cluster.local",
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runFromConfig(config, proxy(), tt.call)
+			assert.NoError(t, err)
+			got.Matches(t, tt.want)
+		})
+	}
+}
+
+func TestParseConfigs(t *testing.T) {
+	proxy := &model.Proxy{ConfigNamespace: "default", IPAddresses: []string{"1.1.1.1"}, Labels: map[string]string{"app": "reviews"}}
+	opts, err := parseConfigs(config, proxy)
+	assert.NoError(t, err)
+	assert.Equal(t, len(opts.Configs), 2)
+	assert.Equal(t, len(opts.Services), 1)
+	assert.Equal(t, len(opts.Instances), 1)
+	assert.Equal(t, opts.Instances[0].Endpoint.EndpointPort, uint32(8080))
+
+	proxy.Labels = map[string]string{"app": "ratings"}
+	opts, err = parseConfigs(config, proxy)
+	assert.NoError(t, err)
+	assert.Equal(t, len(opts.Instances), 0)
+
+	_, err = parseConfigs("apiVersion: v1\nkind: Pod\nmetadata:\n  name: pod\n", proxy)
+	assert.Error(t, err)
+}
+
+func TestCallValidation(t *testing.T) {
+	o := &options{protocol: "http", tls: "plaintext", mode: "outbound", port: 80, headers: []string{"x-foo=bar"}}
+	c, err := o.call()
+	assert.NoError(t, err)
+	assert.Equal(t, c.Headers.Get("x-foo"), "bar")
+
+	o.protocol = "grpc"
+	_, err = o.call()
+	assert.Error(t, err)
+
+	o.protocol = "http"
+	o.headers = []string{"invalid"}
+	_, err = o.call()
+	assert.Error(t, err)
+
+	o.headers = nil
+	o.address = "not-an-ip"
+	_, err = o.call()
+	assert.Error(t, err)
+}
+
+func TestRunFromConfigDump(t *testing.T) {
+	// Generated from the ServiceEntry and VirtualService of config, for a sidecar in the default namespace
+	data, err := os.ReadFile("testdata/config_dump.json")
+	assert.NoError(t, err)
+	cases := []struct {
+		name string
+		call simulation.Call
+		want simulation.Result
+	}{
+		{
+			name: "matched route",
+			call: simulation.Call{Port: 80, HostHeader: "example.com", Path: "/api/foo", Protocol: simulation.HTTP},
+			want: simulation.Result{
+				ListenerMatched: "0.0.0.0_80",
+				RouteMatched:    "api",
+				ClusterMatched:  "outbound|80||example.com",
+			},
+		},
+		{
+			name: "no route",
+			call: simulation.Call{Port: 80, HostHeader: "example.com", Path: "/other", Protocol: simulation.HTTP},
+			want: simulation.Result{
+				ListenerMatched: "0.0.0.0_80",
+				Error:           simulation.ErrNoRoute,
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runFromConfigDump(data, tt.call)
+			assert.NoError(t, err)
+			got.Matches(t, tt.want)
+		})
+	}
+
+	// Invalid addresses are reported, rather than panicking
+	got, err := runFromConfigDump(data, simulation.Call{Address: "not-an-ip", Port: 80, HostHeader: "example.com", Protocol: simulation.HTTP})
+	assert.NoError(t, err)
+	assert.Error(t, got.Error)
+
+	_, err = runFromConfigDump([]byte("not json"), simulation.Call{Port: 80})
+	assert.Error(t, err)
+}
+
+func TestPrintResult(t *testing.T) {
+	var out bytes.Buffer
+	res := simulation.Result{ListenerMatched: "0.0.0.0_80", Error: simulation.ErrNoRoute}
+	assert.NoError(t, printResult(&out, jsonOutput, res))
+	assert.Equal(t, out.String(), `{
+  "listener": "0.0.0.0_80",
+  "error": "no route matched"
+}
+`)
+	out.Reset()
+	assert.NoError(t, printResult(&out, summaryOutput, res))
+	assert.Equal(t, out.String(), "Listener:     0.0.0.0_80\nError:        no route matched\n")
+}
@@ -15,11 +15,15 @@
 package formatting
 
 import (
+	"encoding/json"
 	"testing"
 
 	. "github.com/onsi/gomega"
 
 	"istio.io/istio/pkg/config/analysis/diag"
+	legacykube "istio.io/istio/pkg/config/analysis/legacy/source/kube"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/url"
 )
 
@@ -151,6 +155,125 @@ func TestFormatter_PrintEmpty(t *testing.T) {
 
 	yamlOutput, _ := Print(msgs, YAMLFormat, false)
 	g.Expect(yamlOutput).To(Equal("[]\n"))
+
+	sarifOutput, _ := Print(msgs, SARIFFormat, false)
+	g.Expect(sarifOutput).To(ContainSubstring(`"results": []`))
+
+	junitOutput, _ := Print(msgs, JUnitFormat, false)
+	g.Expect(junitOutput).To(ContainSubstring(`<testsuite name="istioctl" tests="0" failures="0"></testsuite>`))
+}
+
+// fileMessages returns messages for resources read from a file, and for a resource without a file.
+func fileMessages() diag.Messages {
+	fileResource := func(name string, line int) *resource.Instance {
+		return &resource.Instance{
+			Metadata: resource.Metadata{FullName: resource.NewFullName("default", resource.LocalName(name))},
+			Origin: &legacykube.Origin{
+				Type:     gvk.VirtualService,
+				FullName: resource.NewFullName("default", resource.LocalName(name)),
+				Ref:      &legacykube.Position{Filename: "config.yaml", Line: line},
+			},
+		}
+	}
+	firstMsg := diag.NewMessage(
+		diag.NewMessageType(diag.Error, "B1", "Explosion accident: %v"),
+		fileResource("bubble", 3),
+		"the bubble is too big",
+	)
+	// The line of the offending field takes precedence
+	firstMsg.Line = 12
+	secondMsg := diag.NewMessage(
+		diag.NewMessageType(diag.Warning, "C1", "Collapse danger: %v"),
+		fileResource("castle", 20),
+		"the castle is too old",
+	)
+	thirdMsg := diag.NewMessage(
+		diag.NewMessageType(diag.Info, "B1", "Explosion accident: %v"),
+		diag.MockResource("SoapBubble"),
+		"the bubble is small",
+	)
+	return diag.Messages{firstMsg, secondMsg, thirdMsg}
+}
+
+func TestFormatter_PrintSARIF(t *testing.T) {
+	g := NewWithT(t)
+
+	output, err := Print(fileMessages(), SARIFFormat, false)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var out sarifLog
+	g.Expect(json.Unmarshal([]byte(output), &out)).To(Succeed())
+	g.Expect(out.Version).To(Equal("2.1.0"))
+	g.Expect(out.Runs).To(HaveLen(1))
+	run := out.Runs[0]
+	g.Expect(run.Tool.Driver.Name).To(Equal("istioctl"))
+	g.Expect(run.Tool.Driver.Rules).To(Equal([]sarifRule{
+		{ID: "B1", HelpURI: url.ConfigAnalysis + "/b1/", DefaultConfiguration: sarifConfiguration{Level: "error"}},
+		{ID: "C1", HelpURI: url.ConfigAnalysis + "/c1/", DefaultConfiguration: sarifConfiguration{Level: "warning"}},
+	}))
+	g.Expect(run.Results).To(Equal([]sarifResult{
+		{
+			RuleID:    "B1",
+			RuleIndex: 0,
+			Level:     "error",
+			Message:   sarifMessage{Text: "Explosion accident: the bubble is too big"},
+			Locations: []sarifLocation{{
+				PhysicalLocation: &sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: "config.yaml"},
+					Region:           &sarifRegion{StartLine: 12},
+				},
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: "VirtualService default/bubble", Kind: "resource"}},
+			}},
+		},
+		{
+			RuleID:    "C1",
+			RuleIndex: 1,
+			Level:     "warning",
+			Message:   sarifMessage{Text: "Collapse danger: the castle is too old"},
+			Locations: []sarifLocation{{
+				PhysicalLocation: &sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: "config.yaml"},
+					Region:           &sarifRegion{StartLine: 20},
+				},
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: "VirtualService default/castle", Kind: "resource"}},
+			}},
+		},
+		{
+			RuleID:    "B1",
+			RuleIndex: 0,
+			Level:     "note",
+			Message:   sarifMessage{Text: "Explosion accident: the bubble is small"},
+			Locations: []sarifLocation{{
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: "SoapBubble", Kind: "resource"}},
+			}},
+		},
+	}))
+}
+
+func TestFormatter_PrintJUnit(t *testing.T) {
+	g := NewWithT(t)
+
+	output, err := Print(fileMessages(), JUnitFormat, false)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	expectedOutput := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="istioctl" tests="3" failures="2">
+  <testsuite name="istioctl" tests="3" failures="2">
+    <testcase name="B1 VirtualService default/bubble" classname="VirtualService default/bubble" file="config.yaml" line="12">
+      <failure message="Explosion accident: the bubble is too big" type="Error">Error [B1] (VirtualService default/bubble config.yaml:12) ` +
+		`Explosion accident: the bubble is too big&#xA;See ` + url.ConfigAnalysis + `/b1/</failure>
+    </testcase>
+    <testcase name="C1 VirtualService default/castle" classname="VirtualService default/castle" file="config.yaml" line="20">
+      <failure message="Collapse danger: the castle is too old" type="Warning">Warning [C1] (VirtualService default/castle config.yaml:20) ` +
+		`Collapse danger: the castle is too old&#xA;See ` + url.ConfigAnalysis + `/c1/</failure>
+    </testcase>
+    <testcase name="B1 SoapBubble" classname="SoapBubble">
+      <system-out>Info [B1] (SoapBubble) Explosion accident: the bubble is small&#xA;See ` + url.ConfigAnalysis + `/b1/</system-out>
+    </testcase>
+  </testsuite>
+</testsuites>`
+
+	g.Expect(output).To(Equal(expectedOutput))
 }
 
 func TestFormatter_PintLogForMultiCluster(t *testing.T) {
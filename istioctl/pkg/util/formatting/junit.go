@@ -0,0 +1,88 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package formatting
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"istio.io/istio/pkg/config/analysis/diag"
+)
+
+// junitTestSuites is the JUnit XML format, as understood by CI systems.
+// Each message is reported as a test case: errors and warnings are failures, info messages pass.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func printJUnit(ms diag.Messages) (string, error) {
+	suite := junitTestSuite{Name: "istioctl", Tests: len(ms)}
+	for _, m := range ms {
+		text := fmt.Sprintf(m.Type.Template(), m.Parameters...)
+		tc := junitTestCase{
+			Name:      m.Type.Code(),
+			ClassName: "istioctl",
+		}
+		if m.Resource != nil {
+			tc.Name = m.Type.Code() + " " + m.Resource.Origin.FriendlyName()
+			tc.ClassName = m.Resource.Origin.FriendlyName()
+			tc.File, tc.Line = position(m)
+		}
+		details := fmt.Sprintf("%v [%v]%s %s\nSee %s", m.Type.Level(), m.Type.Code(), m.Origin(), text, docURL(m.Type.Code()))
+		if m.Type.Level().IsWorseThanOrEqualTo(diag.Warning) {
+			tc.Failure = &junitFailure{Message: text, Type: m.Type.Level().String(), Text: details}
+			suite.Failures++
+		} else {
+			tc.SystemOut = details
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	out, err := xml.MarshalIndent(junitTestSuites{
+		Name:     "istioctl",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []junitTestSuite{suite},
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(out), nil
+}
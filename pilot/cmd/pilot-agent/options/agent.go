@@ -62,6 +62,12 @@ func NewAgentOptions(proxy *ProxyArgs, cfg *meshconfig.ProxyConfig, sds istioage
 		DNSCapture:                  DNSCaptureByAgent.Get(),
 		DNSAtGateway:                EnableDNSAtGateway.Get(),
 		DNSForwardParallel:          DNSForwardParallel.Get(),
+		DNSTTL:                      DNSTTL.Get(),
+		DNSCacheSize:                DNSCacheSize.Get(),
+		DNSNegativeCacheTTL:         DNSNegativeCacheTTL.Get(),
+		DNSUpstreamProtocol:         DNSUpstreamProtocol.Get(),
+		DNSUpstreamTLSServerName:    DNSUpstreamTLSServerName.Get(),
+		DNSUpstreamTLSCACert:        DNSUpstreamTLSCACert.Get(),
 		DNSAddr:                     DNSCaptureAddr.Get(),
 		ProxyNamespace:              PodNamespaceVar.Get(),
 		ProxyDomain:                 proxy.DNSDomain,
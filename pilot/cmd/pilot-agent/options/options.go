@@ -69,6 +69,23 @@ var (
 	caProviderEnv = env.Register("CA_PROVIDER", "Citadel", "name of authentication provider").Get()
 	caEndpointEnv = env.Register("CA_ADDR", "", "Address of the spiffe certificate provider. Defaults to discoveryAddress").Get()
 
+	vaultAuthPathEnv = env.Register("VAULT_AUTH_PATH", "kubernetes",
+		"The mount path of the Vault auth method used to log in with the workload token, when CA_PROVIDER is Vault").Get()
+	vaultRoleEnv = env.Register("VAULT_ROLE", "",
+		"The Vault role to log in as, when CA_PROVIDER is Vault").Get()
+	vaultSignPathEnv = env.Register("VAULT_SIGN_PATH", "pki/sign/istio",
+		"The path of the Vault PKI endpoint signing workload certificates, in the form <mount>/sign/<role>, when CA_PROVIDER is Vault").Get()
+	vaultNamespaceEnv = env.Register("VAULT_NAMESPACE", "",
+		"The Vault namespace, when CA_PROVIDER is Vault").Get()
+
+	acmeAccountKeyFileEnv = env.Register("ACME_ACCOUNT_KEY_FILE", "",
+		"The file holding the ACME account key, when CA_PROVIDER is ACME. If it does not exist, a key is generated "+
+			"and written to it. If unset, a new account is registered on each start").Get()
+	acmeEABKeyIDEnv = env.Register("ACME_EAB_KEY_ID", "",
+		"The key identifier for ACME external account binding, when CA_PROVIDER is ACME").Get()
+	acmeEABHMACKeyFileEnv = env.Register("ACME_EAB_HMAC_KEY_FILE", "",
+		"The file holding the base64url encoded HMAC key for ACME external account binding, when CA_PROVIDER is ACME").Get()
+
 	trustDomainEnv = env.Register("TRUST_DOMAIN", "cluster.local",
 		"The trust domain for spiffe certificates").Get()
 
@@ -88,13 +105,18 @@ var (
 		"Specify the RSA key size to use for workload certificates.").Get()
 	pkcs8KeysEnv = env.Register("PKCS8_KEY", false,
 		"Whether to generate PKCS#8 private keys").Get()
-	eccSigAlgEnv        = env.Register("ECC_SIGNATURE_ALGORITHM", "", "The type of ECC signature algorithm to use when generating private keys").Get()
+	eccSigAlgEnv = env.Register("ECC_SIGNATURE_ALGORITHM", "",
+		"The type of ECC signature algorithm to use when generating private keys: ECDSA or ED25519. "+
+			"ED25519 is only supported for proxyless gRPC workloads, with DISABLE_ENVOY").Get()
 	eccCurvEnv          = env.Register("ECC_CURVE", "P256", "The elliptic curve to use when ECC_SIGNATURE_ALGORITHM is set to ECDSA").Get()
 	fileMountedCertsEnv = env.Register("FILE_MOUNTED_CERTS", false, "").Get()
 	credFetcherTypeEnv  = env.Register("CREDENTIAL_FETCHER_TYPE", security.JWT,
-		"The type of the credential fetcher. Currently supported types include GoogleComputeEngine").Get()
+		"The type of the credential fetcher. Currently supported types include GoogleComputeEngine, AWSInstanceIdentity and AzureManagedIdentity").Get()
 	credIdentityProvider = env.Register("CREDENTIAL_IDENTITY_PROVIDER", "GoogleComputeEngine",
 		"The identity provider for credential. Currently default supported identity provider is GoogleComputeEngine").Get()
+	azureManagedIdentityResourceEnv = env.Register("AZURE_MANAGED_IDENTITY_RESOURCE", "",
+		"The resource to request the managed identity token for with the AzureManagedIdentity credential fetcher, "+
+			"such as the application ID URI of an application registered in Microsoft Entra ID. Required for AzureManagedIdentity").Get()
 	// DNSCaptureByAgent is a copy of the env var in the init code.
 	DNSCaptureByAgent = env.Register("ISTIO_META_DNS_CAPTURE", false,
 		"If set to true, enable the capture of outgoing DNS packets on port 53, redirecting to istio-agent on :15053")
@@ -110,6 +132,29 @@ var (
 	DNSForwardParallel = env.Register("DNS_FORWARD_PARALLEL", false,
 		"If set to true, agent will send parallel DNS queries to all upstream nameservers")
 
+	DNSTTL = env.Register("DNS_PROXY_TTL", 30*time.Second,
+		"The TTL of the DNS records served by the agent for hosts known to istiod. "+
+			"A low value allows clients honoring the TTL to pick up address changes quickly")
+
+	DNSCacheSize = env.Register("DNS_PROXY_CACHE_SIZE", 0,
+		"The maximum number of upstream DNS responses cached by the agent. Cached responses are served until "+
+			"their upstream TTL expires. If set to 0, upstream responses are not cached")
+
+	DNSNegativeCacheTTL = env.Register("DNS_PROXY_NEGATIVE_CACHE_TTL", 5*time.Second,
+		"How long upstream NXDOMAIN and NODATA responses are cached when they carry no SOA record to take the TTL from. "+
+			"Only used if DNS_PROXY_CACHE_SIZE is set")
+
+	DNSUpstreamProtocol = env.Register("DNS_PROXY_UPSTREAM_PROTOCOL", "",
+		"The protocol used to forward DNS queries to the upstream nameservers: udp, tcp or tls (DNS-over-TLS, on port 853). "+
+			"If unset, queries are forwarded with the protocol they were received with")
+
+	DNSUpstreamTLSServerName = env.Register("DNS_PROXY_UPSTREAM_TLS_SERVER_NAME", "",
+		"The name to verify the certificates of the upstream nameservers against, if DNS_PROXY_UPSTREAM_PROTOCOL is tls")
+
+	DNSUpstreamTLSCACert = env.Register("DNS_PROXY_UPSTREAM_TLS_CA_CERT", "",
+		"The path to the CA certificates used to verify the upstream nameservers, if DNS_PROXY_UPSTREAM_PROTOCOL is tls. "+
+			"If unset, the system roots are used")
+
 	// Ability of istio-agent to retrieve proxyConfig via XDS for dynamic configuration updates
 	enableProxyConfigXdsEnv = env.Register("PROXY_CONFIG_XDS_AGENT", false,
 		"If set to true, agent retrieves dynamic proxy-config updates via xds channel").Get()
@@ -27,6 +27,7 @@ import (
 	"istio.io/istio/pkg/security"
 	"istio.io/istio/security/pkg/credentialfetcher"
 	"istio.io/istio/security/pkg/nodeagent/cafile"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
 )
 
 // Similar with ISTIO_META_, which is used to customize the node metadata - this customizes extra CA header.
@@ -60,6 +61,22 @@ func NewSecurityOptions(proxyConfig *meshconfig.ProxyConfig, stsPort int, tokenM
 		KeyFilePath:                          security.DefaultKeyFilePath,
 		RootCertFilePath:                     security.DefaultRootCertFilePath,
 		CAHeaders:                            map[string]string{},
+		AzureManagedIdentityResource:         azureManagedIdentityResourceEnv,
+		VaultCA: security.VaultCAOptions{
+			AuthPath:  vaultAuthPathEnv,
+			Role:      vaultRoleEnv,
+			SignPath:  vaultSignPathEnv,
+			Namespace: vaultNamespaceEnv,
+		},
+		ACMECA: security.ACMECAOptions{
+			AccountKeyFile: acmeAccountKeyFileEnv,
+			EABKeyID:       acmeEABKeyIDEnv,
+			EABHMACKeyFile: acmeEABHMACKeyFileEnv,
+		},
+	}
+
+	if err := checkECCSigAlg(o.ECCSigAlg, disableEnvoyEnv); err != nil {
+		return o, err
 	}
 
 	o, err := SetupSecurityOptions(proxyConfig, o, jwtPolicy.Get(),
@@ -73,6 +90,16 @@ func NewSecurityOptions(proxyConfig *meshconfig.ProxyConfig, stsPort int, tokenM
 	return o, err
 }
 
+// checkECCSigAlg rejects Ed25519 workload keys when the agent runs Envoy: Envoy only loads RSA and ECDSA
+// certificates, so they are only usable by proxyless gRPC workloads.
+func checkECCSigAlg(sigAlg string, disableEnvoy bool) error {
+	if pkiutil.SupportedECSignatureAlgorithms(sigAlg) == pkiutil.Ed25519SigAlg && !disableEnvoy {
+		return fmt.Errorf("ECC_SIGNATURE_ALGORITHM=%s is only supported with DISABLE_ENVOY: Envoy does not support Ed25519 certificates",
+			sigAlg)
+	}
+	return nil
+}
+
 func SetupSecurityOptions(proxyConfig *meshconfig.ProxyConfig, secOpt *security.Options, jwtPolicy,
 	credFetcherTypeEnv, credIdentityProvider string,
 ) (*security.Options, error) {
@@ -97,7 +124,8 @@ func SetupSecurityOptions(proxyConfig *meshconfig.ProxyConfig, secOpt *security.
 	}
 
 	o.CredIdentityProvider = credIdentityProvider
-	credFetcher, err := credentialfetcher.NewCredFetcher(credFetcherTypeEnv, o.TrustDomain, jwtPath, o.CredIdentityProvider)
+	credFetcher, err := credentialfetcher.NewCredFetcher(credFetcherTypeEnv, o.TrustDomain, jwtPath, o.CredIdentityProvider,
+		o.AzureManagedIdentityResource)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create credential fetcher: %v", err)
 	}
@@ -137,3 +137,22 @@ func TestExtractCAHeadersFromEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckECCSigAlg(t *testing.T) {
+	tests := []struct {
+		sigAlg       string
+		disableEnvoy bool
+		wantErr      bool
+	}{
+		{sigAlg: ""},
+		{sigAlg: "ECDSA"},
+		{sigAlg: "ED25519", wantErr: true},
+		{sigAlg: "ED25519", disableEnvoy: true},
+	}
+	for _, tt := range tests {
+		err := checkECCSigAlg(tt.sigAlg, tt.disableEnvoy)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkECCSigAlg(%q, %v) got error %v, want error %v", tt.sigAlg, tt.disableEnvoy, err, tt.wantErr)
+		}
+	}
+}
@@ -33,13 +33,18 @@ import (
 	"istio.io/istio/pilot/pkg/features"
 	securityModel "istio.io/istio/pilot/pkg/security/model"
 	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/mesh/kubemesh"
+	"istio.io/istio/pkg/config/mesh/meshwatcher"
 	"istio.io/istio/pkg/env"
+	"istio.io/istio/pkg/kube/krt"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/security"
 	"istio.io/istio/security/pkg/cmd"
 	"istio.io/istio/security/pkg/pki/ca"
 	"istio.io/istio/security/pkg/pki/ra"
+	"istio.io/istio/security/pkg/pki/signer"
 	caserver "istio.io/istio/security/pkg/server/ca"
+	"istio.io/istio/security/pkg/server/ca/audit"
 	"istio.io/istio/security/pkg/server/ca/authenticate"
 	"istio.io/istio/security/pkg/util"
 )
@@ -131,6 +136,29 @@ var (
 	externalCaType = env.Register("EXTERNAL_CA", "",
 		"External CA Integration Type. Permitted value is ISTIOD_RA_KUBERNETES_API.").Get()
 
+	externalSignerAddress = env.Register("EXTERNAL_CA_SIGNER_ADDRESS", "",
+		"Address of an external signer holding the CA signing key, as a Unix socket unix:///path/to/socket. "+
+			"If set, the signing cert, cert chain and root cert are read from ROOT_CA_DIR, without the signing key.")
+
+	caAuditSinks = env.Register("CA_AUDIT_SINKS", "",
+		"Comma separated list of sinks of the audit records of the certificates signing decisions of the CA: "+
+			"'stdout' for JSON records on stdout, 'file:<path>' for JSON records in a local file rotated at 100MB, "+
+			"where the rotated files are kept and must be pruned externally, "+
+			"'otlp:<host:port>' for OTLP logs exported to a collector over gRPC.")
+
+	pluggedCARootRotation = env.Register("PLUGGED_CA_ROOT_ROTATION", false,
+		"If enabled, changes of the roots of the plugged-in CA certs are rolled out in stages: the previous and new "+
+			"roots are published, the CA signs with the new signing cert once all the connected proxies acknowledged "+
+			"them, and the previous roots are removed after PLUGGED_CA_ROOT_RETIREMENT_DELAY. The progress is written "+
+			"to the istio-ca-root-rotation ConfigMap. Requires ISTIO_MULTIROOT_MESH.").Get()
+
+	pluggedCARootRotationCheckInterval = env.Register("PLUGGED_CA_ROOT_ROTATION_CHECK_INTERVAL", 30*time.Second,
+		"The interval at which the progress of plugged-in CA root rotations is checked.").Get()
+
+	pluggedCARootRetirementDelay = env.Register("PLUGGED_CA_ROOT_RETIREMENT_DELAY", 24*time.Hour,
+		"The time the previous roots of the plugged-in CA are still published after the CA signs with the new "+
+			"signing cert. It should be longer than the TTL of the workload certs.").Get()
+
 	// TODO: Likely to be removed and added to mesh config
 	k8sSigner = env.Register("K8S_SIGNER", "",
 		"Kubernetes CA Signer type. Valid from Kubernetes 1.18").Get()
@@ -145,6 +173,66 @@ func (s *Server) initCAServer(ca caserver.CertificateAuthority, opts *caOptions)
 		log.Fatalf("failed to create istio ca server: %v", startErr)
 	}
 	s.caServer = caServer
+
+	if sinks := caAuditSinks.Get(); sinks != "" {
+		auditor, err := audit.NewAuditor(sinks)
+		if err != nil {
+			log.Fatalf("failed to create CA auditor: %v", err)
+		}
+		caServer.SetAuditor(auditor)
+		s.addStartFunc("CA audit", func(stop <-chan struct{}) error {
+			go func() {
+				<-stop
+				_ = auditor.Close()
+			}()
+			return nil
+		})
+	}
+}
+
+// initIssuancePolicy watches the CA issuance policy, set in the caIssuancePolicy key of the mesh config ConfigMap.
+// Like the mesh config, it is read from the mounted file next to the mesh config file if it exists, or else from
+// the ConfigMap. An invalid policy is not applied, keeping the previous one.
+func (s *Server) initIssuancePolicy(args *PilotArgs) {
+	if s.caServer == nil {
+		return
+	}
+	opts := krt.NewOptionsBuilder(s.internalStop, "", args.KrtDebugger)
+	var source meshwatcher.MeshConfigSource
+	file := path.Join(path.Dir(args.MeshConfigFile), kubemesh.CAIssuancePolicyKey)
+	if _, err := os.Stat(file); err == nil {
+		source, err = meshwatcher.NewFileSource(s.fileWatcher, file, opts)
+		if err != nil {
+			log.Errorf("failed to watch CA issuance policy %s: %v", file, err)
+		}
+	}
+	if source == nil {
+		if s.kubeClient == nil {
+			return
+		}
+		source = kubemesh.NewConfigMapSource(s.kubeClient, args.Namespace, getMeshConfigMapName(args.Revision),
+			kubemesh.CAIssuancePolicyKey, opts)
+	}
+	source.AsCollection().WaitUntilSynced(s.internalStop)
+	s.setIssuancePolicy(source.Get())
+	source.Register(func(o krt.Event[string]) {
+		s.setIssuancePolicy(o.New)
+	})
+}
+
+// setIssuancePolicy parses and applies the CA issuance policy. A nil policy removes the restrictions.
+func (s *Server) setIssuancePolicy(data *string) {
+	if data == nil {
+		s.caServer.SetIssuancePolicy(nil)
+		return
+	}
+	policy, err := caserver.ParseIssuancePolicy([]byte(*data))
+	if err != nil {
+		log.Errorf("failed to load CA issuance policy, keeping the previous one: %v", err)
+		return
+	}
+	s.caServer.SetIssuancePolicy(policy)
+	log.Infof("loaded CA issuance policy with %d rules", len(policy.Rules))
 }
 
 // RunCA will start the cert signing GRPC service on an existing server.
@@ -316,6 +404,12 @@ func handleEvent(s *Server) {
 		return
 	}
 
+	if s.caRootRotator != nil && (s.caRootRotator.InProgress() || !bytes.Equal(currentCABundle, newCABundle)) {
+		log.Info("Plugged-in CA roots changed, rotating them in stages")
+		s.caRootRotator.Trigger()
+		return
+	}
+
 	// Only updating intermediate CA is supported now
 	if !bytes.Equal(currentCABundle, newCABundle) {
 		if !features.MultiRootMesh {
@@ -334,11 +428,19 @@ func handleEvent(s *Server) {
 		}
 	}
 
-	err = s.CA.GetCAKeyCertBundle().UpdateVerifiedKeyCertBundleFromFile(
-		fileBundle.SigningCertFile,
-		fileBundle.SigningKeyFile,
-		fileBundle.CertChainFiles,
-		fileBundle.RootCertFile)
+	if s.caSigner != nil {
+		err = s.CA.GetCAKeyCertBundle().UpdateVerifiedKeyCertBundleFromSigner(
+			fileBundle.SigningCertFile,
+			s.caSigner,
+			fileBundle.CertChainFiles,
+			fileBundle.RootCertFile)
+	} else {
+		err = s.CA.GetCAKeyCertBundle().UpdateVerifiedKeyCertBundleFromFile(
+			fileBundle.SigningCertFile,
+			fileBundle.SigningKeyFile,
+			fileBundle.CertChainFiles,
+			fileBundle.RootCertFile)
+	}
 	if err != nil {
 		log.Errorf("Failed to update new Plug-in CA certs: %v", err)
 		return
@@ -420,6 +522,46 @@ func (s *Server) initCACertsWatcher() {
 	go s.handleCACertsFileWatch()
 }
 
+// initPluggedCARootRotator starts the rotator of the roots of the plugged-in CA certs, if enabled, restoring the
+// previous roots of the CA if a rotation is in progress.
+func (s *Server) initPluggedCARootRotator(args *PilotArgs) error {
+	if !pluggedCARootRotation || s.CA == nil || s.cacertsWatcher == nil {
+		return nil
+	}
+	if !features.MultiRootMesh {
+		return fmt.Errorf("PLUGGED_CA_ROOT_ROTATION requires ISTIO_MULTIROOT_MESH, to publish the roots to the proxies")
+	}
+	if s.kubeClient == nil {
+		return fmt.Errorf("PLUGGED_CA_ROOT_ROTATION requires a Kubernetes cluster, to write the rotation status")
+	}
+	fileBundle, err := detectSigningCABundle()
+	if err != nil {
+		return fmt.Errorf("unable to determine signing file format %v", err)
+	}
+	config := ca.PluggedCARootRotatorConfig{
+		FileBundle:      fileBundle,
+		Client:          s.kubeClient.Kube().CoreV1(),
+		Namespace:       args.Namespace,
+		Replica:         args.PodName,
+		CheckInterval:   pluggedCARootRotationCheckInterval,
+		RetirementDelay: pluggedCARootRetirementDelay,
+	}
+	if s.caSigner != nil {
+		config.Signer = s.caSigner
+	}
+	s.caRootRotator = ca.NewPluggedCARootRotator(config, s.CA, s.XDSServer, func() error {
+		caserver.RecordCertsExpiry(s.CA.GetCAKeyCertBundle())
+		return s.updateRootCertAndGenKeyCert()
+	})
+	// Resume the rotation in progress, if istiod restarted during it.
+	s.caRootRotator.Restore()
+	s.addStartFunc("plugged CA root rotator", func(stop <-chan struct{}) error {
+		go s.caRootRotator.Run(stop)
+		return nil
+	})
+	return nil
+}
+
 // createIstioCA initializes the Istio CA signing functionality.
 // - for 'plugged in', uses ./etc/cacert directory, mounted from 'cacerts' secret in k8s.
 //
@@ -442,7 +584,24 @@ func (s *Server) createIstioCA(opts *caOptions) (*ca.IstioCA, error) {
 		}
 	}
 
-	if !detectedSigningCABundle || (features.UseCacertsForSelfSignedCA && istioGenerated) {
+	if externalSignerAddress.Get() != "" {
+		// The signing key is held by the external signer, only the certs are mounted.
+		log.Infof("Use local CA certificate, with external signer %s", externalSignerAddress.Get())
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		s.caSigner, err = signer.NewSigner(ctx, externalSignerAddress.Get())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create an istiod CA: %v", err)
+		}
+		caOpts, err = ca.NewExternalSignerIstioCAOptions(fileBundle, s.caSigner,
+			workloadCertTTL.Get(), maxWorkloadCertTTL.Get(), caRSAKeySize.Get())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create an istiod CA: %v", err)
+		}
+
+		s.initCACertsWatcher()
+	} else if !detectedSigningCABundle || (features.UseCacertsForSelfSignedCA && istioGenerated) {
 		if features.UseCacertsForSelfSignedCA && istioGenerated {
 			log.Infof("IstioGenerated %s secret found, use it as the CA certificate", ca.CACertsSecret)
 
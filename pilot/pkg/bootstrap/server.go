@@ -78,9 +78,11 @@ import (
 	"istio.io/istio/pkg/network"
 	"istio.io/istio/pkg/security"
 	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/pkg/tracing"
 	"istio.io/istio/pkg/util/sets"
 	"istio.io/istio/security/pkg/pki/ca"
 	"istio.io/istio/security/pkg/pki/ra"
+	"istio.io/istio/security/pkg/pki/signer"
 	caserver "istio.io/istio/security/pkg/server/ca"
 	"istio.io/istio/security/pkg/server/ca/authenticate"
 	"istio.io/istio/security/pkg/server/ca/authenticate/kubeauth"
@@ -152,6 +154,10 @@ type Server struct {
 	CA       *ca.IstioCA
 	RA       ra.RegistrationAuthority
 	caServer *caserver.Server
+	// caSigner holds the CA signing key, if it is held by an external signer.
+	caSigner *signer.Signer
+	// caRootRotator rotates the roots of the plugged-in CA certs in stages, if enabled.
+	caRootRotator *ca.PluggedCARootRotator
 
 	// TrustAnchors for workload to workload mTLS
 	workloadTrustBundle *tb.TrustBundle
@@ -261,6 +267,12 @@ func NewServer(args *PilotArgs, initFuncs ...func(*Server)) (*Server, error) {
 	}
 	// Initialize workload Trust Bundle before XDS Server
 	s.XDSServer = xds.NewDiscoveryServer(e, args.RegistryOptions.KubeOptions.ClusterAliases, args.KrtDebugger)
+	if features.PushTraceSize > 0 {
+		s.XDSServer.EnablePushTracing(features.PushTraceSize)
+		if err := s.initPushTracing(); err != nil {
+			return nil, fmt.Errorf("error initializing push tracing: %v", err)
+		}
+	}
 	configGen := core.NewConfigGenerator(s.XDSServer.Cache)
 
 	grpcprom.EnableHandlingTimeHistogram()
@@ -325,6 +337,10 @@ func NewServer(args *PilotArgs, initFuncs ...func(*Server)) (*Server, error) {
 
 	InitGenerators(s.XDSServer, configGen, args.Namespace, s.clusterID, s.internalDebugMux)
 
+	// The root rotator may restore the previous roots of the CA, so it must be initialized before they are published.
+	if err := s.initPluggedCARootRotator(args); err != nil {
+		return nil, err
+	}
 	// Initialize workloadTrustBundle after CA has been initialized
 	if err := s.initWorkloadTrustBundle(args); err != nil {
 		return nil, err
@@ -399,6 +415,7 @@ func NewServer(args *PilotArgs, initFuncs ...func(*Server)) (*Server, error) {
 
 	// Start CA or RA server. This should be called after CA and Istiod certs have been created.
 	s.startCA(caOpts)
+	s.initIssuancePolicy(args)
 
 	// TODO: don't run this if galley is started, one ctlz is enough
 	if args.CtrlZOptions != nil {
@@ -693,6 +710,9 @@ func (s *Server) waitForShutdown(stop <-chan struct{}) {
 		if s.cacertsWatcher != nil {
 			_ = s.cacertsWatcher.Close()
 		}
+		if s.caSigner != nil {
+			s.caSigner.Close()
+		}
 		// Stop gRPC services.  If gRPC services fail to stop in the shutdown duration,
 		// force stop them. This does not happen normally.
 		stopped := make(chan struct{})
@@ -826,6 +846,24 @@ func (s *Server) addReadinessProbe(name string, fn readinessProbe) {
 	s.readinessProbes[name] = fn
 }
 
+// initPushTracing sets up the export of push traces as OpenTelemetry spans, if an exporter is configured through the
+// OTEL_* environment variables. Otherwise, no tracing provider is installed and no spans are created.
+func (s *Server) initPushTracing() error {
+	if !tracing.ExporterConfigured() {
+		return nil
+	}
+	shutdown, err := tracing.Initialize()
+	if err != nil {
+		return err
+	}
+	s.addTerminatingStartFunc("push tracing", func(stop <-chan struct{}) error {
+		<-stop
+		shutdown()
+		return nil
+	})
+	return nil
+}
+
 // addTerminatingStartFunc adds a function that should terminate before the serve shuts down
 // This is useful to do cleanup activities
 // This is does not guarantee they will terminate gracefully - best effort only
@@ -197,9 +197,18 @@ func (s *KubeSource) ContentNames() map[string]struct{} {
 // or removed, depending on the new content.
 // Returns an error if any were encountered, but that still may represent a partial success
 func (s *KubeSource) ApplyContent(name, yamlText string) error {
+	_, err := s.UpdateContent(name, yamlText)
+	return err
+}
+
+// UpdateContent is like ApplyContent, and also returns the kinds of the resources which were added, updated or
+// removed by the new content.
+func (s *KubeSource) UpdateContent(name, yamlText string) (sets.Set[config.GroupVersionKind], error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	changed := sets.New[config.GroupVersionKind]()
+
 	// We hold off on dealing with parseErr until the end, since partial success is possible
 	resources, parseErrs := s.parseContent(s.schemas, name, yamlText)
 
@@ -217,11 +226,12 @@ func (s *KubeSource) ApplyContent(name, yamlText string) error {
 			if err != nil {
 				_, err = s.inner.Create(*r.config)
 				if err != nil {
-					return fmt.Errorf("cannot store config %s/%s %s from reader: %s",
+					return changed, fmt.Errorf("cannot store config %s/%s %s from reader: %s",
 						r.schema.Version(), r.schema.Kind(), r.fullName(), err)
 				}
 			}
 			s.shas[key] = r.sha
+			changed.Insert(r.schema.GroupVersionKind())
 		}
 		newKeys[key] = r.schema.GroupVersionKind()
 		if oldKeys != nil {
@@ -236,13 +246,15 @@ func (s *KubeSource) ApplyContent(name, yamlText string) error {
 		if err != nil {
 			scope.Errorf("encountered unexpected error removing resource from filestore: %s", err)
 		}
+		delete(s.shas, k)
+		changed.Insert(col)
 	}
 	s.byFile[name] = newKeys
 
 	if parseErrs != nil {
-		return fmt.Errorf("errors parsing content %q: %v", name, parseErrs)
+		return changed, fmt.Errorf("errors parsing content %q: %v", name, parseErrs)
 	}
-	return nil
+	return changed, nil
 }
 
 // RemoveContent removes the content for the given name
@@ -25,6 +25,7 @@ import (
 	"istio.io/istio/pkg/config/schema/collections"
 	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/sets"
 )
 
 func TestUpdateExistingContents(t *testing.T) {
@@ -72,3 +73,39 @@ items:
   kind: WoKnows
 `))
 }
+
+func TestUpdateContentChangedKinds(t *testing.T) {
+	src := NewKubeSource(collections.Istio)
+	dr := `apiVersion: networking.istio.io/v1
+kind: DestinationRule
+metadata:
+  name: productpage
+spec:
+  host: productpage
+`
+	vs := `apiVersion: networking.istio.io/v1
+kind: VirtualService
+metadata:
+  name: productpage
+spec:
+  hosts:
+  - productpage
+`
+	changed, err := src.UpdateContent("test", dr)
+	assert.NoError(t, err)
+	assert.Equal(t, changed, sets.New(gvk.DestinationRule))
+
+	// Unchanged resources are not reported
+	changed, err = src.UpdateContent("test", dr+"---\n"+vs)
+	assert.NoError(t, err)
+	assert.Equal(t, changed, sets.New(gvk.VirtualService))
+
+	// Removed resources are reported, and can be added back
+	changed, err = src.UpdateContent("test", vs)
+	assert.NoError(t, err)
+	assert.Equal(t, changed, sets.New(gvk.DestinationRule))
+	changed, err = src.UpdateContent("test", dr+"---\n"+vs)
+	assert.NoError(t, err)
+	assert.Equal(t, changed, sets.New(gvk.DestinationRule))
+	assert.Equal(t, src.Get(gvk.DestinationRule, "productpage", "") != nil, true)
+}
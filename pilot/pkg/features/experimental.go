@@ -15,6 +15,7 @@
 package features
 
 import (
+	"strings"
 	"time"
 
 	"go.uber.org/atomic"
@@ -130,6 +131,17 @@ var (
 		return val
 	}()
 
+	AnalysisPlugins = func() []string {
+		v := env.Register("PILOT_ANALYSIS_PLUGINS", "",
+			"If analysis is enabled, a comma separated list of files declaring additional analyzers, whose rules are "+
+				"CEL expressions. The format is the same as for `istioctl analyze --analyzer-plugin`.",
+		).Get()
+		if v == "" {
+			return nil
+		}
+		return strings.Split(v, ",")
+	}()
+
 	EnableGatewayAPI = env.Register("PILOT_ENABLE_GATEWAY_API", true,
 		"If this is set to true, support for Kubernetes gateway-api (github.com/kubernetes-sigs/gateway-api) will "+
 			" be enabled. In addition to this being enabled, the gateway-api CRDs need to be installed.").Get()
@@ -98,6 +98,15 @@ var (
 	EnableUnsafeAdminEndpoints = env.Register("UNSAFE_ENABLE_ADMIN_ENDPOINTS", false,
 		"If this is set to true, dangerous admin endpoints will be exposed on the debug interface. Not recommended for production.").Get()
 
+	KrtEventTraceSize = env.Register("PILOT_KRT_EVENT_TRACE_SIZE", 0,
+		"If set, the latest recomputations of krt collections, along with the inputs that triggered them, are recorded "+
+			"in a buffer of this size and exposed on the /debug/krt_tracez debug endpoint.").Get()
+
+	PushTraceSize = env.Register("PILOT_PUSH_TRACE_SIZE", 0,
+		"If set, the latest pushes to proxies, along with the configs that triggered them and the cost of each generator, "+
+			"are recorded in a buffer of this size and exposed on the /debug/push_tracez debug endpoint. Pushes are also exported "+
+			"as OpenTelemetry spans, if an exporter is configured with the OTEL_EXPORTER_OTLP_ENDPOINT environment variable.").Get()
+
 	EnableServiceEntrySelectPods = env.Register("PILOT_ENABLE_SERVICEENTRY_SELECT_PODS", true,
 		"If enabled, service entries with selectors will select pods from the cluster. "+
 			"It is safe to disable it if you are quite sure you don't need this feature").Get()
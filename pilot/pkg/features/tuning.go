@@ -89,6 +89,26 @@ var (
 			"for this time, we'll trigger a push.",
 	).Get()
 
+	EnableAdaptiveDebounce = env.Register(
+		"PILOT_ENABLE_ADAPTIVE_DEBOUNCE",
+		false,
+		"If enabled, Pilot tunes the debounce delay from the recent rate of events and the time pushes take, instead of "+
+			"using PILOT_DEBOUNCE_AFTER. Full and EDS pushes are tuned separately, between PILOT_ADAPTIVE_DEBOUNCE_MIN_WINDOW "+
+			"and PILOT_ADAPTIVE_DEBOUNCE_MAX_WINDOW. Pushes are still delayed by at most PILOT_DEBOUNCE_MAX.",
+	).Get()
+
+	AdaptiveDebounceMinWindow = env.Register(
+		"PILOT_ADAPTIVE_DEBOUNCE_MIN_WINDOW",
+		10*time.Millisecond,
+		"The minimum delay added to events for debouncing when PILOT_ENABLE_ADAPTIVE_DEBOUNCE is enabled, used when events are rare.",
+	).Get()
+
+	AdaptiveDebounceMaxWindow = env.Register(
+		"PILOT_ADAPTIVE_DEBOUNCE_MAX_WINDOW",
+		time.Second,
+		"The maximum delay added to events for debouncing when PILOT_ENABLE_ADAPTIVE_DEBOUNCE is enabled, used during event storms.",
+	).Get()
+
 	EnableEDSDebounce = env.Register(
 		"PILOT_ENABLE_EDS_DEBOUNCE",
 		true,
@@ -96,6 +116,13 @@ var (
 			" EDS pushes may be delayed, but there will be fewer pushes. By default this is enabled",
 	).Get()
 
+	EnablePushQueuePriority = env.Register(
+		"PILOT_ENABLE_PUSH_QUEUE_PRIORITY",
+		true,
+		"If enabled, the push queue prioritizes gateways, waypoints, newly connected proxies and proxies with updates to "+
+			"their own workload over other sidecars. Sidecars are still guaranteed a share of the pushes, so they are not starved.",
+	).Get()
+
 	ConvertSidecarScopeConcurrency = env.Register(
 		"PILOT_CONVERT_SIDECAR_SCOPE_CONCURRENCY",
 		1,
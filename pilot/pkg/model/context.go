@@ -268,6 +268,10 @@ type XdsUpdates = sets.Set[ConfigKey]
 type XdsLogDetails struct {
 	Incremental    bool
 	AdditionalInfo string
+	// CacheHits and CacheMisses are the number of resources served from the XDS cache, and generated.
+	// These are only set by generators using the cache.
+	CacheHits   int
+	CacheMisses int
 }
 
 var DefaultXdsLogDetails = XdsLogDetails{}
@@ -278,7 +278,11 @@ func (configgen *ConfigGeneratorImpl) buildClusters(proxy *model.Proxy, req *mod
 	if cacheStats.empty() {
 		return resources, model.DefaultXdsLogDetails
 	}
-	return resources, model.XdsLogDetails{AdditionalInfo: fmt.Sprintf("cached:%v/%v", cacheStats.hits, cacheStats.hits+cacheStats.miss)}
+	return resources, model.XdsLogDetails{
+		AdditionalInfo: fmt.Sprintf("cached:%v/%v", cacheStats.hits, cacheStats.hits+cacheStats.miss),
+		CacheHits:      cacheStats.hits,
+		CacheMisses:    cacheStats.miss,
+	}
 }
 
 func shouldUseDelta(updates *model.PushRequest) bool {
@@ -109,7 +109,11 @@ func (configgen *ConfigGeneratorImpl) BuildHTTPRoutes(
 	if !features.EnableRDSCaching {
 		return routeConfigurations, model.DefaultXdsLogDetails
 	}
-	return routeConfigurations, model.XdsLogDetails{AdditionalInfo: fmt.Sprintf("cached:%v/%v", hit, hit+miss)}
+	return routeConfigurations, model.XdsLogDetails{
+		AdditionalInfo: fmt.Sprintf("cached:%v/%v", hit, hit+miss),
+		CacheHits:      hit,
+		CacheMisses:    miss,
+	}
 }
 
 // buildSidecarInboundHTTPRouteConfig builds the route config with a single wildcard virtual host on the inbound path
@@ -0,0 +1,188 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// Covers returns true if every request matched by other is also matched by m, for HTTP traffic.
+// It is conservative: false may be returned for models which do cover other, for instance if the union of
+// several sources or operations of m is needed to cover a single one of other.
+func (m *Model) Covers(other *Model) bool {
+	return listsCover(m.principals, other.principals) && listsCover(m.permissions, other.permissions)
+}
+
+// listsCover returns true if each of the lists in others is covered by one of the lists in lists.
+func listsCover(lists []ruleList, others []ruleList) bool {
+	for _, o := range others {
+		covered := false
+		for _, l := range lists {
+			if l.covers(o) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// covers returns true if every request matching all the rules of other also matches all the rules of p.
+func (p ruleList) covers(other ruleList) bool {
+	for _, r := range p.rules {
+		implied := false
+		for _, o := range other.rules {
+			if o.key == r.key && o.implies(r) {
+				implied = true
+				break
+			}
+		}
+		if !implied {
+			return false
+		}
+	}
+	return true
+}
+
+// implies returns true if every value matching r also matches other, both having the same key.
+func (r *rule) implies(other *rule) bool {
+	if len(other.values) > 0 {
+		if len(r.values) == 0 {
+			return false
+		}
+		for _, v := range r.values {
+			if !anyValueCovers(r.key, other.values, v) {
+				return false
+			}
+		}
+	}
+	for _, nv := range other.notValues {
+		if !r.excludes(nv) {
+			return false
+		}
+	}
+	return true
+}
+
+// excludes returns true if no value matching r matches the value v.
+func (r *rule) excludes(v string) bool {
+	if anyValueCovers(r.key, r.notValues, v) {
+		return true
+	}
+	if len(r.values) == 0 {
+		return false
+	}
+	if !isSupported(r.key, v) {
+		return false
+	}
+	for _, rv := range r.values {
+		// Only exact values can be checked against v without knowing the syntax of both
+		if isPattern(r.key, rv) || valueCovers(r.key, v, rv) {
+			return false
+		}
+	}
+	return true
+}
+
+func anyValueCovers(key string, values []string, v string) bool {
+	for _, value := range values {
+		if valueCovers(key, value, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// valueCovers returns true if every value matched by v is also matched by value.
+func valueCovers(key string, value, v string) bool {
+	if key == hostHeader {
+		value, v = strings.ToLower(value), strings.ToLower(v)
+	}
+	if value == v {
+		return true
+	}
+	if isIPAttribute(key) {
+		outer, err := parseCIDR(value)
+		if err != nil {
+			return false
+		}
+		inner, err := parseCIDR(v)
+		if err != nil {
+			return false
+		}
+		return outer.Bits() <= inner.Bits() && outer.Contains(inner.Addr())
+	}
+	if value == "*" {
+		return true
+	}
+	if key == pathMatcher && (strings.Contains(value, "{") || strings.Contains(v, "{")) {
+		// Path templates are only compared exactly
+		return false
+	}
+	switch {
+	case strings.HasSuffix(value, "*"):
+		prefix := strings.TrimSuffix(value, "*")
+		if strings.HasPrefix(v, "*") {
+			return false
+		}
+		return strings.HasPrefix(strings.TrimSuffix(v, "*"), prefix)
+	case strings.HasPrefix(value, "*"):
+		suffix := strings.TrimPrefix(value, "*")
+		if strings.HasSuffix(v, "*") {
+			return false
+		}
+		return strings.HasSuffix(strings.TrimPrefix(v, "*"), suffix)
+	}
+	return false
+}
+
+// isPattern returns true if v matches more than the exact value v.
+func isPattern(key string, v string) bool {
+	if isIPAttribute(key) {
+		p, err := parseCIDR(v)
+		return err != nil || p.Bits() != p.Addr().BitLen()
+	}
+	return strings.HasPrefix(v, "*") || strings.HasSuffix(v, "*") || (key == pathMatcher && strings.Contains(v, "{"))
+}
+
+// isSupported returns true if the values matched by v are understood by valueCovers.
+func isSupported(key string, v string) bool {
+	if isIPAttribute(key) {
+		_, err := parseCIDR(v)
+		return err == nil
+	}
+	return key != pathMatcher || !strings.Contains(v, "{")
+}
+
+func isIPAttribute(key string) bool {
+	return key == attrSrcIP || key == attrRemoteIP || key == attrDestIP
+}
+
+// parseCIDR parses a CIDR or a single IP, as a single address prefix.
+func parseCIDR(v string) (netip.Prefix, error) {
+	if strings.Contains(v, "/") {
+		p, err := netip.ParsePrefix(v)
+		return p.Masked(), err
+	}
+	a, err := netip.ParseAddr(v)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(a, a.BitLen()), nil
+}
@@ -0,0 +1,173 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestModelCovers(t *testing.T) {
+	cases := []struct {
+		name  string
+		rule  string
+		other string
+		want  bool
+	}{
+		{
+			name:  "empty rule covers all",
+			rule:  `{}`,
+			other: `{from: [{source: {namespaces: [foo]}}], to: [{operation: {paths: [/foo]}}]}`,
+			want:  true,
+		},
+		{
+			name:  "rule does not cover empty rule",
+			rule:  `{from: [{source: {namespaces: [foo]}}]}`,
+			other: `{}`,
+			want:  false,
+		},
+		{
+			name:  "identical",
+			rule:  `{from: [{source: {principals: [a, b]}}], to: [{operation: {methods: [GET]}}]}`,
+			other: `{from: [{source: {principals: [b, a]}}], to: [{operation: {methods: [GET]}}]}`,
+			want:  true,
+		},
+		{
+			name:  "more values",
+			rule:  `{from: [{source: {principals: [a, b]}}]}`,
+			other: `{from: [{source: {principals: [a]}}]}`,
+			want:  true,
+		},
+		{
+			name:  "fewer values",
+			rule:  `{from: [{source: {principals: [a]}}]}`,
+			other: `{from: [{source: {principals: [a, b]}}]}`,
+			want:  false,
+		},
+		{
+			name:  "additional condition",
+			rule:  `{to: [{operation: {methods: [GET]}}]}`,
+			other: `{to: [{operation: {methods: [GET], paths: [/foo]}}], when: [{key: "request.headers[x]", values: [z]}]}`,
+			want:  true,
+		},
+		{
+			name:  "missing condition",
+			rule:  `{to: [{operation: {methods: [GET], paths: [/foo]}}]}`,
+			other: `{to: [{operation: {methods: [GET]}}]}`,
+			want:  false,
+		},
+		{
+			name:  "prefix",
+			rule:  `{to: [{operation: {paths: [/api/*]}}]}`,
+			other: `{to: [{operation: {paths: [/api/v1/*, /api/v2]}}]}`,
+			want:  true,
+		},
+		{
+			name:  "prefix does not cover suffix",
+			rule:  `{to: [{operation: {paths: [/api/*]}}]}`,
+			other: `{to: [{operation: {paths: ["*/api"]}}]}`,
+			want:  false,
+		},
+		{
+			name:  "suffix",
+			rule:  `{to: [{operation: {hosts: ["*.example.com"]}}]}`,
+			other: `{to: [{operation: {hosts: [Foo.Example.com, "*.bar.example.com"]}}]}`,
+			want:  true,
+		},
+		{
+			name:  "path templates",
+			rule:  `{to: [{operation: {paths: ["/foo/{*}"]}}]}`,
+			other: `{to: [{operation: {paths: [/foo/bar]}}]}`,
+			want:  false,
+		},
+		{
+			name:  "cidr",
+			rule:  `{from: [{source: {ipBlocks: [10.0.0.0/8]}}]}`,
+			other: `{from: [{source: {ipBlocks: [10.1.0.0/16, 10.2.3.4]}}]}`,
+			want:  true,
+		},
+		{
+			name:  "narrower cidr",
+			rule:  `{from: [{source: {ipBlocks: [10.1.0.0/16]}}]}`,
+			other: `{from: [{source: {ipBlocks: [10.0.0.0/8]}}]}`,
+			want:  false,
+		},
+		{
+			name:  "not values",
+			rule:  `{from: [{source: {notNamespaces: [foo-*]}}]}`,
+			other: `{from: [{source: {notNamespaces: [foo-*, bar]}}]}`,
+			want:  true,
+		},
+		{
+			name:  "not values excluded by values",
+			rule:  `{from: [{source: {notNamespaces: [foo-*]}}]}`,
+			other: `{from: [{source: {namespaces: [bar, baz]}}]}`,
+			want:  true,
+		},
+		{
+			name:  "not values matched by values",
+			rule:  `{from: [{source: {notNamespaces: [foo-*]}}]}`,
+			other: `{from: [{source: {namespaces: [bar, foo-bar]}}]}`,
+			want:  false,
+		},
+		{
+			name:  "not values with pattern values",
+			rule:  `{from: [{source: {notNamespaces: [foo]}}]}`,
+			other: `{from: [{source: {namespaces: [ba*]}}]}`,
+			want:  false,
+		},
+		{
+			name:  "not values not excluded",
+			rule:  `{from: [{source: {notNamespaces: [foo]}}]}`,
+			other: `{from: [{source: {principals: [a]}}]}`,
+			want:  false,
+		},
+		{
+			name:  "each source covered",
+			rule:  `{from: [{source: {namespaces: [foo]}}, {source: {namespaces: [bar]}}]}`,
+			other: `{from: [{source: {namespaces: [bar], principals: [a]}}, {source: {namespaces: [foo]}}]}`,
+			want:  true,
+		},
+		{
+			name:  "a source not covered",
+			rule:  `{from: [{source: {namespaces: [foo]}}]}`,
+			other: `{from: [{source: {namespaces: [foo]}}, {source: {namespaces: [bar]}}]}`,
+			want:  false,
+		},
+		{
+			name:  "when and operation on the same attribute",
+			rule:  `{to: [{operation: {ports: ["80"]}}]}`,
+			other: `{when: [{key: destination.port, values: ["80"]}]}`,
+			want:  true,
+		},
+	}
+	name := types.NamespacedName{Name: "policy", Namespace: "ns"}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := New(name, yamlRule(t, tc.rule))
+			if err != nil {
+				t.Fatal(err)
+			}
+			other, err := New(name, yamlRule(t, tc.other))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.Covers(other); got != tc.want {
+				t.Errorf("Covers() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulation
+
+import (
+	"fmt"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/core"
+	"istio.io/istio/pilot/pkg/serviceregistry"
+	"istio.io/istio/pilot/pkg/serviceregistry/aggregate"
+	memregistry "istio.io/istio/pilot/pkg/serviceregistry/memory"
+	"istio.io/istio/pilot/pkg/serviceregistry/provider"
+	"istio.io/istio/pilot/pkg/serviceregistry/serviceentry"
+	cluster2 "istio.io/istio/pkg/cluster"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/mesh/meshwatcher"
+	"istio.io/istio/pkg/config/schema/collections"
+	kubelib "istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/krt"
+)
+
+// ConfigOptions is the configuration a simulated proxy's resources are generated from.
+type ConfigOptions struct {
+	// Configs are the Istio configs, such as ServiceEntries and VirtualServices.
+	Configs []config.Config
+	// Services and Instances to populate service discovery with, in addition to ServiceEntries.
+	Services  []*model.Service
+	Instances []*model.ServiceInstance
+	// If provided, this mesh config will be used
+	MeshConfig *meshconfig.MeshConfig
+}
+
+// NewSimulationFromConfig generates the resources of proxy from in memory config and service registries, and
+// builds a simulation from them. Unlike NewSimulationFromConfigGen, this does not require a test, so it can be
+// used by tools such as istioctl.
+func NewSimulationFromConfig(opts ConfigOptions, proxy *model.Proxy) (*Simulation, error) {
+	store := memory.NewSyncController(memory.Make(collections.Pilot))
+
+	m := opts.MeshConfig
+	if m == nil {
+		m = mesh.DefaultMeshConfig()
+	}
+	env := model.NewEnvironment()
+	env.Watcher = meshwatcher.ConfigAdapter(krt.NewStatic(&meshwatcher.MeshConfigResource{MeshConfig: m}, true))
+	env.NetworksWatcher = meshwatcher.NetworksAdapter(krt.NewStatic(&meshwatcher.MeshNetworksResource{}, true))
+	xdsUpdater := model.NewEndpointIndexUpdater(env.EndpointIndex)
+
+	registry := aggregate.NewController(aggregate.Options{})
+	se := serviceentry.NewController(store, xdsUpdater, env.Watcher)
+	registry.AddRegistry(se)
+	msd := memregistry.NewServiceDiscovery(opts.Services...)
+	msd.XdsUpdater = xdsUpdater
+	for _, instance := range opts.Instances {
+		msd.AddInstance(instance)
+	}
+	msd.ClusterID = cluster2.ID(provider.Mock)
+	registry.AddRegistry(serviceregistry.Simple{
+		ClusterID:           cluster2.ID(provider.Mock),
+		ProviderID:          provider.Mock,
+		DiscoveryController: msd,
+	})
+	env.ServiceDiscovery = registry
+	env.ConfigStore = store
+	env.Init()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go registry.Run(stop)
+	go store.Run(stop)
+	for _, cfg := range opts.Configs {
+		if _, err := store.Create(cfg); err != nil {
+			return nil, fmt.Errorf("failed to create config %v/%v: %v", cfg.Namespace, cfg.Name, err)
+		}
+	}
+	kubelib.WaitForCacheSync("simulation", stop, store.HasSynced, registry.HasSynced)
+	se.ResyncEDS()
+	if err := env.InitNetworksManager(xdsUpdater); err != nil {
+		return nil, err
+	}
+	push := env.PushContext()
+	push.InitContext(env, nil, nil)
+
+	proxy = setupProxy(env, proxy)
+	cg := core.NewConfigGenerator(&model.DisabledCache{})
+	listeners := cg.BuildListeners(proxy, push)
+	raw, _ := cg.BuildClusters(proxy, &model.PushRequest{Push: push})
+	clusters := make([]*cluster.Cluster, 0, len(raw))
+	for _, r := range raw {
+		c := &cluster.Cluster{}
+		if err := r.Resource.UnmarshalTo(c); err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, c)
+	}
+	resources, _ := cg.BuildHTTPRoutes(proxy, &model.PushRequest{Push: push}, core.ExtractRoutesFromListeners(listeners))
+	routes := make([]*route.RouteConfiguration, 0, len(resources))
+	for _, r := range resources {
+		rc := &route.RouteConfiguration{}
+		if err := r.Resource.UnmarshalTo(rc); err != nil {
+			return nil, err
+		}
+		routes = append(routes, rc)
+	}
+	return NewSimulationFromResources(listeners, clusters, routes), nil
+}
+
+// setupProxy fills in the defaults of proxy and initializes it against the environment, like a connecting proxy.
+func setupProxy(env *model.Environment, p *model.Proxy) *model.Proxy {
+	if p.Metadata == nil {
+		p.Metadata = &model.NodeMetadata{}
+	}
+	if p.Metadata.IstioVersion == "" {
+		p.Metadata.IstioVersion = "1.23.0"
+	}
+	if p.IstioVersion == nil {
+		p.IstioVersion = model.ParseIstioVersion(p.Metadata.IstioVersion)
+	}
+	if p.Type == "" {
+		p.Type = model.SidecarProxy
+	}
+	if p.ConfigNamespace == "" {
+		p.ConfigNamespace = "default"
+	}
+	if p.Metadata.Namespace == "" {
+		p.Metadata.Namespace = p.ConfigNamespace
+	}
+	if p.ID == "" {
+		p.ID = "app." + p.ConfigNamespace
+	}
+	if p.DNSDomain == "" {
+		p.DNSDomain = p.ConfigNamespace + ".svc." + env.DomainSuffix
+	}
+	if len(p.IPAddresses) == 0 {
+		p.IPAddresses = []string{"1.1.1.1"}
+	}
+
+	pc := env.PushContext()
+	p.SetSidecarScope(pc)
+	p.SetServiceTargets(env.ServiceDiscovery)
+	p.SetGatewaysForProxy(pc)
+	p.DiscoverIPMode()
+	return p
+}
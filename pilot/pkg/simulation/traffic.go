@@ -30,6 +30,8 @@ import (
 	envoycore "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	tcpproxy "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
 	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -38,14 +40,12 @@ import (
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/networking/core"
 	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
-	"istio.io/istio/pilot/test/xds"
-	"istio.io/istio/pilot/test/xdstest"
 	"istio.io/istio/pkg/config/host"
 	istiolog "istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/maps"
 	"istio.io/istio/pkg/slices"
-	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/pkg/wellknown"
 )
 
 var log = istiolog.RegisterScope("simulation", "")
@@ -176,7 +176,7 @@ type Result struct {
 	// if we pass the test. This is to ensure that if the behavior changes, we still capture it; the skip
 	// just ensures we notice a test is wrong
 	Skip string
-	t    test.Failer
+	t    *testing.T
 }
 
 func (r Result) Matches(t *testing.T, want Result) {
@@ -249,8 +249,14 @@ func NewSimulationFromConfigGen(t *testing.T, s *core.ConfigGenTest, proxy *mode
 	return sim
 }
 
-func NewSimulation(t *testing.T, s *xds.FakeDiscoveryServer, proxy *model.Proxy) *Simulation {
-	return NewSimulationFromConfigGen(t, s.ConfigGenTest, proxy)
+// NewSimulationFromResources builds a simulation directly from already generated resources, for example
+// those extracted from an Envoy config dump. This does not require a running config generator.
+func NewSimulationFromResources(listeners []*listener.Listener, clusters []*cluster.Cluster, routes []*route.RouteConfiguration) *Simulation {
+	return &Simulation{
+		Listeners: listeners,
+		Clusters:  clusters,
+		Routes:    routes,
+	}
 }
 
 // withT swaps out the testing struct. This allows executing sub tests.
@@ -269,14 +275,40 @@ func (sim *Simulation) RunExpectations(es []Expect) {
 }
 
 func hasFilterOnPort(l *listener.Listener, filter string, port int) bool {
-	got, f := xdstest.ExtractListenerFilters(l)[filter]
-	if !f {
+	f := slices.FindFunc(l.ListenerFilters, func(lf *listener.ListenerFilter) bool {
+		return lf.Name == filter
+	})
+	if f == nil {
 		return false
 	}
+	got := *f
 	if got.FilterDisabled == nil {
 		return true
 	}
-	return !xdstest.EvaluateListenerFilterPredicates(got.FilterDisabled, port)
+	return !evaluateListenerFilterPredicates(got.FilterDisabled, port)
+}
+
+func evaluateListenerFilterPredicates(predicate *listener.ListenerFilterChainMatchPredicate, port int) bool {
+	if predicate == nil {
+		return true
+	}
+	switch r := predicate.Rule.(type) {
+	case *listener.ListenerFilterChainMatchPredicate_NotMatch:
+		return !evaluateListenerFilterPredicates(r.NotMatch, port)
+	case *listener.ListenerFilterChainMatchPredicate_OrMatch:
+		matches := false
+		for _, r := range r.OrMatch.Rules {
+			matches = matches || evaluateListenerFilterPredicates(r, port)
+		}
+		return matches
+	case *listener.ListenerFilterChainMatchPredicate_DestinationPortRange:
+		return int32(port) >= r.DestinationPortRange.GetStart() && int32(port) < r.DestinationPortRange.GetEnd()
+	case *listener.ListenerFilterChainMatchPredicate_AnyMatch:
+		return r.AnyMatch
+	default:
+		log.Warnf("unsupported listener filter predicate %T", r)
+		return false
+	}
 }
 
 func (sim *Simulation) Run(input Call) (result Result) {
@@ -326,8 +358,13 @@ func (sim *Simulation) Run(input Call) (result Result) {
 		mTLSSecretConfigName = input.MtlsSecretConfigName
 	}
 
+	mtls, err := sim.requiresMTLS(fc, mTLSSecretConfigName)
+	if err != nil {
+		result.Error = err
+		return
+	}
 	// mTLS listener will only accept mTLS traffic
-	if fc.TransportSocket != nil && sim.requiresMTLS(fc, mTLSSecretConfigName) != (input.TLS == MTLS) {
+	if fc.TransportSocket != nil && mtls != (input.TLS == MTLS) {
 		// If there is no tls inspector, then
 		result.Error = ErrMTLSError
 		return
@@ -341,7 +378,12 @@ func (sim *Simulation) Run(input Call) (result Result) {
 		}
 	}
 
-	if hcm := xdstest.ExtractHTTPConnectionManager(sim.t, fc); hcm != nil {
+	hcm, tcp, err := extractNetworkFilter(fc)
+	if err != nil {
+		result.Error = err
+		return
+	}
+	if hcm != nil {
 		// We matched HCM and didn't terminate TLS, but we are sending TLS traffic - decoding will fail
 		if input.TLS != Plaintext && fc.TransportSocket == nil {
 			result.Error = ErrProtocolError
@@ -359,7 +401,11 @@ func (sim *Simulation) Run(input Call) (result Result) {
 			// If not set, fallback to RDS
 			routeName := hcm.GetRds().RouteConfigName
 			result.RouteConfigMatched = routeName
-			rc = xdstest.ExtractRouteConfigurations(sim.Routes)[routeName]
+			if r := slices.FindFunc(sim.Routes, func(rc *route.RouteConfiguration) bool {
+				return rc.Name == routeName
+			}); r != nil {
+				rc = *r
+			}
 		}
 		hostHeader := ""
 		if len(input.Headers["Host"]) > 0 {
@@ -376,7 +422,11 @@ func (sim *Simulation) Run(input Call) (result Result) {
 			return
 		}
 
-		r := sim.matchRoute(vh, input)
+		r, err := sim.matchRoute(vh, input)
+		if err != nil {
+			result.Error = err
+			return
+		}
 		if r == nil {
 			result.Error = ErrNoRoute
 			return
@@ -386,35 +436,60 @@ func (sim *Simulation) Run(input Call) (result Result) {
 		case *route.Route_Route:
 			result.ClusterMatched = t.Route.GetCluster()
 		}
-	} else if tcp := xdstest.ExtractTCPProxy(sim.t, fc); tcp != nil {
+	} else if tcp != nil {
 		result.ClusterMatched = tcp.GetCluster()
 	}
 	return
 }
 
-func (sim *Simulation) requiresMTLS(fc *listener.FilterChain, mTLSSecretConfigName string) bool {
+// extractNetworkFilter returns the HTTP connection manager or TCP proxy of the filter chain, if any.
+func extractNetworkFilter(fc *listener.FilterChain) (*hcm.HttpConnectionManager, *tcpproxy.TcpProxy, error) {
+	for _, f := range fc.Filters {
+		switch f.Name {
+		case wellknown.HTTPConnectionManager:
+			h := &hcm.HttpConnectionManager{}
+			if f.GetTypedConfig() != nil {
+				if err := f.GetTypedConfig().UnmarshalTo(h); err != nil {
+					return nil, nil, fmt.Errorf("failed to unmarshal hcm: %v", err)
+				}
+			}
+			return h, nil, nil
+		case wellknown.TCPProxy:
+			tcp := &tcpproxy.TcpProxy{}
+			if f.GetTypedConfig() != nil {
+				if err := f.GetTypedConfig().UnmarshalTo(tcp); err != nil {
+					return nil, nil, fmt.Errorf("failed to unmarshal tcp proxy: %v", err)
+				}
+			}
+			return nil, tcp, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+func (sim *Simulation) requiresMTLS(fc *listener.FilterChain, mTLSSecretConfigName string) (bool, error) {
 	if fc.TransportSocket == nil {
-		return false
+		return false, nil
 	}
 	t := &tls.DownstreamTlsContext{}
 	if err := fc.GetTransportSocket().GetTypedConfig().UnmarshalTo(t); err != nil {
-		sim.t.Fatal(err)
+		return false, err
 	}
 
 	if len(t.GetCommonTlsContext().GetTlsCertificateSdsSecretConfigs()) == 0 {
-		return false
+		return false, nil
 	}
 	// This is a lazy heuristic, we could check for explicit default resource or spiffe if it becomes necessary
 	if t.GetCommonTlsContext().GetTlsCertificateSdsSecretConfigs()[0].Name != mTLSSecretConfigName {
-		return false
+		return false, nil
 	}
 	if !t.RequireClientCertificate.Value {
-		return false
+		return false, nil
 	}
-	return true
+	return true, nil
 }
 
-func (sim *Simulation) matchRoute(vh *route.VirtualHost, input Call) *route.Route {
+func (sim *Simulation) matchRoute(vh *route.VirtualHost, input Call) (*route.Route, error) {
 	for _, r := range vh.Routes {
 		// check path
 		switch pt := r.Match.GetPathSpecifier().(type) {
@@ -433,20 +508,20 @@ func (sim *Simulation) matchRoute(vh *route.VirtualHost, input Call) *route.Rout
 		case *route.RouteMatch_SafeRegex:
 			r, err := regexp.Compile(pt.SafeRegex.GetRegex())
 			if err != nil {
-				sim.t.Fatalf("invalid regex %v: %v", pt.SafeRegex.GetRegex(), err)
+				return nil, fmt.Errorf("invalid regex %v: %v", pt.SafeRegex.GetRegex(), err)
 			}
 			if !r.MatchString(input.Path) {
 				continue
 			}
 		default:
-			sim.t.Fatalf("unknown route path type %T", pt)
+			return nil, fmt.Errorf("unknown route path type %T", pt)
 		}
 
 		// TODO this only handles path - we need to add headers, query params, etc to be complete.
 
-		return r
+		return r, nil
 	}
-	return nil
+	return nil, nil
 }
 
 func (sim *Simulation) matchVirtualHost(rc *route.RouteConfiguration, host string) *route.VirtualHost {
@@ -519,21 +594,30 @@ func (sim *Simulation) matchFilterChain(chains []*listener.FilterChain, defaultC
 	chains = filter("DestinationPort", chains, (*listener.FilterChainMatch).GetDestinationPort, func(port *wrapperspb.UInt32Value) bool {
 		return int(port.GetValue()) == input.Port
 	})
+	addr, err := netip.ParseAddr(input.Address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q: %v", input.Address, err)
+	}
+	var cidrErr error
 	chains = filterRank("PrefixRanges", chains, (*listener.FilterChainMatch).GetPrefixRanges, func(ranges []*envoycore.CidrRange) int {
 		best := 0
 		for _, a := range ranges {
 			s := fmt.Sprintf("%s/%d", a.AddressPrefix, a.GetPrefixLen().GetValue())
 			cidr, err := netip.ParsePrefix(s)
 			if err != nil {
-				sim.t.Fatalf("failed to parse cidr %v: %v", s, err)
+				cidrErr = fmt.Errorf("failed to parse cidr %v: %v", s, err)
+				continue
 			}
-			if cidr.Contains(netip.MustParseAddr(input.Address)) {
+			if cidr.Contains(addr) {
 				// Rank by how exact of a match it is. A /32 should match before a /8 even if they both match.
 				best = max(cidr.Bits(), best)
 			}
 		}
 		return best
 	})
+	if cidrErr != nil {
+		return nil, cidrErr
+	}
 	chains = filterRank("ServerNames", chains, (*listener.FilterChainMatch).GetServerNames, func(serverNames []string) int {
 		sni := host.Name(input.Sni)
 		best := 0
@@ -674,7 +758,12 @@ func protocolToAlpn(s Protocol) string {
 
 func matchListener(listeners []*listener.Listener, input Call) *listener.Listener {
 	if input.CallMode == CallModeInbound {
-		return xdstest.ExtractListener(model.VirtualInboundListenerName, listeners)
+		if l := slices.FindFunc(listeners, func(l *listener.Listener) bool {
+			return l.Name == model.VirtualInboundListenerName
+		}); l != nil {
+			return *l
+		}
+		return nil
 	}
 	// First find exact match for the IP/Port, then fallback to wildcard IP/Port
 	// There is no wildcard port
@@ -148,7 +148,7 @@ func (s *DiscoveryServer) processRequest(req *discovery.DiscoveryRequest, con *C
 	if strings.HasPrefix(req.TypeUrl, v3.DebugType) {
 		return s.pushXds(con,
 			&model.WatchedResource{TypeUrl: req.TypeUrl, ResourceNames: sets.New(req.ResourceNames...)},
-			&model.PushRequest{Full: true, Push: con.proxy.LastPushContext})
+			&model.PushRequest{Full: true, Push: con.proxy.LastPushContext}, nil)
 	}
 
 	shouldRespond, delta := xds.ShouldRespond(con.proxy, con.ID(), req)
@@ -175,7 +175,7 @@ func (s *DiscoveryServer) processRequest(req *discovery.DiscoveryRequest, con *C
 	if con.proxy.SidecarScope != nil && con.proxy.SidecarScope.Version != request.Push.PushVersion {
 		s.computeProxyState(con.proxy, request)
 	}
-	return s.pushXds(con, con.proxy.GetWatchedResource(req.TypeUrl), request)
+	return s.pushXds(con, con.proxy.GetWatchedResource(req.TypeUrl), request, nil)
 }
 
 // StreamAggregatedResources implements the ADS interface.
@@ -480,11 +480,14 @@ func (s *DiscoveryServer) pushConnection(con *Connection, pushEv *Event) error {
 		return nil
 	}
 
+	trace := s.pushTracer.start(con, pushRequest)
+	defer s.pushTracer.finish(trace)
+
 	// Send pushes to all generators
 	// Each Generator is responsible for determining if the push event requires a push
 	wrl := con.watchedResourcesByOrder()
 	for _, w := range wrl {
-		if err := s.pushXds(con, w, pushRequest); err != nil {
+		if err := s.pushXds(con, w, pushRequest, trace); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,175 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"istio.io/istio/pilot/pkg/model"
+)
+
+// debounceRateHalfLife is the half-life of the event rate estimate; events older than a few half-lives are ignored.
+const debounceRateHalfLife = 10 * time.Second
+
+// debouncePushWeight is the weight of the latest push in the push duration estimate.
+const debouncePushWeight = 0.3
+
+// adaptiveDebounce tunes the debounce quiet window from the recent event rate and push duration.
+//
+// The quiet window of each push type is:
+//
+//	min + rate * duration * duration
+//
+// where rate is the rate of events and duration the time a push takes, bounded by the min and max windows.
+// rate * duration is the number of events expected to arrive while pushing: when events arrive slower than
+// pushes complete, the window stays close to min, so quiet periods do not get extra latency. During event
+// storms, the window grows towards max so bursts of events are merged in fewer pushes.
+// Full and EDS-only pushes have separate windows, as their rates and costs differ widely.
+type adaptiveDebounce struct {
+	min time.Duration
+	max time.Duration
+
+	mu   sync.Mutex
+	full debounceStats
+	eds  debounceStats
+}
+
+// debounceStats is the state of the window of a push type.
+type debounceStats struct {
+	// rate is the decaying event rate, in events per second, as of lastEvent.
+	rate      float64
+	lastEvent time.Time
+	// duration is the moving average of the push duration.
+	duration time.Duration
+}
+
+func newAdaptiveDebounce(minWindow, maxWindow time.Duration) *adaptiveDebounce {
+	return &adaptiveDebounce{min: minWindow, max: max(minWindow, maxWindow)}
+}
+
+func (a *adaptiveDebounce) stats(full bool) *debounceStats {
+	if full {
+		return &a.full
+	}
+	return &a.eds
+}
+
+// rateAt returns the event rate at now, decayed since the last event.
+func (s *debounceStats) rateAt(now time.Time) float64 {
+	if s.lastEvent.IsZero() {
+		return 0
+	}
+	elapsed := now.Sub(s.lastEvent).Seconds()
+	return s.rate * math.Exp2(-elapsed/debounceRateHalfLife.Seconds())
+}
+
+// RecordEvent records an event for a full or EDS-only push.
+func (a *adaptiveDebounce) RecordEvent(full bool, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := a.stats(full)
+	// Each event adds to the rate so that a steady stream of r events per second converges to r.
+	s.rate = s.rateAt(now) + math.Ln2/debounceRateHalfLife.Seconds()
+	s.lastEvent = now
+}
+
+// RecordPush records the time a full or EDS-only push took, until all its connections were sent it.
+func (a *adaptiveDebounce) RecordPush(full bool, d time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := a.stats(full)
+	if s.duration == 0 {
+		s.duration = d
+		return
+	}
+	s.duration = time.Duration(debouncePushWeight*float64(d) + (1-debouncePushWeight)*float64(s.duration))
+}
+
+// Window returns the quiet window for a full or EDS-only push.
+func (a *adaptiveDebounce) Window(full bool, now time.Time) time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.window(a.stats(full), now)
+}
+
+func (a *adaptiveDebounce) window(s *debounceStats, now time.Time) time.Duration {
+	d := s.duration.Seconds()
+	w := a.min + time.Duration(s.rateAt(now)*d*d*float64(time.Second))
+	if w > a.max || w < 0 {
+		// w may overflow with extreme values
+		return a.max
+	}
+	return w
+}
+
+// DebounceStatus describes the current debounce windows.
+type DebounceStatus struct {
+	Adaptive bool                 `json:"adaptive"`
+	Full     DebounceWindowStatus `json:"full"`
+	EDS      DebounceWindowStatus `json:"eds"`
+	Max      string               `json:"max"`
+}
+
+// DebounceWindowStatus describes the debounce window of a push type.
+type DebounceWindowStatus struct {
+	Window string `json:"window"`
+	// EventRate is the recent rate of events per second. Only set with adaptive debounce.
+	EventRate float64 `json:"eventRate,omitempty"`
+	// PushDuration is the average duration of a push. Only set with adaptive debounce.
+	PushDuration string `json:"pushDuration,omitempty"`
+}
+
+// status returns the current debounce windows.
+func (o DebounceOptions) status(now time.Time) DebounceStatus {
+	res := DebounceStatus{Max: o.debounceMax.String()}
+	if o.adaptive == nil {
+		res.Full.Window = o.DebounceAfter.String()
+		res.EDS.Window = o.DebounceAfter.String()
+		return res
+	}
+	res.Adaptive = true
+	a := o.adaptive
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, w := range []struct {
+		stats  *debounceStats
+		status *DebounceWindowStatus
+	}{{&a.full, &res.Full}, {&a.eds, &res.EDS}} {
+		w.status.Window = a.window(w.stats, now).String()
+		w.status.EventRate = w.stats.rateAt(now)
+		w.status.PushDuration = w.stats.duration.String()
+	}
+	return res
+}
+
+// quietWindow returns the time without events to wait for before pushing req.
+func (o DebounceOptions) quietWindow(req *model.PushRequest) time.Duration {
+	if o.adaptive == nil {
+		return o.DebounceAfter
+	}
+	full := req != nil && req.Full
+	w := o.adaptive.Window(full, time.Now())
+	debounceWindow.With(typeTag.Value(pushType(full))).Record(w.Seconds())
+	return w
+}
+
+func pushType(full bool) string {
+	if full {
+		return "full"
+	}
+	return "eds"
+}
@@ -0,0 +1,79 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+	"time"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/monitoring/monitortest"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestAdaptiveDebounce(t *testing.T) {
+	now := time.Now()
+	a := newAdaptiveDebounce(10*time.Millisecond, time.Second)
+
+	// Without events or pushes, the minimum window is used
+	assert.Equal(t, a.Window(true, now), 10*time.Millisecond)
+
+	// Rare events do not add latency
+	a.RecordPush(true, 100*time.Millisecond)
+	a.RecordEvent(true, now)
+	assert.Equal(t, a.Window(true, now) < 11*time.Millisecond, true)
+
+	// A storm of 100 events per second for a minute, with pushes taking 50ms, merges events.
+	// About 5 events are expected to arrive during a push, so the window is about 5*50ms.
+	a = newAdaptiveDebounce(10*time.Millisecond, time.Second)
+	a.RecordPush(true, 50*time.Millisecond)
+	for i := range 6000 {
+		a.RecordEvent(true, now.Add(time.Duration(i)*10*time.Millisecond))
+	}
+	now = now.Add(time.Minute)
+	storm := a.Window(true, now)
+	assert.Equal(t, storm > 200*time.Millisecond && storm < 300*time.Millisecond, true)
+
+	// Slower pushes lead to larger windows, up to the maximum
+	a.RecordPush(true, time.Second)
+	assert.Equal(t, a.Window(true, now), time.Second)
+
+	// EDS pushes are tuned separately
+	assert.Equal(t, a.Window(false, now), 10*time.Millisecond)
+
+	// The window shrinks back once the storm is over
+	assert.Equal(t, a.Window(true, now.Add(5*time.Minute)) < 20*time.Millisecond, true)
+
+	status := DebounceOptions{debounceMax: 10 * time.Second, adaptive: a}.status(now)
+	assert.Equal(t, status.Adaptive, true)
+	assert.Equal(t, status.Full.Window, time.Second.String())
+	assert.Equal(t, status.EDS.Window, (10 * time.Millisecond).String())
+}
+
+func TestAdaptiveDebounceMetrics(t *testing.T) {
+	mt := monitortest.New(t)
+	opts := DebounceOptions{
+		DebounceAfter: 100 * time.Millisecond,
+		adaptive:      newAdaptiveDebounce(20*time.Millisecond, time.Second),
+	}
+	assert.Equal(t, opts.quietWindow(&model.PushRequest{Full: true}), 20*time.Millisecond)
+	mt.Assert(debounceWindow.Name(), map[string]string{"type": "full"}, monitortest.Exactly(0.02))
+	assert.Equal(t, opts.quietWindow(nil), 20*time.Millisecond)
+	mt.Assert(debounceWindow.Name(), map[string]string{"type": "eds"}, monitortest.Exactly(0.02))
+
+	opts.adaptive = nil
+	assert.Equal(t, opts.quietWindow(&model.PushRequest{Full: true}), 100*time.Millisecond)
+	assert.Equal(t, opts.status(time.Now()), DebounceStatus{Full: DebounceWindowStatus{Window: "100ms"}, EDS: DebounceWindowStatus{Window: "100ms"}, Max: "0s"})
+}
@@ -45,6 +45,7 @@ import (
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/schema/resource"
 	"istio.io/istio/pkg/config/xds"
+	"istio.io/istio/pkg/kube/krt"
 	istiolog "istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/maps"
 	"istio.io/istio/pkg/security"
@@ -206,11 +207,16 @@ func (s *DiscoveryServer) AddDebugHandlers(mux, internalMux *http.ServeMux, enab
 	s.addDebugHandler(mux, internalMux, "/debug/instancesz", "Debug support for service instances", s.instancesz)
 	s.addDebugHandler(mux, internalMux, "/debug/ambientz", "Debug support for ambient", s.ambientz)
 	s.addDebugHandler(mux, internalMux, "/debug/krtz", "Debug support for krt (internal state)", s.krtz)
+	s.addDebugHandler(mux, internalMux, "/debug/krt_tracez", "Latest recomputations of krt collections, if PILOT_KRT_EVENT_TRACE_SIZE is set",
+		s.krtTracez)
+	s.addDebugHandler(mux, internalMux, "/debug/push_tracez", "Latest pushes to proxies and their cost, if PILOT_PUSH_TRACE_SIZE is set",
+		s.pushTracez)
 
 	s.addDebugHandler(mux, internalMux, "/debug/authorizationz", "Internal authorization policies", s.authorizationz)
 	s.addDebugHandler(mux, internalMux, "/debug/telemetryz", "Debug Telemetry configuration", s.telemetryz)
 	s.addDebugHandler(mux, internalMux, "/debug/config_dump", "ConfigDump in the form of the Envoy admin config dump API for passed in proxyID", s.ConfigDump)
 	s.addDebugHandler(mux, internalMux, "/debug/push_status", "Last PushContext Details", s.pushStatusHandler)
+	s.addDebugHandler(mux, internalMux, "/debug/debouncez", "Current debounce windows of pushes", s.debouncez)
 	s.addDebugHandler(mux, internalMux, "/debug/pushcontext", "Debug support for current push context", s.pushContextHandler)
 	s.addDebugHandler(mux, internalMux, "/debug/connections", "Info about the connected XDS clients", s.connectionsHandler)
 
@@ -844,6 +850,11 @@ func (s *DiscoveryServer) pushStatusHandler(w http.ResponseWriter, req *http.Req
 	_, _ = w.Write(out)
 }
 
+// debouncez dumps the current debounce windows
+func (s *DiscoveryServer) debouncez(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, s.DebounceOptions.status(time.Now()), req)
+}
+
 // PushContextDebug holds debug information for push context.
 type PushContextDebug struct {
 	AuthorizationPolicies *model.AuthorizationPolicies
@@ -1031,6 +1042,27 @@ func (s *DiscoveryServer) krtz(w http.ResponseWriter, req *http.Request) {
 	writeJSON(w, s.krtDebugger, req)
 }
 
+// krtTracez returns the latest recomputations of krt collections, optionally filtered by ?collection=<name>.
+func (s *DiscoveryServer) krtTracez(w http.ResponseWriter, req *http.Request) {
+	trace := s.krtDebugger.Trace()
+	if collection := req.URL.Query().Get("collection"); collection != "" {
+		trace = slices.FilterInPlace(trace, func(e krt.TraceEvent) bool {
+			return e.Collection == collection
+		})
+	}
+	writeJSON(w, trace, req)
+}
+
+func (s *DiscoveryServer) pushTracez(w http.ResponseWriter, req *http.Request) {
+	traces := s.pushTracer.list()
+	if proxyID := req.URL.Query().Get("proxyID"); proxyID != "" {
+		traces = slices.FilterInPlace(traces, func(t *PushTrace) bool {
+			return t.Proxy == proxyID
+		})
+	}
+	writeJSON(w, traces, req)
+}
+
 func (s *DiscoveryServer) networkz(w http.ResponseWriter, req *http.Request) {
 	if s.Env == nil || s.Env.NetworkManager == nil {
 		return
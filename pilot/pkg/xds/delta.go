@@ -161,11 +161,14 @@ func (s *DiscoveryServer) pushConnectionDelta(con *Connection, pushEv *Event) er
 		return nil
 	}
 
+	trace := s.pushTracer.start(con, pushRequest)
+	defer s.pushTracer.finish(trace)
+
 	// Send pushes to all generators
 	// Each Generator is responsible for determining if the push event requires a push
 	wrl := con.watchedResourcesByOrder()
 	for _, w := range wrl {
-		if err := s.pushDeltaXds(con, w, pushRequest); err != nil {
+		if err := s.pushDeltaXds(con, w, pushRequest, trace); err != nil {
 			return err
 		}
 	}
@@ -274,7 +277,7 @@ func (s *DiscoveryServer) processDeltaRequest(req *discovery.DeltaDiscoveryReque
 	if strings.HasPrefix(req.TypeUrl, v3.DebugType) {
 		return s.pushDeltaXds(con,
 			&model.WatchedResource{TypeUrl: req.TypeUrl, ResourceNames: sets.New(req.ResourceNamesSubscribe...)},
-			&model.PushRequest{Full: true, Push: con.proxy.LastPushContext})
+			&model.PushRequest{Full: true, Push: con.proxy.LastPushContext}, nil)
 	}
 
 	shouldRespond := shouldRespondDelta(con, req)
@@ -305,7 +308,7 @@ func (s *DiscoveryServer) processDeltaRequest(req *discovery.DeltaDiscoveryReque
 		s.computeProxyState(con.proxy, request)
 	}
 
-	err := s.pushDeltaXds(con, con.proxy.GetWatchedResource(req.TypeUrl), request)
+	err := s.pushDeltaXds(con, con.proxy.GetWatchedResource(req.TypeUrl), request, nil)
 	if err != nil {
 		return err
 	}
@@ -340,7 +343,7 @@ func (s *DiscoveryServer) forceEDSPush(con *Connection) error {
 			Start:  con.proxy.LastPushTime,
 		}
 		deltaLog.Infof("ADS:%s: FORCE %s PUSH for warming.", v3.GetShortType(v3.EndpointType), con.ID())
-		return s.pushDeltaXds(con, dwr, request)
+		return s.pushDeltaXds(con, dwr, request, nil)
 	}
 	return nil
 }
@@ -458,8 +461,8 @@ func shouldRespondDelta(con *Connection, request *discovery.DeltaDiscoveryReques
 	return true
 }
 
-// Push a Delta XDS resource for the given connection.
-func (s *DiscoveryServer) pushDeltaXds(con *Connection, w *model.WatchedResource, req *model.PushRequest) error {
+// Push a Delta XDS resource for the given connection. If trace is set, the cost of the generator is recorded in it.
+func (s *DiscoveryServer) pushDeltaXds(con *Connection, w *model.WatchedResource, req *model.PushRequest, trace *PushTrace) error {
 	if w == nil {
 		return nil
 	}
@@ -501,6 +504,7 @@ func (s *DiscoveryServer) pushDeltaXds(con *Connection, w *model.WatchedResource
 		res, logdata, err = g.Generate(con.proxy, w, req)
 	}
 	if err != nil || (res == nil && deletedRes == nil) {
+		trace.recordSkip(w.TypeUrl, t0, err)
 		return err
 	}
 	defer func() { recordPushTime(w.TypeUrl, time.Since(t0)) }()
@@ -558,7 +562,9 @@ func (s *DiscoveryServer) pushDeltaXds(con *Connection, w *model.WatchedResource
 		info += logFiltered
 	}
 
-	if err := con.sendDelta(resp, newResourceNames); err != nil {
+	err = con.sendDelta(resp, newResourceNames)
+	trace.record(w.TypeUrl, t0, res, len(resp.RemovedResources), logdata, err)
+	if err != nil {
 		logger := deltaLog.Debugf
 		if recordSendError(w.TypeUrl, err) {
 			logger = deltaLog.Warnf
@@ -59,6 +59,10 @@ type DebounceOptions struct {
 
 	// enableEDSDebounce indicates whether EDS pushes should be debounced.
 	enableEDSDebounce bool
+
+	// adaptive tunes the quiet window from the recent event rate and push duration, instead of
+	// using DebounceAfter. If nil, DebounceAfter is used.
+	adaptive *adaptiveDebounce
 }
 
 // DiscoveryServer is Pilot's gRPC implementation for Envoy's xds APIs
@@ -135,6 +139,9 @@ type DiscoveryServer struct {
 	DiscoveryStartTime time.Time
 
 	krtDebugger *krt.DebugHandler
+
+	// pushTracer records the latest pushes, if PILOT_PUSH_TRACE_SIZE is set.
+	pushTracer *pushTracer
 }
 
 // NewDiscoveryServer creates DiscoveryServer that sources data from Pilot's internal mesh data structures
@@ -161,6 +168,10 @@ func NewDiscoveryServer(env *model.Environment, clusterAliases map[string]string
 		DiscoveryStartTime: processStartTime,
 	}
 
+	if features.EnableAdaptiveDebounce {
+		out.DebounceOptions.adaptive = newAdaptiveDebounce(features.AdaptiveDebounceMinWindow, features.AdaptiveDebounceMaxWindow)
+	}
+
 	out.ClusterAliases = make(map[cluster.ID]cluster.ID)
 	for alias := range clusterAliases {
 		out.ClusterAliases[cluster.ID(alias)] = cluster.ID(clusterAliases[alias])
@@ -171,6 +182,16 @@ func NewDiscoveryServer(env *model.Environment, clusterAliases map[string]string
 	return out
 }
 
+// EnablePushTracing records the latest size pushes to proxies, exposed on the /debug/push_tracez endpoint and exported as
+// OpenTelemetry spans. This must be called before the server is started.
+func (s *DiscoveryServer) EnablePushTracing(size int) {
+	if size <= 0 {
+		s.pushTracer = nil
+		return
+	}
+	s.pushTracer = newPushTracer(size)
+}
+
 // initJwkResolver initializes the JWT key resolver to be used.
 func (s *DiscoveryServer) initJwksResolver() {
 	if s.JwtKeyResolver != nil {
@@ -324,7 +345,19 @@ func (s *DiscoveryServer) ConfigUpdate(req *model.PushRequest) {
 // It ensures that at minimum minQuiet time has elapsed since the last event before processing it.
 // It also ensures that at most maxDelay is elapsed between receiving an event and processing it.
 func (s *DiscoveryServer) handleUpdates(stopCh <-chan struct{}) {
-	debounce(s.pushChannel, stopCh, s.DebounceOptions, s.Push, s.CommittedUpdates)
+	pushFn := s.Push
+	if a := s.DebounceOptions.adaptive; a != nil {
+		pushFn = func(req *model.PushRequest) {
+			pushStart := time.Now()
+			// Push only enqueues the connections, it is complete once all of them were sent the push.
+			enqueued := s.pushQueue.TrackPush(req, func() {
+				a.RecordPush(req.Full, time.Since(pushStart))
+			})
+			s.Push(req)
+			enqueued()
+		}
+	}
+	debounce(s.pushChannel, stopCh, s.DebounceOptions, pushFn, s.CommittedUpdates)
 }
 
 // The debounce helper function is implemented to enable mocking
@@ -352,8 +385,9 @@ func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, opts DebounceO
 	pushWorker := func() {
 		eventDelay := time.Since(startDebounce)
 		quietTime := time.Since(lastConfigUpdateTime)
+		window := opts.quietWindow(req)
 		// it has been too long or quiet enough
-		if eventDelay >= opts.debounceMax || quietTime >= opts.DebounceAfter {
+		if eventDelay >= opts.debounceMax || quietTime >= window {
 			if req != nil {
 				pushCounter++
 				if req.ConfigsUpdated == nil {
@@ -371,7 +405,7 @@ func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, opts DebounceO
 				debouncedEvents = 0
 			}
 		} else {
-			timeChan = time.After(opts.DebounceAfter - quietTime)
+			timeChan = time.After(window - quietTime)
 		}
 	}
 
@@ -395,13 +429,15 @@ func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, opts DebounceO
 			}
 
 			lastConfigUpdateTime = time.Now()
+			if opts.adaptive != nil {
+				opts.adaptive.RecordEvent(r.Full, lastConfigUpdateTime)
+			}
+			req = req.Merge(r)
 			if debouncedEvents == 0 {
-				timeChan = time.After(opts.DebounceAfter)
+				timeChan = time.After(opts.quietWindow(req))
 				startDebounce = lastConfigUpdateTime
 			}
 			debouncedEvents++
-
-			req = req.Merge(r)
 		case <-timeChan:
 			if free {
 				pushWorker()
@@ -230,6 +230,8 @@ func (eds *EdsGenerator) buildEndpoints(proxy *model.Proxy,
 	return resources, model.XdsLogDetails{
 		Incremental:    len(edsUpdatedServices) != 0,
 		AdditionalInfo: fmt.Sprintf("empty:%v cached:%v/%v", empty, cached, cached+regenerated),
+		CacheHits:      cached,
+		CacheMisses:    regenerated,
 	}
 }
 
@@ -289,5 +291,7 @@ func (eds *EdsGenerator) buildDeltaEndpoints(proxy *model.Proxy,
 	return resources, removed, model.XdsLogDetails{
 		Incremental:    len(edsUpdatedServices) != 0,
 		AdditionalInfo: fmt.Sprintf("empty:%v cached:%v/%v", empty, cached, cached+regenerated),
+		CacheHits:      cached,
+		CacheMisses:    regenerated,
 	}
 }
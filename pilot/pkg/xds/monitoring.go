@@ -60,6 +60,11 @@ var (
 		[]float64{.01, .1, 1, 3, 5, 10, 20, 30},
 	)
 
+	debounceWindow = monitoring.NewGauge(
+		"pilot_debounce_window_seconds",
+		"Quiet window in seconds chosen by the adaptive debounce, labeled by push type (full or eds).",
+	)
+
 	pushContextInitTime = monitoring.NewDistribution(
 		"pilot_pushcontext_init_seconds",
 		"Total time in seconds Pilot takes to init pushContext.",
@@ -78,6 +83,19 @@ var (
 		[]float64{.1, .5, 1, 3, 5, 10, 20, 30},
 	)
 
+	pushClassTag = monitoring.CreateLabel("class")
+
+	pushQueueDepth = monitoring.NewGauge(
+		"pilot_push_queue_depth",
+		"Number of proxies in the push queue, labeled by priority class.",
+	)
+
+	pushQueueWaitTime = monitoring.NewDistribution(
+		"pilot_push_queue_wait_time",
+		"Time in seconds a proxy waits in the push queue before being dequeued, labeled by priority class.",
+		[]float64{.01, .1, .5, 1, 3, 5, 10, 20, 30},
+	)
+
 	pushTriggers = monitoring.NewSum(
 		"pilot_push_triggers",
 		"Total number of times a push was triggered, labeled by reason for the push.",
@@ -16,32 +16,144 @@ package xds
 
 import (
 	"sync"
+	"time"
 
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 )
 
+// PushClass is the priority class of a connection in the push queue.
+type PushClass int
+
+const (
+	// PushClassGateway is used for ingress and egress gateways.
+	PushClassGateway PushClass = iota
+	// PushClassWaypoint is used for waypoint proxies.
+	PushClassWaypoint
+	// PushClassNewConnection is used for proxies which connected recently.
+	PushClassNewConnection
+	// PushClassProxyUpdate is used for proxies with a pending push for a change to their own workload.
+	PushClassProxyUpdate
+	// PushClassSidecar is used for all other proxies.
+	PushClassSidecar
+
+	pushClassCount = int(PushClassSidecar) + 1
+)
+
+func (c PushClass) String() string {
+	switch c {
+	case PushClassGateway:
+		return "gateway"
+	case PushClassWaypoint:
+		return "waypoint"
+	case PushClassNewConnection:
+		return "new_connection"
+	case PushClassProxyUpdate:
+		return "proxy_update"
+	default:
+		return "sidecar"
+	}
+}
+
+// pushClassWeights is the number of connections of each class dequeued in a round, when all classes are pending.
+// Classes are served in order within a round, so higher classes go first, but every class gets its share of each
+// round and sidecars are never starved.
+var pushClassWeights = [pushClassCount]int{
+	PushClassGateway:       8,
+	PushClassWaypoint:      8,
+	PushClassNewConnection: 4,
+	PushClassProxyUpdate:   4,
+	PushClassSidecar:       1,
+}
+
+// newConnectionPeriod is the time after connecting during which a proxy is considered newly connected.
+var newConnectionPeriod = 30 * time.Second
+
+// pushClassOf returns the priority class of a push of request to con.
+func pushClassOf(con *Connection, request *model.PushRequest) PushClass {
+	proxy := con.proxy
+	if proxy == nil {
+		return PushClassSidecar
+	}
+	switch {
+	case proxy.Type == model.Router:
+		return PushClassGateway
+	case proxy.IsWaypointProxy():
+		return PushClassWaypoint
+	case time.Since(con.ConnectedAt()) < newConnectionPeriod:
+		return PushClassNewConnection
+	case request != nil && request.IsProxyUpdate():
+		return PushClassProxyUpdate
+	}
+	return PushClassSidecar
+}
+
+type pendingPush struct {
+	request *model.PushRequest
+	class   PushClass
+	// enqueued is the time the connection was added to the queue.
+	enqueued time.Time
+}
+
+// maxTrackedPushes bounds the number of pushes tracked at once. Further pushes are not tracked until one completes.
+const maxTrackedPushes = 16
+
+// pushTracker tracks the connections a push was enqueued for, until all of them were sent it.
+type pushTracker struct {
+	// remaining is the number of connections which were not sent the push yet.
+	remaining int
+	// enqueuing is set until the push is enqueued for all connections.
+	enqueuing bool
+	done      func()
+}
+
 type PushQueue struct {
 	cond *sync.Cond
 
 	// pending stores all connections in the queue. If the same connection is enqueued again,
 	// the PushRequest will be merged.
-	pending map[*Connection]*model.PushRequest
+	pending map[*Connection]*pendingPush
+
+	// queues maintain ordering of the queue, for each class. When a pending connection moves to a higher class,
+	// it is added to the queue of that class and left in the lower one; entries that do not match the class of the
+	// pending connection are skipped on Dequeue.
+	queues [pushClassCount][]*Connection
+
+	// depth is the number of pending connections of each class.
+	depth [pushClassCount]int
 
-	// queue maintains ordering of the queue
-	queue []*Connection
+	// credits is the number of connections each class can still dequeue in the current round.
+	credits [pushClassCount]int
+
+	// prioritize enables priority classes. If disabled, all connections are in PushClassSidecar.
+	prioritize bool
 
 	// processing stores all connections that have been Dequeue(), but not MarkDone().
 	// The value stored will be initially be nil, but may be populated if the connection is Enqueue().
 	// If model.PushRequest is not nil, it will be Enqueued again once MarkDone has been called.
 	processing map[*Connection]*model.PushRequest
 
+	// tracked are the pushes being enqueued which are tracked, by request.
+	tracked map[*model.PushRequest]*pushTracker
+	// trackers is the number of tracked pushes which did not complete yet.
+	trackers int
+	// waiting holds the trackers of the pushes to send to a connection next, either pending or merged while processing.
+	waiting map[*Connection][]*pushTracker
+	// sending holds the trackers of the push being sent to a processing connection.
+	sending map[*Connection][]*pushTracker
+
 	shuttingDown bool
 }
 
 func NewPushQueue() *PushQueue {
 	return &PushQueue{
-		pending:    make(map[*Connection]*model.PushRequest),
+		pending:    make(map[*Connection]*pendingPush),
 		processing: make(map[*Connection]*model.PushRequest),
+		tracked:    make(map[*model.PushRequest]*pushTracker),
+		waiting:    make(map[*Connection][]*pushTracker),
+		sending:    make(map[*Connection][]*pushTracker),
+		credits:    pushClassWeights,
+		prioritize: features.EnablePushQueuePriority,
 		cond:       sync.NewCond(&sync.Mutex{}),
 	}
 }
@@ -56,73 +168,185 @@ func (p *PushQueue) Enqueue(con *Connection, pushRequest *model.PushRequest) {
 		return
 	}
 
+	if t, f := p.tracked[pushRequest]; f {
+		t.remaining++
+		p.waiting[con] = append(p.waiting[con], t)
+	}
+
 	// If its already in progress, merge the info and return
 	if request, f := p.processing[con]; f {
 		p.processing[con] = request.CopyMerge(pushRequest)
 		return
 	}
 
-	if request, f := p.pending[con]; f {
-		p.pending[con] = request.CopyMerge(pushRequest)
+	if pending, f := p.pending[con]; f {
+		pending.request = pending.request.CopyMerge(pushRequest)
+		// The merged request may require a higher priority, for instance if it now includes a proxy update
+		if class := p.classOf(con, pending.request); class < pending.class {
+			p.recordDepth(pending.class, -1)
+			pending.class = class
+			p.push(con, class)
+		}
 		return
 	}
 
-	p.pending[con] = pushRequest
-	p.queue = append(p.queue, con)
+	p.add(con, pushRequest)
+}
+
+// add adds con to the queue. The lock must be held.
+func (p *PushQueue) add(con *Connection, request *model.PushRequest) {
+	class := p.classOf(con, request)
+	p.pending[con] = &pendingPush{request: request, class: class, enqueued: time.Now()}
+	p.push(con, class)
 	// Signal waiters on Dequeue that a new item is available
 	p.cond.Signal()
 }
 
+func (p *PushQueue) push(con *Connection, class PushClass) {
+	p.queues[class] = append(p.queues[class], con)
+	p.recordDepth(class, 1)
+}
+
+// recordDepth updates the number of pending connections of class by delta. The lock must be held.
+func (p *PushQueue) recordDepth(class PushClass, delta int) {
+	p.depth[class] += delta
+	pushQueueDepth.With(pushClassTag.Value(class.String())).Record(float64(p.depth[class]))
+}
+
+func (p *PushQueue) classOf(con *Connection, request *model.PushRequest) PushClass {
+	if !p.prioritize {
+		return PushClassSidecar
+	}
+	return pushClassOf(con, request)
+}
+
 // Remove a proxy from the queue. If there are no proxies ready to be removed, this will block
 func (p *PushQueue) Dequeue() (con *Connection, request *model.PushRequest, shutdown bool) {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
 
 	// Block until there is one to remove. Enqueue will signal when one is added.
-	for len(p.queue) == 0 && !p.shuttingDown {
+	for len(p.pending) == 0 && !p.shuttingDown {
 		p.cond.Wait()
 	}
 
-	if len(p.queue) == 0 {
+	if len(p.pending) == 0 {
 		// We must be shutting down.
 		return nil, nil, true
 	}
 
-	con = p.queue[0]
-	// The underlying array will still exist, despite the slice changing, so the object may not GC without this
-	// See https://github.com/grpc/grpc-go/issues/4758
-	p.queue[0] = nil
-	p.queue = p.queue[1:]
-
-	request = p.pending[con]
+	con, pending := p.next()
+	request = pending.request
 	delete(p.pending, con)
+	p.recordDepth(pending.class, -1)
+	pushQueueWaitTime.With(pushClassTag.Value(pending.class.String())).Record(time.Since(pending.enqueued).Seconds())
 
 	// Mark the connection as in progress
 	p.processing[con] = nil
+	if trackers, f := p.waiting[con]; f {
+		p.sending[con] = trackers
+		delete(p.waiting, con)
+	}
 
 	return con, request, false
 }
 
+// next removes the next pending connection from the queues. There must be at least one pending connection.
+func (p *PushQueue) next() (*Connection, *pendingPush) {
+	for {
+		class := p.nextClass()
+		queue := p.queues[class]
+		con := queue[0]
+		// The underlying array will still exist, despite the slice changing, so the object may not GC without this
+		// See https://github.com/grpc/grpc-go/issues/4758
+		queue[0] = nil
+		p.queues[class] = queue[1:]
+		if pending, f := p.pending[con]; f && pending.class == class {
+			p.credits[class]--
+			return con, pending
+		}
+		// Stale entry, the connection was moved to a higher class
+	}
+}
+
+// nextClass returns the class to dequeue from, in a weighted round-robin over the non-empty classes.
+func (p *PushQueue) nextClass() PushClass {
+	for {
+		for class := range pushClassCount {
+			if len(p.queues[class]) > 0 && p.credits[class] > 0 {
+				return PushClass(class)
+			}
+		}
+		// All non-empty classes used their share, start a new round
+		p.credits = pushClassWeights
+	}
+}
+
 func (p *PushQueue) MarkDone(con *Connection) {
 	p.cond.L.Lock()
-	defer p.cond.L.Unlock()
 	request := p.processing[con]
 	delete(p.processing, con)
 
 	// If the info is present, that means Enqueue was called while connection was not yet marked done.
 	// This means we need to add it back to the queue.
 	if request != nil {
-		p.pending[con] = request
-		p.queue = append(p.queue, con)
-		p.cond.Signal()
+		p.add(con, request)
+	}
+
+	var done []func()
+	for _, t := range p.sending[con] {
+		t.remaining--
+		if f := p.complete(t); f != nil {
+			done = append(done, f)
+		}
+	}
+	delete(p.sending, con)
+	p.cond.L.Unlock()
+	for _, f := range done {
+		f()
+	}
+}
+
+// TrackPush calls done once request, as enqueued for connections until the returned function is called, was sent to
+// all of them. Merged into other requests, it is sent along with them. To bound the bookkeeping, at most
+// maxTrackedPushes pushes are tracked at once; beyond that, done is never called.
+func (p *PushQueue) TrackPush(request *model.PushRequest, done func()) (enqueued func()) {
+	p.cond.L.Lock()
+	defer p.cond.L.Unlock()
+	if p.trackers >= maxTrackedPushes {
+		return func() {}
+	}
+	p.trackers++
+	t := &pushTracker{enqueuing: true, done: done}
+	p.tracked[request] = t
+	return func() {
+		p.cond.L.Lock()
+		delete(p.tracked, request)
+		t.enqueuing = false
+		f := p.complete(t)
+		p.cond.L.Unlock()
+		if f != nil {
+			f()
+		}
+	}
+}
+
+// complete returns the function to call once t completes, if it just did. The lock must be held.
+func (p *PushQueue) complete(t *pushTracker) func() {
+	if t.enqueuing || t.remaining > 0 || t.done == nil {
+		return nil
 	}
+	p.trackers--
+	f := t.done
+	t.done = nil
+	return f
 }
 
 // Get number of pending proxies
 func (p *PushQueue) Pending() int {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
-	return len(p.queue)
+	return len(p.pending)
 }
 
 // ShutDown will cause queue to ignore all new items added to it. As soon as the
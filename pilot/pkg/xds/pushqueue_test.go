@@ -25,6 +25,8 @@ import (
 
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/monitoring/monitortest"
+	"istio.io/istio/pkg/test/util/assert"
 	"istio.io/istio/pkg/util/sets"
 )
 
@@ -131,6 +133,62 @@ func TestProxyQueue(t *testing.T) {
 		ExpectTimeout(t, p)
 	})
 
+	t.Run("track push", func(t *testing.T) {
+		t.Parallel()
+		p := NewPushQueue()
+		defer p.ShutDown()
+
+		first, second := 0, 0
+		req := &model.PushRequest{}
+		enqueued := p.TrackPush(req, func() { first++ })
+		p.Enqueue(proxies[0], req)
+		p.Enqueue(proxies[1], req)
+		enqueued()
+
+		// A later push does not delay the first one
+		next := &model.PushRequest{}
+		enqueued = p.TrackPush(next, func() { second++ })
+		ExpectDequeue(t, p, proxies[0])
+		p.Enqueue(proxies[0], next)
+		p.Enqueue(proxies[1], next)
+		enqueued()
+		p.MarkDone(proxies[0])
+		assert.Equal(t, first, 0)
+		ExpectDequeue(t, p, proxies[1])
+		p.MarkDone(proxies[1])
+		assert.Equal(t, first, 1)
+		assert.Equal(t, second, 0)
+
+		// proxies[0] was processing when next was enqueued, so it is sent again
+		ExpectDequeue(t, p, proxies[0])
+		p.MarkDone(proxies[0])
+		assert.Equal(t, second, 1)
+
+		// A push to no connections completes once enqueued
+		empty := 0
+		p.TrackPush(&model.PushRequest{}, func() { empty++ })()
+		assert.Equal(t, empty, 1)
+	})
+
+	t.Run("track push limit", func(t *testing.T) {
+		t.Parallel()
+		p := NewPushQueue()
+		defer p.ShutDown()
+
+		done := 0
+		for range maxTrackedPushes + 1 {
+			req := &model.PushRequest{}
+			enqueued := p.TrackPush(req, func() { done++ })
+			p.Enqueue(proxies[0], req)
+			enqueued()
+		}
+		ExpectDequeue(t, p, proxies[0])
+		p.MarkDone(proxies[0])
+		// Only maxTrackedPushes were tracked, the last one was not
+		assert.Equal(t, done, maxTrackedPushes)
+		assert.Equal(t, p.trackers, 0)
+	})
+
 	t.Run("add and remove and add and markdone", func(t *testing.T) {
 		t.Parallel()
 		p := NewPushQueue()
@@ -373,3 +431,87 @@ func TestProxyQueue(t *testing.T) {
 		}
 	})
 }
+
+func TestPushQueuePriority(t *testing.T) {
+	newConnectionPeriod = 0
+	t.Cleanup(func() {
+		newConnectionPeriod = 30 * time.Second
+	})
+	newProxy := func(id string, proxy *model.Proxy) *Connection {
+		conn := newConnection("", nil)
+		conn.SetID(id)
+		conn.proxy = proxy
+		return conn
+	}
+	sidecars := make([]*Connection, 0, 20)
+	for i := 0; i < 20; i++ {
+		sidecars = append(sidecars, newProxy(fmt.Sprintf("sidecar-%d", i), &model.Proxy{Type: model.SidecarProxy}))
+	}
+	dequeue := func(p *PushQueue, n int) []string {
+		var res []string
+		for i := 0; i < n; i++ {
+			con, _, _ := p.Dequeue()
+			res = append(res, con.ID())
+			p.MarkDone(con)
+		}
+		return res
+	}
+
+	t.Run("classes", func(t *testing.T) {
+		assert.Equal(t, pushClassOf(newProxy("gw", &model.Proxy{Type: model.Router}), &model.PushRequest{}), PushClassGateway)
+		assert.Equal(t, pushClassOf(newProxy("waypoint", &model.Proxy{Type: model.Waypoint}), &model.PushRequest{}), PushClassWaypoint)
+		assert.Equal(t, pushClassOf(sidecars[0], &model.PushRequest{Reason: model.NewReasonStats(model.ProxyUpdate)}), PushClassProxyUpdate)
+		assert.Equal(t, pushClassOf(sidecars[0], &model.PushRequest{Reason: model.NewReasonStats(model.ConfigUpdate)}), PushClassSidecar)
+		newConnectionPeriod = time.Hour
+		assert.Equal(t, pushClassOf(sidecars[0], &model.PushRequest{}), PushClassNewConnection)
+		newConnectionPeriod = 0
+	})
+
+	t.Run("higher classes first", func(t *testing.T) {
+		mt := monitortest.New(t)
+		p := NewPushQueue()
+		p.prioritize = true
+		defer p.ShutDown()
+		for _, s := range sidecars[:3] {
+			p.Enqueue(s, &model.PushRequest{})
+		}
+		p.Enqueue(newProxy("gateway", &model.Proxy{Type: model.Router}), &model.PushRequest{})
+		// A pending sidecar is promoted when a proxy update is merged
+		p.Enqueue(sidecars[2], &model.PushRequest{Reason: model.NewReasonStats(model.ProxyUpdate)})
+		mt.Assert("pilot_push_queue_depth", map[string]string{"class": "sidecar"}, monitortest.Exactly(2))
+		mt.Assert("pilot_push_queue_depth", map[string]string{"class": "proxy_update"}, monitortest.Exactly(1))
+		assert.Equal(t, p.Pending(), 4)
+
+		assert.Equal(t, dequeue(p, 4), []string{"gateway", "sidecar-2", "sidecar-0", "sidecar-1"})
+		ExpectTimeout(t, p)
+		mt.Assert("pilot_push_queue_depth", map[string]string{"class": "sidecar"}, monitortest.Exactly(0))
+		mt.Assert("pilot_push_queue_wait_time", map[string]string{"class": "gateway"}, monitortest.Buckets(9))
+		mt.Assert("pilot_push_queue_wait_time", map[string]string{"class": "sidecar"}, monitortest.Buckets(9))
+	})
+
+	t.Run("sidecars are not starved", func(t *testing.T) {
+		p := NewPushQueue()
+		p.prioritize = true
+		defer p.ShutDown()
+		gateways := make([]*Connection, 0, 20)
+		for i := 0; i < 20; i++ {
+			gateways = append(gateways, newProxy(fmt.Sprintf("gateway-%d", i), &model.Proxy{Type: model.Router}))
+		}
+		p.Enqueue(sidecars[0], &model.PushRequest{})
+		for _, gw := range gateways {
+			p.Enqueue(gw, &model.PushRequest{})
+		}
+		// The sidecar is pushed once the gateways used their share of the round
+		got := dequeue(p, pushClassWeights[PushClassGateway]+1)
+		assert.Equal(t, got[len(got)-1], "sidecar-0")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		p := NewPushQueue()
+		p.prioritize = false
+		defer p.ShutDown()
+		p.Enqueue(sidecars[0], &model.PushRequest{})
+		p.Enqueue(newProxy("gateway", &model.Proxy{Type: model.Router}), &model.PushRequest{})
+		assert.Equal(t, dequeue(p, 2), []string{"sidecar-0", "gateway"})
+	})
+}
@@ -0,0 +1,208 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/tracing"
+)
+
+// maxTracedConfigs is the maximum number of triggering configs recorded in a PushTrace.
+const maxTracedConfigs = 20
+
+// PushTrace records the work done to push to a proxy: what triggered the push, and the cost of each generator.
+type PushTrace struct {
+	Time time.Time `json:"time"`
+	// Proxy is the ID of the proxy pushed to
+	Proxy string `json:"proxy"`
+	// Connection is the ID of the connection of the proxy
+	Connection string `json:"connection"`
+	Full       bool   `json:"full"`
+	// Reasons are the reasons of the merged push requests, with their count
+	Reasons model.ReasonStats `json:"reasons,omitempty"`
+	// Configs are the configs whose changes triggered the push, up to maxTracedConfigs
+	Configs []string `json:"configs,omitempty"`
+	// OmittedConfigs is the number of triggering configs not included in Configs
+	OmittedConfigs int `json:"omittedConfigs,omitempty"`
+	// Generators are the generators invoked for the push, in order
+	Generators []GeneratorTrace `json:"generators,omitempty"`
+	// Duration is the total time taken to push
+	Duration time.Duration `json:"duration"`
+}
+
+// GeneratorTrace records the cost of a generator during a push.
+type GeneratorTrace struct {
+	Time time.Time `json:"time"`
+	// Type is the short type of the generated resources, such as CDS
+	Type string `json:"type"`
+	// Skipped is set if the generator had nothing to push
+	Skipped bool `json:"skipped,omitempty"`
+	// Resources is the number of resources sent
+	Resources int `json:"resources"`
+	// Removed is the number of resources removed, for delta XDS
+	Removed int `json:"removed,omitempty"`
+	// CacheHits and CacheMisses are the number of resources served from the XDS cache, and generated
+	CacheHits   int `json:"cacheHits,omitempty"`
+	CacheMisses int `json:"cacheMisses,omitempty"`
+	// Bytes is the approximate size of the resources sent
+	Bytes    int           `json:"bytes"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+func newPushTrace(con *Connection, req *model.PushRequest) *PushTrace {
+	t := &PushTrace{
+		Time:       time.Now(),
+		Proxy:      con.proxy.ID,
+		Connection: con.ID(),
+		Full:       req.Full,
+		Reasons:    req.Reason,
+	}
+	for key := range req.ConfigsUpdated {
+		if len(t.Configs) == maxTracedConfigs {
+			t.OmittedConfigs++
+			continue
+		}
+		t.Configs = append(t.Configs, key.String())
+	}
+	slices.Sort(t.Configs)
+	return t
+}
+
+// recordSkip records a generator which had nothing to push. It is a no-op if t is nil.
+func (t *PushTrace) recordSkip(typeURL string, start time.Time, err error) {
+	if t == nil {
+		return
+	}
+	g := GeneratorTrace{Time: start, Type: v3.GetShortType(typeURL), Skipped: true, Duration: time.Since(start)}
+	if err != nil {
+		g.Error = err.Error()
+	}
+	t.Generators = append(t.Generators, g)
+}
+
+// record records a generator which pushed res. It is a no-op if t is nil.
+func (t *PushTrace) record(typeURL string, start time.Time, res model.Resources, removed int, logdata model.XdsLogDetails, err error) {
+	if t == nil {
+		return
+	}
+	g := GeneratorTrace{
+		Time:        start,
+		Type:        v3.GetShortType(typeURL),
+		Resources:   len(res),
+		Removed:     removed,
+		CacheHits:   logdata.CacheHits,
+		CacheMisses: logdata.CacheMisses,
+		Bytes:       ResourceSize(res),
+		Duration:    time.Since(start),
+	}
+	if err != nil {
+		g.Error = err.Error()
+	}
+	t.Generators = append(t.Generators, g)
+}
+
+// pushTracer is a ring buffer holding the latest PushTraces, which are also exported as OpenTelemetry spans.
+type pushTracer struct {
+	mu     sync.Mutex
+	traces []*PushTrace
+	next   int
+	full   bool
+}
+
+func newPushTracer(size int) *pushTracer {
+	return &pushTracer{traces: make([]*PushTrace, size)}
+}
+
+// start returns a trace for a push to con, or nil if tracing is disabled.
+func (p *pushTracer) start(con *Connection, req *model.PushRequest) *PushTrace {
+	if p == nil {
+		return nil
+	}
+	return newPushTrace(con, req)
+}
+
+// finish records t, once the push is complete. It is a no-op if t is nil.
+func (p *pushTracer) finish(t *PushTrace) {
+	if t == nil {
+		return
+	}
+	t.Duration = time.Since(t.Time)
+	exportPushTrace(t)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.traces[p.next] = t
+	p.next = (p.next + 1) % len(p.traces)
+	if p.next == 0 {
+		p.full = true
+	}
+}
+
+// list returns the recorded traces, oldest first.
+func (p *pushTracer) list() []*PushTrace {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.full {
+		return append([]*PushTrace{}, p.traces[:p.next]...)
+	}
+	return append(append([]*PushTrace{}, p.traces[p.next:]...), p.traces[:p.next]...)
+}
+
+// exportPushTrace exports t as a span, with a child span for each generator.
+// This is a no-op unless a tracing provider is initialized.
+func exportPushTrace(t *PushTrace) {
+	ctx, span := tracing.StartAt(context.Background(), "xds.push", t.Time)
+	if !span.IsRecording() {
+		return
+	}
+	reasons := make([]string, 0, len(t.Reasons))
+	for r := range t.Reasons {
+		reasons = append(reasons, string(r))
+	}
+	slices.Sort(reasons)
+	span.SetAttributes(
+		tracing.String("proxy", t.Proxy),
+		tracing.Bool("full", t.Full),
+		tracing.StringSlice("reasons", reasons),
+		tracing.StringSlice("configs", t.Configs),
+	)
+	for _, g := range t.Generators {
+		_, gs := tracing.StartAt(ctx, "xds.generate."+g.Type, g.Time)
+		gs.SetAttributes(
+			tracing.String("type", g.Type),
+			tracing.Bool("skipped", g.Skipped),
+			tracing.Int("resources", g.Resources),
+			tracing.Int("removed", g.Removed),
+			tracing.Int("cache_hits", g.CacheHits),
+			tracing.Int("cache_misses", g.CacheMisses),
+			tracing.Int("bytes", g.Bytes),
+		)
+		if g.Error != "" {
+			gs.SetError(g.Error)
+		}
+		gs.EndAt(g.Time.Add(g.Duration))
+	}
+	span.EndAt(t.Time.Add(t.Duration))
+}
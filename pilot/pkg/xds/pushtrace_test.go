@@ -0,0 +1,98 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/xds"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	xdsfake "istio.io/istio/pilot/test/xds"
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/util/sets"
+)
+
+func TestPushTracing(t *testing.T) {
+	s := xdsfake.NewFakeDiscoveryServer(t, xdsfake.FakeOptions{ConfigString: `apiVersion: networking.istio.io/v1
+kind: ServiceEntry
+metadata:
+  name: se
+  namespace: default
+spec:
+  hosts:
+  - example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: DNS
+`})
+	s.Discovery.EnablePushTracing(10)
+
+	ads := s.ConnectADS().WithType(v3.ClusterType)
+	ads.RequestResponseAck(t, nil)
+
+	// Requests from the proxy are not traced, only pushes
+	assert.Equal(t, len(getPushTraces(t, s, "")), 0)
+
+	s.Discovery.ConfigUpdate(&model.PushRequest{
+		Full:           true,
+		ConfigsUpdated: sets.New(model.ConfigKey{Kind: kind.EnvoyFilter, Name: "ef", Namespace: "default"}),
+		Reason:         model.NewReasonStats(model.ConfigUpdate),
+	})
+	ads.ExpectResponse(t)
+
+	var trace xds.PushTrace
+	retry.UntilSuccessOrFail(t, func() error {
+		traces := getPushTraces(t, s, "test.default")
+		if len(traces) != 1 {
+			return fmt.Errorf("expected 1 trace, got %v", len(traces))
+		}
+		trace = traces[0]
+		return nil
+	})
+	assert.Equal(t, trace.Full, true)
+	assert.Equal(t, trace.Configs, []string{"EnvoyFilter/default/ef"})
+	assert.Equal(t, trace.Reasons, model.NewReasonStats(model.ConfigUpdate))
+	assert.Equal(t, len(trace.Generators), 1)
+	cds := trace.Generators[0]
+	assert.Equal(t, cds.Type, "CDS")
+	assert.Equal(t, cds.Skipped, false)
+	assert.Equal(t, cds.Resources > 0, true)
+	assert.Equal(t, cds.Bytes > 0, true)
+	// The EnvoyFilter change invalidated the cached cluster of the ServiceEntry
+	assert.Equal(t, cds.CacheHits, 0)
+	assert.Equal(t, cds.CacheMisses, 1)
+
+	assert.Equal(t, len(getPushTraces(t, s, "other")), 0)
+}
+
+func getPushTraces(t *testing.T, s *xdsfake.FakeDiscoveryServer, proxyID string) []xds.PushTrace {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/debug/push_tracez?proxyID="+proxyID, nil)
+	rr := httptest.NewRecorder()
+	s.DiscoveryDebug.ServeHTTP(rr, req)
+	assert.Equal(t, rr.Code, http.StatusOK)
+	traces := []xds.PushTrace{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &traces))
+	return traces
+}
@@ -166,6 +166,8 @@ func (s *SecretGen) Generate(proxy *model.Proxy, w *model.WatchedResource, req *
 	return results, model.XdsLogDetails{
 		Incremental:    updatedSecrets != nil,
 		AdditionalInfo: fmt.Sprintf("cached:%v/%v", cached, cached+regenerated),
+		CacheHits:      cached,
+		CacheMisses:    regenerated,
 	}, nil
 }
 
@@ -0,0 +1,69 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"strconv"
+	"strings"
+
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+)
+
+// uuidLength is the length of the random suffix of nonces.
+const uuidLength = 36
+
+// PushCount returns the number of pushes computed so far. Pushes counted after the returned value are computed
+// after the call, from the state at that time.
+func (s *DiscoveryServer) PushCount() uint64 {
+	return s.pushVersion.Load()
+}
+
+// TrustBundleSyncStatus returns the connected proxies which acknowledged the trust bundle of a push counted after
+// pushCount, as returned by PushCount, the proxies which did not yet, and the proxies which do not get the
+// trust bundle over xDS, such as proxies without an agent.
+// The trust bundle is sent with the proxy config (PCDS), on all full pushes.
+func (s *DiscoveryServer) TrustBundleSyncStatus(pushCount uint64) (synced, pending, untracked []string) {
+	for _, con := range s.Clients() {
+		proxy := con.proxy
+		if proxy.GetWatchedResource(v3.ProxyConfigType) == nil {
+			untracked = append(untracked, proxy.ID)
+			continue
+		}
+		if count, ok := pushCountFromNonce(proxy.NonceAcked(v3.ProxyConfigType)); ok && count > pushCount {
+			synced = append(synced, proxy.ID)
+		} else {
+			pending = append(pending, proxy.ID)
+		}
+	}
+	return synced, pending, untracked
+}
+
+// pushCountFromNonce returns the count of the push a nonce was sent for. Nonces are the push version, which ends
+// with the push count, followed by a UUID.
+func pushCountFromNonce(nonce string) (uint64, bool) {
+	if len(nonce) <= uuidLength {
+		return 0, false
+	}
+	version := nonce[:len(nonce)-uuidLength]
+	i := strings.LastIndex(version, "/")
+	if i < 0 {
+		return 0, false
+	}
+	count, err := strconv.ParseUint(version[i+1:], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return count, true
+}
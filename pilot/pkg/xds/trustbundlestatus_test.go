@@ -0,0 +1,59 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds_test
+
+import (
+	"testing"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/trustbundle"
+	"istio.io/istio/pilot/pkg/xds"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	xdsfake "istio.io/istio/pilot/test/xds"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+func TestTrustBundleSyncStatus(t *testing.T) {
+	test.SetForTest(t, &features.MultiRootMesh, true)
+	s := xdsfake.NewFakeDiscoveryServer(t, xdsfake.FakeOptions{})
+	s.Discovery.Generators[v3.ProxyConfigType] = &xds.PcdsGenerator{TrustBundle: trustbundle.NewTrustBundle(nil, s.Env().Watcher)}
+
+	pcds := s.ConnectADS().WithType(v3.ProxyConfigType).WithID("sidecar~1.1.1.1~app.default~default.svc.cluster.local")
+	pcds.RequestResponseAck(t, nil)
+	s.ConnectADS().WithType(v3.ClusterType).WithID("router~1.1.1.2~gateway.default~default.svc.cluster.local").RequestResponseAck(t, nil)
+
+	pushCount := s.Discovery.PushCount()
+	retry.UntilOrFail(t, func() bool {
+		_, pending, untracked := s.Discovery.TrustBundleSyncStatus(pushCount)
+		return len(pending) == 1 && len(untracked) == 1
+	})
+	_, pending, untracked := s.Discovery.TrustBundleSyncStatus(pushCount)
+	assert.Equal(t, pending, []string{"app.default"})
+	assert.Equal(t, untracked, []string{"gateway.default"})
+
+	// The proxy config of the next full push is acknowledged
+	s.Discovery.ConfigUpdate(&model.PushRequest{Full: true, Reason: model.NewReasonStats(model.GlobalUpdate)})
+	resp := pcds.ExpectResponse(t)
+	pcds.Request(t, &discovery.DiscoveryRequest{ResponseNonce: resp.Nonce, VersionInfo: resp.VersionInfo})
+	retry.UntilOrFail(t, func() bool {
+		synced, _, _ := s.Discovery.TrustBundleSyncStatus(pushCount)
+		return len(synced) == 1
+	})
+}
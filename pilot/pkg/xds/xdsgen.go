@@ -98,7 +98,8 @@ func (s *DiscoveryServer) findGenerator(typeURL string, con *Connection) model.X
 // Push an XDS resource for the given connection. Configuration will be generated
 // based on the passed in generator. Based on the updates field, generators may
 // choose to send partial or even no response if there are no changes.
-func (s *DiscoveryServer) pushXds(con *Connection, w *model.WatchedResource, req *model.PushRequest) error {
+// If trace is set, the cost of the generator is recorded in it.
+func (s *DiscoveryServer) pushXds(con *Connection, w *model.WatchedResource, req *model.PushRequest, trace *PushTrace) error {
 	if w == nil {
 		return nil
 	}
@@ -131,6 +132,7 @@ func (s *DiscoveryServer) pushXds(con *Connection, w *model.WatchedResource, req
 		info += logFiltered
 	}
 	if err != nil || res == nil {
+		trace.recordSkip(w.TypeUrl, t0, err)
 		if log.DebugEnabled() {
 			log.Debugf("%s: SKIP%s for node:%s%s", v3.GetShortType(w.TypeUrl), req.PushReason(), con.proxy.ID, info)
 		}
@@ -156,7 +158,9 @@ func (s *DiscoveryServer) pushXds(con *Connection, w *model.WatchedResource, req
 		ptype = "PUSH INC"
 	}
 
-	if err := xds.Send(con, resp); err != nil {
+	err = xds.Send(con, resp)
+	trace.record(w.TypeUrl, t0, res, 0, logdata, err)
+	if err != nil {
 		if recordSendError(w.TypeUrl, err) {
 			log.Warnf("%s: Send failure for node:%s resources:%d size:%s%s: %v",
 				v3.GetShortType(w.TypeUrl), con.proxy.ID, len(res), util.ByteCount(configSize), info, err)
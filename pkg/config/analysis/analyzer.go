@@ -16,6 +16,7 @@ package analysis
 
 import (
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis/diag"
 	"istio.io/istio/pkg/config/analysis/scope"
 	"istio.io/istio/pkg/config/schema/collection"
 	"istio.io/istio/pkg/util/sets"
@@ -27,6 +28,19 @@ type Analyzer interface {
 	Analyze(c Context)
 }
 
+// Remediator is implemented by analyzers which can fix some of the issues they report.
+type Remediator interface {
+	// Remediate returns the JSON patch fixing the issue reported by m on its resource, or nil if there is no fix for it.
+	Remediate(m diag.Message) []PatchOperation
+}
+
+// PatchOperation is a JSON patch (RFC 6902) operation. Paths are relative to the Kubernetes object of the resource.
+type PatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value,omitempty"`
+}
+
 // CombinedAnalyzer is an interface used to combine and run multiple analyzers into one
 type CombinedAnalyzer interface {
 	Analyzer
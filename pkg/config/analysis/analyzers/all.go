@@ -44,6 +44,7 @@ func All() []analysis.Analyzer {
 		// Please keep this list sorted alphabetically by pkg.name for convenience
 		&annotations.K8sAnalyzer{},
 		&authz.AuthorizationPoliciesAnalyzer{},
+		&authz.AuthorizationPolicyShadowingAnalyzer{},
 		&conditions.ConditionAnalyzer{},
 		&deployment.ServiceAssociationAnalyzer{},
 		&deployment.ApplicationUIDAnalyzer{},
@@ -65,6 +66,7 @@ func All() []analysis.Analyzer {
 		&virtualservice.DestinationRuleAnalyzer{},
 		&virtualservice.GatewayAnalyzer{},
 		&virtualservice.JWTClaimRouteAnalyzer{},
+		&virtualservice.UnreachableRouteAnalyzer{},
 		&destinationrule.CaCertificateAnalyzer{},
 		&serviceentry.ProtocolAddressesAnalyzer{},
 		&webhook.Analyzer{},
@@ -99,16 +101,21 @@ func AllMultiClusterCombined() analysis.CombinedAnalyzer {
 }
 
 func NamedCombined(names ...string) analysis.CombinedAnalyzer {
-	selected := make([]analysis.Analyzer, 0, len(All()))
+	return NamedCombinedOf(All(), names...)
+}
+
+// NamedCombinedOf returns the analyzers of all with the given names combined as one, or all of them if none match.
+func NamedCombinedOf(all []analysis.Analyzer, names ...string) analysis.CombinedAnalyzer {
+	selected := make([]analysis.Analyzer, 0, len(all))
 	nameSet := sets.New(names...)
-	for _, a := range All() {
+	for _, a := range all {
 		if nameSet.Contains(a.Metadata().Name) {
 			selected = append(selected, a)
 		}
 	}
 
 	if len(selected) == 0 {
-		return AllCombined()
+		return analysis.Combine("all", all...)
 	}
 
 	return analysis.Combine("named", selected...)
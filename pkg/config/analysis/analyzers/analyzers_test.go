@@ -488,6 +488,16 @@ var testGrid = []testCase{
 			{msg.JwtClaimBasedRoutingWithoutRequestAuthN, "VirtualService foo"},
 		},
 	},
+	{
+		name:       "virtualServiceUnreachableRoutes",
+		inputFiles: []string{"testdata/virtualservice_unreachable_routes.yaml"},
+		analyzer:   &virtualservice.UnreachableRouteAnalyzer{},
+		expected: []message{
+			{msg.VirtualServiceRouteShadowed, "VirtualService shadowed-prefix"},
+			{msg.VirtualServiceCatchAllRouteNotLast, "VirtualService catch-all-not-last"},
+			{msg.VirtualServiceRouteShadowed, "VirtualService gateway-second"},
+		},
+	},
 	{
 		name:       "virtualServiceInternalGatewayRef",
 		inputFiles: []string{"testdata/virtualservice_internal_gateway_ref.yaml"},
@@ -547,6 +557,20 @@ var testGrid = []testCase{
 			{msg.NoMatchingWorkloadsFound, "AuthorizationPolicy test-ambient/no-workload"},
 		},
 	},
+	{
+		name: "authorizationpolicies shadowing",
+		inputFiles: []string{
+			"testdata/authorizationpolicies-shadowing.yaml",
+		},
+		analyzer: &authz.AuthorizationPolicyShadowingAnalyzer{},
+		expected: []message{
+			{msg.AuthorizationPolicyRuleShadowed, "AuthorizationPolicy foo/allow-a"},
+			{msg.AuthorizationPolicyRuleRedundant, "AuthorizationPolicy foo/allow-a"},
+			{msg.AuthorizationPolicyRuleRedundant, "AuthorizationPolicy foo/allow-bar"},
+			{msg.AuthorizationPolicyDuplicateRule, "AuthorizationPolicy foo/allow-bar-copy"},
+			{msg.AuthorizationPolicyRuleRedundant, "AuthorizationPolicy foo/deny-subnet"},
+		},
+	},
 	{
 		name: "destinationrule with no cacert, simple at destinationlevel",
 		inputFiles: []string{
@@ -1129,6 +1153,51 @@ func TestAnalyzersHaveDescription(t *testing.T) {
 	}
 }
 
+func TestRemediators(t *testing.T) {
+	cases := []struct {
+		name      string
+		inputFile string
+		analyzer  analysis.Analyzer
+		expected  map[string][]analysis.PatchOperation
+	}{
+		{
+			name:      "port name",
+			inputFile: "testdata/service-no-port-name.yaml",
+			analyzer:  &service.PortNameAnalyzer{},
+			expected: map[string][]analysis.PatchOperation{
+				"Service my-namespace1/my-service1": {{Op: "add", Path: "/spec/ports/0/name", Value: "http"}},
+				"Service my-namespace2/my-service2": {{Op: "replace", Path: "/spec/ports/0/name", Value: "http-foo"}},
+			},
+		},
+		{
+			name:      "namespace injection",
+			inputFile: "testdata/injection.yaml",
+			analyzer:  &injection.Analyzer{},
+			expected: map[string][]analysis.PatchOperation{
+				"Namespace bar": {{Op: "add", Path: "/metadata/labels", Value: map[string]string{"istio-injection": "enabled"}}},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			sa, err := setupAnalyzerForCase(testCase{name: tc.name, inputFiles: []string{tc.inputFile}, analyzer: tc.analyzer}, nil)
+			g.Expect(err).To(BeNil())
+			result, err := runAnalyzer(sa)
+			g.Expect(err).To(BeNil())
+
+			patches := map[string][]analysis.PatchOperation{}
+			for _, m := range result.Messages {
+				if patch := tc.analyzer.(analysis.Remediator).Remediate(m); patch != nil {
+					name := m.Resource.Origin.FriendlyName()
+					patches[name] = append(patches[name], patch...)
+				}
+			}
+			g.Expect(patches).To(Equal(tc.expected))
+		})
+	}
+}
+
 func setupAnalyzerForCase(tc testCase, cr local.CollectionReporterFn) (*local.IstiodAnalyzer, error) {
 	sa := local.NewSourceAnalyzer(analysis.Combine("testCase", tc.analyzer), "", "istio-system", cr)
 
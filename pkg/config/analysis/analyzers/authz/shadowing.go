@@ -0,0 +1,181 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	"istio.io/api/annotation"
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/api/security/v1beta1"
+	authzmodel "istio.io/istio/pilot/pkg/security/authz/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// AuthorizationPolicyShadowingAnalyzer checks for rules of authorization policies which never match, or which are
+// redundant with other rules applied to the same workloads.
+// Policies selecting no workloads are reported by AuthorizationPoliciesAnalyzer, and are not analyzed further.
+type AuthorizationPolicyShadowingAnalyzer struct{}
+
+var _ analysis.Analyzer = &AuthorizationPolicyShadowingAnalyzer{}
+
+func (a *AuthorizationPolicyShadowingAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "auth.AuthorizationPolicyShadowingAnalyzer",
+		Description: "Checks for shadowed, redundant and duplicate rules of authorization policies",
+		Inputs: []config.GroupVersionKind{
+			gvk.MeshConfig,
+			gvk.AuthorizationPolicy,
+			gvk.Namespace,
+			gvk.Pod,
+		},
+	}
+}
+
+// policyRule is a rule of an ALLOW or DENY authorization policy, with the workloads it applies to.
+type policyRule struct {
+	r         *resource.Instance
+	action    v1beta1.AuthorizationPolicy_Action
+	index     int
+	model     *authzmodel.Model
+	workloads sets.String
+}
+
+func (p policyRule) policyName() string {
+	return p.r.Metadata.FullName.String()
+}
+
+// appliesToAll returns true if the rule applies to all the workloads o applies to.
+func (p policyRule) appliesToAll(o policyRule) bool {
+	return p.workloads.SupersetOf(o.workloads)
+}
+
+func (a *AuthorizationPolicyShadowingAnalyzer) Analyze(c analysis.Context) {
+	workloads := map[string]klabels.Set{}
+	c.ForEach(gvk.Pod, func(r *resource.Instance) bool {
+		if util.PodInMesh(r, c) || util.PodInAmbientMode(r) {
+			workloads[r.Metadata.FullName.String()] = r.Metadata.Labels
+		}
+		return true
+	})
+
+	rootNamespace := ""
+	c.ForEach(gvk.MeshConfig, func(r *resource.Instance) bool {
+		rootNamespace = r.Message.(*meshconfig.MeshConfig).GetRootNamespace()
+		return r.Metadata.FullName.Name != util.MeshConfigName
+	})
+
+	var rules []policyRule
+	c.ForEach(gvk.AuthorizationPolicy, func(r *resource.Instance) bool {
+		rules = append(rules, policyRules(r, rootNamespace, workloads)...)
+		return true
+	})
+	// Order the rules, so that the first of duplicate rules is not reported
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].policyName() != rules[j].policyName() {
+			return rules[i].policyName() < rules[j].policyName()
+		}
+		return rules[i].index < rules[j].index
+	})
+
+	for i, rule := range rules {
+		if m, ok := analyzeRule(rule, i, rules); ok {
+			if line, found := util.ErrorLineForPrefix(rule.r, fmt.Sprintf(util.AuthorizationPolicyRule, rule.index)); found {
+				m.Line = line
+			}
+			c.Report(gvk.AuthorizationPolicy, m)
+		}
+	}
+}
+
+// policyRules returns the rules of the policy r, if it is an enforced ALLOW or DENY policy applied to workloads.
+func policyRules(r *resource.Instance, rootNamespace string, workloads map[string]klabels.Set) []policyRule {
+	ap := r.Message.(*v1beta1.AuthorizationPolicy)
+	if ap.Action != v1beta1.AuthorizationPolicy_ALLOW && ap.Action != v1beta1.AuthorizationPolicy_DENY {
+		return nil
+	}
+	if ap.GetTargetRef() != nil || len(ap.GetTargetRefs()) > 0 {
+		return nil
+	}
+	if dryRun, err := strconv.ParseBool(r.Metadata.Annotations[annotation.IoIstioDryRun.Name]); err == nil && dryRun {
+		return nil
+	}
+
+	ns := r.Metadata.FullName.Namespace.String()
+	meshWide := ns == rootNamespace
+	selector := klabels.SelectorFromSet(ap.GetSelector().GetMatchLabels())
+	applied := sets.New[string]()
+	for name, labels := range workloads {
+		if !meshWide && !strings.HasPrefix(name, ns+"/") {
+			continue
+		}
+		if selector.Matches(labels) {
+			applied.Insert(name)
+		}
+	}
+	if applied.IsEmpty() {
+		return nil
+	}
+
+	policyName := types.NamespacedName{Name: r.Metadata.FullName.Name.String(), Namespace: ns}
+	var rules []policyRule
+	for i, rule := range ap.Rules {
+		m, err := authzmodel.New(policyName, rule)
+		if err != nil {
+			// Invalid rules are reported by validation
+			continue
+		}
+		rules = append(rules, policyRule{r: r, action: ap.Action, index: i, model: m, workloads: applied})
+	}
+	return rules
+}
+
+// analyzeRule checks whether the i-th rule is shadowed by a DENY rule, or duplicates or is redundant with another rule.
+func analyzeRule(rule policyRule, i int, rules []policyRule) (diag.Message, bool) {
+	if rule.action == v1beta1.AuthorizationPolicy_ALLOW {
+		for _, o := range rules {
+			if o.action == v1beta1.AuthorizationPolicy_DENY && o.appliesToAll(rule) && o.model.Covers(rule.model) {
+				return msg.NewAuthorizationPolicyRuleShadowed(rule.r, rule.index, o.index, o.policyName()), true
+			}
+		}
+	}
+	for j, o := range rules {
+		if i == j || o.action != rule.action || !o.appliesToAll(rule) || !o.model.Covers(rule.model) {
+			continue
+		}
+		if rule.appliesToAll(o) && rule.model.Covers(o.model) {
+			// Only the later of duplicate rules is reported
+			if j < i {
+				return msg.NewAuthorizationPolicyDuplicateRule(rule.r, rule.index, o.index, o.action.String(), o.policyName()), true
+			}
+			continue
+		}
+		return msg.NewAuthorizationPolicyRuleRedundant(rule.r, rule.index, o.index, o.action.String(), o.policyName()), true
+	}
+	return diag.Message{}, false
+}
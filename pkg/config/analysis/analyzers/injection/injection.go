@@ -26,6 +26,7 @@ import (
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/analysis"
 	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/diag"
 	"istio.io/istio/pkg/config/analysis/msg"
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/resource"
@@ -36,7 +37,10 @@ import (
 // Analyzer checks conditions related to Istio sidecar injection.
 type Analyzer struct{}
 
-var _ analysis.Analyzer = &Analyzer{}
+var (
+	_ analysis.Analyzer   = &Analyzer{}
+	_ analysis.Remediator = &Analyzer{}
+)
 
 // We assume that enablement is via an istio-injection=enabled or istio.io/rev namespace label
 // In theory, there can be alternatives using Mutatingwebhookconfiguration, but they're very uncommon
@@ -178,3 +182,22 @@ func GetEnableNamespacesByDefaultFromInjectedConfigMap(cm *v1.ConfigMap) bool {
 	injectionEnable := injectedCMValues[util.InjectorWebhookConfigKey].(map[string]any)[util.InjectorWebhookConfigValue]
 	return injectionEnable.(bool)
 }
+
+// Remediate implements Remediator. Namespaces without injection are labeled for injection.
+func (a *Analyzer) Remediate(m diag.Message) []analysis.PatchOperation {
+	if m.Type.Code() != msg.NamespaceNotInjected.Code() || m.Resource == nil {
+		return nil
+	}
+	if len(m.Resource.Metadata.Labels) == 0 {
+		return []analysis.PatchOperation{{
+			Op:    "add",
+			Path:  "/metadata/labels",
+			Value: map[string]string{util.InjectionLabelName: util.InjectionLabelEnableValue},
+		}}
+	}
+	return []analysis.PatchOperation{{
+		Op:    "add",
+		Path:  "/metadata/labels/" + util.InjectionLabelName,
+		Value: util.InjectionLabelEnableValue,
+	}}
+}
@@ -0,0 +1,335 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plugin provides analyzers declared in configuration files, with rules written as CEL expressions, so that
+// organization-specific rules can be checked without rebuilding istioctl or istiod.
+//
+// A plugin file declares a list of analyzers:
+//
+//	analyzers:
+//	- name: acme.GatewayTeamLabel
+//	  description: Checks that gateways have a team label
+//	  code: ACME0001
+//	  level: Warning
+//	  apiVersion: networking.istio.io/v1
+//	  kind: Gateway
+//	  expression: "has(object.metadata.labels) && 'team' in object.metadata.labels"
+//	  message: gateways must have a team label
+//
+// The expression is evaluated for each resource of the kind, available as `object`, and must evaluate to true for
+// valid resources, as in Kubernetes validating admission policies. The resources of the additional kinds listed in
+// `inputs` are available in `resources`, indexed by kind.
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/analysis/scope"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// Definitions is the content of a plugin file.
+type Definitions struct {
+	Analyzers []Definition `json:"analyzers"`
+}
+
+// Definition declares an analyzer.
+type Definition struct {
+	// Name of the analyzer, used to select it with `istioctl analyze --analyzer`.
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Code and level of the messages of the analyzer. The level is Error, Warning or Info.
+	Code  string `json:"code"`
+	Level string `json:"level"`
+	// APIVersion and Kind of the resources checked by the analyzer.
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// Inputs are the additional resources the expression has access to.
+	Inputs []Input `json:"inputs,omitempty"`
+	// Expression is a CEL expression which evaluates to true for valid resources.
+	Expression string `json:"expression"`
+	// Message of the invalid resources. MessageExpression, a CEL expression evaluating to a string, takes precedence.
+	Message           string `json:"message,omitempty"`
+	MessageExpression string `json:"messageExpression,omitempty"`
+}
+
+// Input is a kind of resources an expression has access to.
+type Input struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+}
+
+var codeRegex = regexp.MustCompile(`^[A-Z]+[0-9]{4}$`)
+
+// Analyzer is an analyzer declared in a plugin file.
+type Analyzer struct {
+	metadata    analysis.Metadata
+	messageType *diag.MessageType
+	kind        config.GroupVersionKind
+	// inputs are the additional resources, by kind.
+	inputs            map[string]config.GroupVersionKind
+	expression        cel.Program
+	message           string
+	messageExpression cel.Program
+}
+
+var _ analysis.Analyzer = &Analyzer{}
+
+// Load reads the analyzers declared in the plugin files at paths.
+func Load(paths ...string) ([]*Analyzer, error) {
+	var analyzers []*Analyzer
+	names := sets.New[string]()
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		as, err := Parse(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", p, err)
+		}
+		for _, a := range as {
+			if names.InsertContains(a.metadata.Name) {
+				return nil, fmt.Errorf("%s: duplicate analyzer %s", p, a.metadata.Name)
+			}
+		}
+		analyzers = append(analyzers, as...)
+	}
+	return analyzers, nil
+}
+
+// Parse parses the analyzers declared in a plugin file.
+func Parse(b []byte) ([]*Analyzer, error) {
+	var defs Definitions
+	if err := yaml.UnmarshalStrict(b, &defs); err != nil {
+		return nil, err
+	}
+	analyzers := make([]*Analyzer, 0, len(defs.Analyzers))
+	for _, d := range defs.Analyzers {
+		a, err := New(d)
+		if err != nil {
+			return nil, fmt.Errorf("analyzer %q: %v", d.Name, err)
+		}
+		analyzers = append(analyzers, a)
+	}
+	return analyzers, nil
+}
+
+// New creates an analyzer from its definition.
+func New(d Definition) (*Analyzer, error) {
+	if d.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if !codeRegex.MatchString(d.Code) {
+		return nil, fmt.Errorf("invalid code %q: must be letters followed by 4 digits", d.Code)
+	}
+	for _, mt := range msg.All() {
+		if mt.Code() == d.Code {
+			return nil, fmt.Errorf("code %s is already used by Istio", d.Code)
+		}
+	}
+	level, ok := diag.GetUppercaseStringToLevelMap()[strings.ToUpper(d.Level)]
+	if !ok {
+		return nil, fmt.Errorf("invalid level %q: must be Error, Warning or Info", d.Level)
+	}
+	if d.Message == "" && d.MessageExpression == "" {
+		return nil, fmt.Errorf("message or messageExpression is required")
+	}
+
+	kind, err := findKind(d.APIVersion, d.Kind)
+	if err != nil {
+		return nil, err
+	}
+	a := &Analyzer{
+		messageType: diag.NewMessageType(level, d.Code, "%s"),
+		kind:        kind,
+		inputs:      map[string]config.GroupVersionKind{},
+		message:     d.Message,
+	}
+	a.metadata = analysis.Metadata{
+		Name:        d.Name,
+		Description: d.Description,
+		Inputs:      []config.GroupVersionKind{kind},
+	}
+	for _, in := range d.Inputs {
+		k, err := findKind(in.APIVersion, in.Kind)
+		if err != nil {
+			return nil, err
+		}
+		if _, f := a.inputs[k.Kind]; f {
+			return nil, fmt.Errorf("duplicate input kind %s", k.Kind)
+		}
+		a.inputs[k.Kind] = k
+		if k != kind {
+			a.metadata.Inputs = append(a.metadata.Inputs, k)
+		}
+	}
+
+	if a.expression, err = compile(d.Expression, cel.BoolType); err != nil {
+		return nil, fmt.Errorf("expression: %v", err)
+	}
+	if d.MessageExpression != "" {
+		if a.messageExpression, err = compile(d.MessageExpression, cel.StringType); err != nil {
+			return nil, fmt.Errorf("messageExpression: %v", err)
+		}
+	}
+	return a, nil
+}
+
+func findKind(apiVersion, kind string) (config.GroupVersionKind, error) {
+	group, version, found := strings.Cut(apiVersion, "/")
+	if !found {
+		// Core resources, such as v1 Namespace
+		group, version = "", apiVersion
+	}
+	s, ok := collections.All.FindByGroupVersionAliasesKind(config.GroupVersionKind{Group: group, Version: version, Kind: kind})
+	if !ok {
+		return config.GroupVersionKind{}, fmt.Errorf("unknown kind %s %s", apiVersion, kind)
+	}
+	return s.GroupVersionKind(), nil
+}
+
+// celEnv returns the CEL environment of the expressions, built once.
+var celEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("object", cel.DynType),
+		cel.Variable("resources", cel.MapType(cel.StringType, cel.ListType(cel.DynType))),
+	)
+})
+
+func compile(expression string, outputType *cel.Type) (cel.Program, error) {
+	if expression == "" {
+		return nil, fmt.Errorf("expression is required")
+	}
+	env, err := celEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %v", err)
+	}
+	ast, iss := env.Compile(expression)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	if !ast.OutputType().IsEquivalentType(outputType) && !ast.OutputType().IsEquivalentType(cel.DynType) {
+		return nil, fmt.Errorf("expression must evaluate to %s, got %s", outputType, ast.OutputType())
+	}
+	return env.Program(ast)
+}
+
+// Metadata implements Analyzer
+func (a *Analyzer) Metadata() analysis.Metadata {
+	return a.metadata
+}
+
+// MessageType returns the type of the messages reported by the analyzer.
+func (a *Analyzer) MessageType() *diag.MessageType {
+	return a.messageType
+}
+
+// Analyze implements Analyzer
+func (a *Analyzer) Analyze(c analysis.Context) {
+	resources := make(map[string][]any, len(a.inputs))
+	for kind, k := range a.inputs {
+		list := []any{}
+		c.ForEach(k, func(r *resource.Instance) bool {
+			if obj, err := toObject(r); err == nil {
+				list = append(list, obj)
+			}
+			return true
+		})
+		resources[kind] = list
+	}
+
+	c.ForEach(a.kind, func(r *resource.Instance) bool {
+		obj, err := toObject(r)
+		if err != nil {
+			scope.Analysis.Warnf("%s: failed to convert %s: %v", a.metadata.Name, r.Metadata.FullName, err)
+			return true
+		}
+		vars := map[string]any{"object": obj, "resources": resources}
+		out, _, err := a.expression.Eval(vars)
+		if err != nil {
+			scope.Analysis.Warnf("%s: failed to evaluate expression for %s: %v", a.metadata.Name, r.Metadata.FullName, err)
+			return true
+		}
+		if valid, ok := out.Value().(bool); !ok || valid {
+			return true
+		}
+		c.Report(a.kind, diag.NewMessage(a.messageType, r, a.messageFor(r, vars)))
+		return true
+	})
+}
+
+func (a *Analyzer) messageFor(r *resource.Instance, vars map[string]any) string {
+	if a.messageExpression != nil {
+		out, _, err := a.messageExpression.Eval(vars)
+		if err == nil {
+			if m, ok := out.Value().(string); ok && m != "" {
+				return m
+			}
+		}
+		scope.Analysis.Warnf("%s: failed to evaluate message expression for %s: %v", a.metadata.Name, r.Metadata.FullName, err)
+	}
+	if a.message != "" {
+		return a.message
+	}
+	return fmt.Sprintf("%s is invalid", a.metadata.Name)
+}
+
+// toObject converts a resource to the object passed to expressions, laid out like a Kubernetes object.
+func toObject(r *resource.Instance) (map[string]any, error) {
+	spec, err := config.ToMap(r.Message)
+	if err != nil {
+		return nil, err
+	}
+	gvk := r.Metadata.Schema.GroupVersionKind()
+	metadata := map[string]any{
+		"name": r.Metadata.FullName.Name.String(),
+	}
+	if r.Metadata.FullName.Namespace != "" {
+		metadata["namespace"] = r.Metadata.FullName.Namespace.String()
+	}
+	if len(r.Metadata.Labels) > 0 {
+		metadata["labels"] = toAnyMap(r.Metadata.Labels)
+	}
+	if len(r.Metadata.Annotations) > 0 {
+		metadata["annotations"] = toAnyMap(r.Metadata.Annotations)
+	}
+	return map[string]any{
+		"apiVersion": gvk.GroupVersion(),
+		"kind":       gvk.Kind,
+		"metadata":   metadata,
+		"spec":       spec,
+	}, nil
+}
+
+func toAnyMap(m map[string]string) map[string]any {
+	res := make(map[string]any, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
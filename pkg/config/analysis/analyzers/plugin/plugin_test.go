@@ -0,0 +1,194 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"strings"
+	"testing"
+
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/analysis/local"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+const plugins = `
+analyzers:
+- name: acme.GatewayTeamLabel
+  description: Checks that gateways have a team label
+  code: ACME0001
+  level: Warning
+  apiVersion: networking.istio.io/v1
+  kind: Gateway
+  expression: "has(object.metadata.labels) && 'team' in object.metadata.labels"
+  message: gateways must have a team label
+- name: acme.NamespaceSidecar
+  code: ACME0002
+  level: Error
+  apiVersion: v1
+  kind: Namespace
+  inputs:
+  - apiVersion: networking.istio.io/v1
+    kind: Sidecar
+  expression: "resources.Sidecar.exists(s, s.metadata.namespace == object.metadata.name)"
+  messageExpression: "'namespace ' + object.metadata.name + ' has no Sidecar'"
+`
+
+const resources = `
+apiVersion: networking.istio.io/v1
+kind: Gateway
+metadata:
+  name: labeled
+  namespace: default
+  labels:
+    team: payments
+spec:
+  selector:
+    istio: ingressgateway
+---
+apiVersion: networking.istio.io/v1
+kind: Gateway
+metadata:
+  name: unlabeled
+  namespace: default
+spec:
+  selector:
+    istio: ingressgateway
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: default
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: payments
+---
+apiVersion: networking.istio.io/v1
+kind: Sidecar
+metadata:
+  name: default
+  namespace: payments
+spec:
+  egress:
+  - hosts:
+    - "./*"
+`
+
+func TestAnalyzer(t *testing.T) {
+	plugins, err := Parse([]byte(plugins))
+	assert.NoError(t, err)
+	all := make([]analysis.Analyzer, 0, len(plugins))
+	for _, p := range plugins {
+		all = append(all, p)
+	}
+
+	sa := local.NewSourceAnalyzer(analysis.Combine("plugins", all...), "", "istio-system", nil)
+	assert.NoError(t, sa.AddTestReaderKubeSource([]local.ReaderSource{{Name: "resources", Reader: strings.NewReader(resources)}}))
+	result, err := sa.Analyze(make(chan struct{}))
+	assert.NoError(t, err)
+
+	got := []string{}
+	for _, m := range result.Messages {
+		got = append(got, m.String())
+	}
+	assert.Equal(t, got, []string{
+		"Error [ACME0002] (Namespace default resources:22) namespace default has no Sidecar",
+		"Warning [ACME0001] (Gateway default/unlabeled resources:13) gateways must have a team label",
+	})
+	assert.Equal(t, plugins[1].MessageType().Level().String(), diag.Error.String())
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		name       string
+		definition string
+		err        string
+	}{
+		{
+			name:       "unknown field",
+			definition: "rego: package acme",
+			err:        "unknown field",
+		},
+		{
+			name:       "invalid code",
+			definition: "code: acme-1",
+			err:        "invalid code",
+		},
+		{
+			name:       "istio code",
+			definition: "code: IST0101",
+			err:        "already used by Istio",
+		},
+		{
+			name:       "invalid level",
+			definition: "level: Critical",
+			err:        "invalid level",
+		},
+		{
+			name:       "unknown kind",
+			definition: "kind: Gateways",
+			err:        "unknown kind",
+		},
+		{
+			name:       "not a boolean",
+			definition: `expression: "'name'"`,
+			err:        "must evaluate to bool",
+		},
+		{
+			name:       "invalid expression",
+			definition: "expression: object.metadata.name ==",
+			err:        "Syntax error",
+		},
+		{
+			name:       "no message",
+			definition: "message: ''",
+			err:        "message or messageExpression is required",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			// Override a field of a valid definition
+			fields := map[string]string{
+				"name":       "acme.Test",
+				"code":       "ACME0001",
+				"level":      "Warning",
+				"apiVersion": "networking.istio.io/v1",
+				"kind":       "Gateway",
+				"expression": "true",
+				"message":    "invalid",
+			}
+			var extra string
+			k, v, _ := strings.Cut(tt.definition, ": ")
+			if _, f := fields[k]; f {
+				fields[k] = v
+			} else {
+				extra = "\n    " + tt.definition
+			}
+			var b strings.Builder
+			b.WriteString("analyzers:\n  - name: " + fields["name"])
+			for _, f := range []string{"code", "level", "apiVersion", "kind", "expression", "message"} {
+				b.WriteString("\n    " + f + ": " + fields[f])
+			}
+			b.WriteString(extra)
+
+			_, err := Parse([]byte(b.String()))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ package service
 
 import (
 	"fmt"
+	"strings"
 
 	v1 "k8s.io/api/core/v1"
 
@@ -23,17 +24,32 @@ import (
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/analysis"
 	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/diag"
 	"istio.io/istio/pkg/config/analysis/msg"
 	"istio.io/istio/pkg/config/constants"
 	configKube "istio.io/istio/pkg/config/kube"
+	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/util/sets"
 )
 
 // PortNameAnalyzer checks the port name of the service
 type PortNameAnalyzer struct{}
 
-var _ analysis.Analyzer = &PortNameAnalyzer{}
+var (
+	_ analysis.Analyzer   = &PortNameAnalyzer{}
+	_ analysis.Remediator = &PortNameAnalyzer{}
+)
+
+// portProtocols are the protocols of ports whose protocol is obvious from their number.
+var portProtocols = map[int32]protocol.Instance{
+	80:   protocol.HTTP,
+	8080: protocol.HTTP,
+	443:  protocol.HTTPS,
+	8443: protocol.HTTPS,
+	6379: protocol.Redis,
+}
 
 // Metadata implements Analyzer
 func (s *PortNameAnalyzer) Metadata() analysis.Metadata {
@@ -85,3 +101,38 @@ func (s *PortNameAnalyzer) analyzeService(r *resource.Instance, c analysis.Conte
 		}
 	}
 }
+
+// Remediate implements Remediator. Ports with an obvious protocol are renamed with their protocol as prefix.
+func (s *PortNameAnalyzer) Remediate(m diag.Message) []analysis.PatchOperation {
+	if m.Type.Code() != msg.PortNameIsNotUnderNamingConvention.Code() || m.Resource == nil || len(m.Parameters) < 2 {
+		return nil
+	}
+	svc := m.Resource.Message.(*v1.ServiceSpec)
+	names := sets.New[string]()
+	for _, port := range svc.Ports {
+		names.Insert(port.Name)
+	}
+	for i, port := range svc.Ports {
+		if port.Name != m.Parameters[0] || int(port.Port) != m.Parameters[1] {
+			continue
+		}
+		proto, ok := portProtocols[port.Port]
+		// The application protocol takes precedence over the name, so renaming would not help
+		if !ok || port.AppProtocol != nil {
+			return nil
+		}
+		name := strings.ToLower(string(proto))
+		if port.Name != "" {
+			name += "-" + port.Name
+		}
+		if names.Contains(name) {
+			return nil
+		}
+		op := "replace"
+		if port.Name == "" {
+			op = "add"
+		}
+		return []analysis.PatchOperation{{Op: op, Path: fmt.Sprintf("/spec/ports/%d/name", i), Value: name}}
+	}
+	return nil
+}
@@ -113,6 +113,14 @@ const (
 	// Path for selector in telemetry.
 	// Required parameters: selector label.
 	TelemetrySelector = "{.spec.selector.matchLabels.%s}"
+
+	// Path prefix for the fields of a rule in authorizationPolicy, to use with ErrorLineForPrefix.
+	// Required parameters: rule index.
+	AuthorizationPolicyRule = "{.spec.rules[%d]."
+
+	// Path prefix for the fields of an HTTP route in VirtualService, to use with ErrorLineForPrefix.
+	// Required parameters: http index.
+	VSHTTPRoute = "{.spec.http[%d]."
 )
 
 // ErrorLine returns the line number of the input path key in the resource
@@ -125,6 +133,17 @@ func ErrorLine(r *resource.Instance, path string) (line int, found bool) {
 	return line, true
 }
 
+// ErrorLineForPrefix returns the first line number of the fields whose path starts with prefix in the resource.
+// This is used to find the line of an object, as only the lines of scalar fields are known.
+func ErrorLineForPrefix(r *resource.Instance, prefix string) (line int, found bool) {
+	for path, l := range r.Origin.FieldMap() {
+		if strings.HasPrefix(path, prefix) && (!found || l < line) {
+			line, found = l, true
+		}
+	}
+	return line, found
+}
+
 // ExtractLabelFromSelectorString returns the label of the match in the k8s labels.Selector
 func ExtractLabelFromSelectorString(s string) string {
 	equalIndex := strings.Index(s, "=")
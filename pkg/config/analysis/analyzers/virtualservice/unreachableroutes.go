@@ -0,0 +1,399 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// UnreachableRouteAnalyzer checks for HTTP routes which are never matched, because earlier routes for the same host
+// match all their requests. Routes are ordered as proxies match them: in the order of each virtual service, and
+// across the virtual services merged for the same gateway host, with catch-all routes last.
+type UnreachableRouteAnalyzer struct{}
+
+var _ analysis.Analyzer = &UnreachableRouteAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *UnreachableRouteAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "virtualservice.UnreachableRouteAnalyzer",
+		Description: "Checks for HTTP routes of virtual services which are never matched",
+		Inputs: []config.GroupVersionKind{
+			gvk.VirtualService,
+		},
+	}
+}
+
+// httpMatch is a match of an HTTP route. match is nil for routes without matches.
+type httpMatch struct {
+	vs    *resource.Instance
+	route int
+	match *v1alpha3.HTTPMatchRequest
+}
+
+type routeKey struct {
+	vs    resource.FullName
+	route int
+}
+
+func (m httpMatch) key() routeKey {
+	return routeKey{vs: m.vs.Metadata.FullName, route: m.route}
+}
+
+type gatewayHost struct {
+	gateway resource.FullName
+	host    string
+}
+
+// Analyze implements Analyzer
+func (a *UnreachableRouteAnalyzer) Analyze(c analysis.Context) {
+	var vsList []*resource.Instance
+	c.ForEach(gvk.VirtualService, func(r *resource.Instance) bool {
+		vsList = append(vsList, r)
+		return true
+	})
+	// Virtual services are merged in order of creation, as in istiod
+	sort.SliceStable(vsList, func(i, j int) bool {
+		mi, mj := vsList[i].Metadata, vsList[j].Metadata
+		if !mi.CreateTime.Equal(mj.CreateTime) {
+			return mi.CreateTime.Before(mj.CreateTime)
+		}
+		if mi.FullName.Name != mj.FullName.Name {
+			return mi.FullName.Name < mj.FullName.Name
+		}
+		return mi.FullName.Namespace < mj.FullName.Namespace
+	})
+
+	reported := sets.New[routeKey]()
+	matches := map[resource.FullName][]httpMatch{}
+	gatewayHosts := map[gatewayHost][]*resource.Instance{}
+	for _, r := range vsList {
+		matches[r.Metadata.FullName] = analyzeVirtualServiceRoutes(c, r, reported)
+
+		vs := r.Message.(*v1alpha3.VirtualService)
+		for _, gw := range vs.Gateways {
+			if gw == util.MeshGateway {
+				continue
+			}
+			gwName := resource.NewShortOrFullName(r.Metadata.FullName.Namespace, gw)
+			for _, h := range vs.Hosts {
+				key := gatewayHost{gateway: gwName, host: strings.ToLower(h)}
+				gatewayHosts[key] = append(gatewayHosts[key], r)
+			}
+		}
+	}
+
+	keys := make([]gatewayHost, 0, len(gatewayHosts))
+	for key, vss := range gatewayHosts {
+		if len(vss) > 1 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].gateway != keys[j].gateway {
+			return keys[i].gateway.String() < keys[j].gateway.String()
+		}
+		return keys[i].host < keys[j].host
+	})
+	for _, key := range keys {
+		var merged, catchAll []httpMatch
+		for _, r := range gatewayHosts[key] {
+			for _, m := range matches[r.Metadata.FullName] {
+				if !appliesToGateway(m, key.gateway) {
+					continue
+				}
+				// Catch-all routes of the merged virtual services are moved to the end
+				if isCatchAll(m.match, true) {
+					catchAll = append(catchAll, m)
+				} else {
+					merged = append(merged, m)
+				}
+			}
+		}
+		reportShadowedRoutes(c, append(merged, catchAll...), reported, true)
+	}
+}
+
+// analyzeVirtualServiceRoutes reports the unreachable routes of a virtual service, and returns the matches of the
+// reachable routes, in order.
+func analyzeVirtualServiceRoutes(c analysis.Context, r *resource.Instance, reported sets.Set[routeKey]) []httpMatch {
+	vs := r.Message.(*v1alpha3.VirtualService)
+	var matches []httpMatch
+	for i, route := range vs.Http {
+		if route == nil {
+			continue
+		}
+		catchAll := false
+		if len(route.Match) == 0 {
+			matches = append(matches, httpMatch{vs: r, route: i})
+			catchAll = true
+		}
+		for _, match := range route.Match {
+			matches = append(matches, httpMatch{vs: r, route: i, match: match})
+			// Proxies never go beyond a catch-all match, so the next routes are not even sent to them
+			if isCatchAll(match, false) {
+				catchAll = true
+				break
+			}
+		}
+		if catchAll && i < len(vs.Http)-1 {
+			var unreachable []string
+			for j := i + 1; j < len(vs.Http); j++ {
+				unreachable = append(unreachable, routeName(vs.Http[j], j))
+				reported.Insert(routeKey{vs: r.Metadata.FullName, route: j})
+			}
+			report(c, r, i, msg.NewVirtualServiceCatchAllRouteNotLast(r, routeName(route, i), strings.Join(unreachable, ", ")))
+			break
+		}
+	}
+	reportShadowedRoutes(c, matches, reported, false)
+	return matches
+}
+
+// reportShadowedRoutes reports the routes all of whose matches are covered by matches of earlier routes.
+// For merged virtual services, only the routes covered by the routes of another virtual service are reported, the
+// others being reported by the analysis of their virtual service.
+func reportShadowedRoutes(c analysis.Context, matches []httpMatch, reported sets.Set[routeKey], merged bool) {
+	var routes []routeKey
+	positions := map[routeKey][]int{}
+	for i, m := range matches {
+		if _, f := positions[m.key()]; !f {
+			routes = append(routes, m.key())
+		}
+		positions[m.key()] = append(positions[m.key()], i)
+	}
+
+	for _, key := range routes {
+		if reported.Contains(key) {
+			continue
+		}
+		r := matches[positions[key][0]].vs
+		var covering []string
+		coveringSeen := sets.New[routeKey]()
+		shadowed, otherVirtualService, onlyDuplicates := true, false, true
+		for _, pos := range positions[key] {
+			m := matches[pos]
+			var coveredBy *httpMatch
+			for i := 0; i < pos; i++ {
+				if matches[i].key() != key && covers(matches[i].match, m.match, merged) {
+					coveredBy = &matches[i]
+					break
+				}
+			}
+			if coveredBy == nil {
+				shadowed = false
+				break
+			}
+			if coveredBy.vs != r {
+				otherVirtualService = true
+			}
+			if coveredBy.vs != r || !proto.Equal(coveredBy.match, m.match) {
+				onlyDuplicates = false
+			}
+			if !coveringSeen.InsertContains(coveredBy.key()) {
+				covering = append(covering, coveringRouteName(*coveredBy, r))
+			}
+		}
+		// Routes whose matches all duplicate earlier matches of the same virtual service are reported by validation
+		if !shadowed || (merged && !otherVirtualService) || onlyDuplicates {
+			continue
+		}
+		reported.Insert(key)
+		vs := r.Message.(*v1alpha3.VirtualService)
+		report(c, r, key.route, msg.NewVirtualServiceRouteShadowed(r, routeName(vs.Http[key.route], key.route), strings.Join(covering, ", ")))
+	}
+}
+
+func report(c analysis.Context, r *resource.Instance, route int, m diag.Message) {
+	if line, ok := util.ErrorLineForPrefix(r, fmt.Sprintf(util.VSHTTPRoute, route)); ok {
+		m.Line = line
+	}
+	c.Report(gvk.VirtualService, m)
+}
+
+func routeName(route *v1alpha3.HTTPRoute, i int) string {
+	if route.GetName() != "" {
+		return fmt.Sprintf("%q", route.Name)
+	}
+	return fmt.Sprintf("#%d", i)
+}
+
+// coveringRouteName returns the name of the route of m, qualified by its virtual service if it is not r.
+func coveringRouteName(m httpMatch, r *resource.Instance) string {
+	vs := m.vs.Message.(*v1alpha3.VirtualService)
+	name := routeName(vs.Http[m.route], m.route)
+	if m.vs != r {
+		name += " of VirtualService " + m.vs.Metadata.FullName.String()
+	}
+	return name
+}
+
+func appliesToGateway(m httpMatch, gateway resource.FullName) bool {
+	if len(m.match.GetGateways()) == 0 {
+		return true
+	}
+	for _, gw := range m.match.Gateways {
+		if resource.NewShortOrFullName(m.vs.Metadata.FullName.Namespace, gw) == gateway {
+			return true
+		}
+	}
+	return false
+}
+
+// isCatchAll returns true if m matches all requests, as in route.IsCatchAllRoute.
+// For matches of a gateway, the gateways of the match are ignored.
+func isCatchAll(m *v1alpha3.HTTPMatchRequest, gatewayScoped bool) bool {
+	return covers(m, nil, gatewayScoped)
+}
+
+// covers returns true if all the requests matching m also match c. A nil match matches all requests.
+// It is conservative: false may be returned for some matches which do cover m, for instance with regexes.
+// For matches of a gateway, the gateways of the matches are ignored.
+func covers(c, m *v1alpha3.HTTPMatchRequest, gatewayScoped bool) bool {
+	if c == nil {
+		return true
+	}
+	if m == nil {
+		m = &v1alpha3.HTTPMatchRequest{}
+	}
+	if !uriCovers(c, m) ||
+		!stringMatchCovers(c.Scheme, m.Scheme, false, false) ||
+		!stringMatchCovers(c.Method, m.Method, false, false) ||
+		!stringMatchCovers(c.Authority, m.Authority, false, false) ||
+		!stringMatchesCover(c.Headers, m.Headers) ||
+		!stringMatchesCover(c.QueryParams, m.QueryParams) {
+		return false
+	}
+	// Requests without the headers of c do not match m, unless m excludes the same headers
+	for name, wh := range c.WithoutHeaders {
+		if !proto.Equal(wh, m.WithoutHeaders[name]) {
+			return false
+		}
+	}
+	if c.Port != 0 && c.Port != m.Port {
+		return false
+	}
+	if c.SourceNamespace != "" && c.SourceNamespace != m.SourceNamespace {
+		return false
+	}
+	for k, v := range c.SourceLabels {
+		if mv, f := m.SourceLabels[k]; !f || mv != v {
+			return false
+		}
+	}
+	if !gatewayScoped && len(c.Gateways) > 0 {
+		if len(m.Gateways) == 0 || !sets.New(c.Gateways...).SupersetOf(sets.New(m.Gateways...)) {
+			return false
+		}
+	}
+	return true
+}
+
+func uriCovers(c, m *v1alpha3.HTTPMatchRequest) bool {
+	switch u := c.Uri.GetMatchType().(type) {
+	case *v1alpha3.StringMatch_Prefix:
+		if u.Prefix == "/" {
+			return true
+		}
+	case *v1alpha3.StringMatch_Regex:
+		if u.Regex == ".*" {
+			return true
+		}
+	}
+	return stringMatchCovers(c.Uri, m.Uri, c.IgnoreUriCase, m.IgnoreUriCase)
+}
+
+// stringMatchesCover returns true if the headers or query parameters matched by m are also matched by c.
+func stringMatchesCover(c, m map[string]*v1alpha3.StringMatch) bool {
+	for name, cm := range c {
+		mm, f := m[name]
+		if !f {
+			return false
+		}
+		// An empty match only requires the presence of the header
+		if cm.GetMatchType() == nil {
+			continue
+		}
+		if !stringMatchCovers(cm, mm, false, false) {
+			return false
+		}
+	}
+	return true
+}
+
+func stringMatchCovers(c, m *v1alpha3.StringMatch, cIgnoreCase, mIgnoreCase bool) bool {
+	if c.GetMatchType() == nil {
+		return true
+	}
+	if m.GetMatchType() == nil {
+		return false
+	}
+	if r, ok := c.MatchType.(*v1alpha3.StringMatch_Regex); ok {
+		return regexCovers(r.Regex, m)
+	}
+	if mIgnoreCase && !cIgnoreCase {
+		return false
+	}
+	normalize := func(s string) string {
+		if cIgnoreCase {
+			return strings.ToLower(s)
+		}
+		return s
+	}
+	switch cm := c.MatchType.(type) {
+	case *v1alpha3.StringMatch_Exact:
+		e, ok := m.MatchType.(*v1alpha3.StringMatch_Exact)
+		return ok && normalize(e.Exact) == normalize(cm.Exact)
+	case *v1alpha3.StringMatch_Prefix:
+		switch mm := m.MatchType.(type) {
+		case *v1alpha3.StringMatch_Exact:
+			return strings.HasPrefix(normalize(mm.Exact), normalize(cm.Prefix))
+		case *v1alpha3.StringMatch_Prefix:
+			return strings.HasPrefix(normalize(mm.Prefix), normalize(cm.Prefix))
+		}
+	}
+	return false
+}
+
+// regexCovers returns true if the values matched by m match the regex.
+func regexCovers(regex string, m *v1alpha3.StringMatch) bool {
+	if regex == ".*" {
+		return true
+	}
+	switch mm := m.MatchType.(type) {
+	case *v1alpha3.StringMatch_Regex:
+		return mm.Regex == regex
+	case *v1alpha3.StringMatch_Exact:
+		// Envoy regexes must match the full value
+		re, err := regexp.Compile("^(?:" + regex + ")$")
+		return err == nil && re.MatchString(mm.Exact)
+	}
+	return false
+}
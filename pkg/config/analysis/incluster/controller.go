@@ -26,7 +26,9 @@ import (
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/status"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
 	"istio.io/istio/pkg/config/analysis/analyzers"
+	"istio.io/istio/pkg/config/analysis/analyzers/plugin"
 	"istio.io/istio/pkg/config/analysis/diag"
 	"istio.io/istio/pkg/config/analysis/legacy/util/kuberesource"
 	"istio.io/istio/pkg/config/analysis/local"
@@ -47,8 +49,16 @@ type Controller struct {
 func NewController(stop <-chan struct{}, rwConfigStore model.ConfigStoreController,
 	kubeClient kube.Client, revision, namespace string, statusManager *status.Manager, domainSuffix string,
 ) (*Controller, error) {
-	analyzer := analyzers.AllCombined()
-	all := kuberesource.ConvertInputsToSchemas(analyzer.Metadata().Inputs)
+	plugins, err := plugin.Load(features.AnalysisPlugins...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load analyzer plugins: %v", err)
+	}
+	all := analyzers.All()
+	for _, p := range plugins {
+		all = append(all, p)
+	}
+	analyzer := analysis.Combine("all", all...)
+	schemas := kuberesource.ConvertInputsToSchemas(analyzer.Metadata().Inputs)
 
 	ia := local.NewIstiodAnalyzer(analyzer, "", resource.Namespace(namespace), func(name config.GroupVersionKind) {})
 	ia.AddSource(rwConfigStore)
@@ -61,11 +71,11 @@ func NewController(stop <-chan struct{}, rwConfigStore model.ConfigStoreControll
 			Identifier:   "analysis-controller",
 			FiltersByGVK: ia.GetFiltersByGVK(),
 		},
-		all.Remove(rwConfigStore.Schemas().All()...))
+		schemas.Remove(rwConfigStore.Schemas().All()...))
 
 	ia.AddSource(store)
 	kubeClient.RunAndWait(stop)
-	err := ia.Init(stop)
+	err = ia.Init(stop)
 	if err != nil {
 		return nil, fmt.Errorf("unable to initialize analysis controller, releasing lease: %s", err)
 	}
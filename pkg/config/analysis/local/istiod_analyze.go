@@ -319,6 +319,29 @@ func (sa *IstiodAnalyzer) addReaderKubeSourceInternal(readers []ReaderSource, in
 	return errs
 }
 
+// UpdateReaderKubeSource applies the new content of readers added with AddReaderKubeSource, and returns the kinds of
+// the resources which changed, to re-analyze them with ReAnalyzeSubset. Empty content removes the resources of a reader.
+func (sa *IstiodAnalyzer) UpdateReaderKubeSource(readers []ReaderSource) (sets.Set[config.GroupVersionKind], error) {
+	if sa.fileSource == nil {
+		return nil, fmt.Errorf("no file source to update")
+	}
+	changed := sets.New[config.GroupVersionKind]()
+	var errs error
+	for _, r := range readers {
+		by, err := io.ReadAll(r.Reader)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		kinds, err := sa.fileSource.UpdateContent(r.Name, string(by))
+		changed.Merge(kinds)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return changed, errs
+}
+
 // AddRunningKubeSource adds a source based on a running k8s cluster to the current IstiodAnalyzer
 // Also tries to get mesh config from the running cluster, if it can
 func (sa *IstiodAnalyzer) AddRunningKubeSource(c kubelib.Client) {
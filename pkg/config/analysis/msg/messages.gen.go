@@ -255,6 +255,26 @@ var (
 	// NegativeConditionStatus defines a diag.MessageType for message "NegativeConditionStatus".
 	// Description: A condition with a negative status is present
 	NegativeConditionStatus = diag.NewMessageType(diag.Warning, "IST0171", "A condition with a negative status is present: type=%s, reason=%s, message=%s.")
+
+	// AuthorizationPolicyRuleShadowed defines a diag.MessageType for message "AuthorizationPolicyRuleShadowed".
+	// Description: An ALLOW rule of an authorization policy never matches, as all the requests it matches are denied by a DENY policy applied to the same workloads
+	AuthorizationPolicyRuleShadowed = diag.NewMessageType(diag.Warning, "IST0172", "Rule %d of this ALLOW policy never matches: all the requests it matches are denied by rule %d of the DENY policy %s.")
+
+	// AuthorizationPolicyRuleRedundant defines a diag.MessageType for message "AuthorizationPolicyRuleRedundant".
+	// Description: A rule of an authorization policy is redundant, as all the requests it matches are matched by a broader rule with the same action
+	AuthorizationPolicyRuleRedundant = diag.NewMessageType(diag.Info, "IST0173", "Rule %d of this policy is redundant: all the requests it matches are matched by rule %d of the %s policy %s.")
+
+	// AuthorizationPolicyDuplicateRule defines a diag.MessageType for message "AuthorizationPolicyDuplicateRule".
+	// Description: A rule of an authorization policy duplicates a rule with the same action applied to the same workloads
+	AuthorizationPolicyDuplicateRule = diag.NewMessageType(diag.Info, "IST0174", "Rule %d of this policy duplicates rule %d of the %s policy %s.")
+
+	// VirtualServiceRouteShadowed defines a diag.MessageType for message "VirtualServiceRouteShadowed".
+	// Description: An HTTP route of a VirtualService is never matched, as all its matches are covered by earlier routes for the same host
+	VirtualServiceRouteShadowed = diag.NewMessageType(diag.Warning, "IST0175", "HTTP route %s is never matched: all its matches are covered by route %s.")
+
+	// VirtualServiceCatchAllRouteNotLast defines a diag.MessageType for message "VirtualServiceCatchAllRouteNotLast".
+	// Description: An HTTP route of a VirtualService matches all requests, but is not the last route
+	VirtualServiceCatchAllRouteNotLast = diag.NewMessageType(diag.Warning, "IST0176", "HTTP route %s matches all requests, so the routes %s after it are never matched.")
 )
 
 // All returns a list of all known message types.
@@ -322,6 +342,11 @@ func All() []*diag.MessageType {
 		UpdateIncompatibility,
 		MultiClusterInconsistentService,
 		NegativeConditionStatus,
+		AuthorizationPolicyRuleShadowed,
+		AuthorizationPolicyRuleRedundant,
+		AuthorizationPolicyDuplicateRule,
+		VirtualServiceRouteShadowed,
+		VirtualServiceCatchAllRouteNotLast,
 	}
 }
 
@@ -935,3 +960,58 @@ func NewNegativeConditionStatus(r *resource.Instance, conditionType string, reas
 		message,
 	)
 }
+
+// NewAuthorizationPolicyRuleShadowed returns a new diag.Message based on AuthorizationPolicyRuleShadowed.
+func NewAuthorizationPolicyRuleShadowed(r *resource.Instance, rule int, denyRule int, denyPolicy string) diag.Message {
+	return diag.NewMessage(
+		AuthorizationPolicyRuleShadowed,
+		r,
+		rule,
+		denyRule,
+		denyPolicy,
+	)
+}
+
+// NewAuthorizationPolicyRuleRedundant returns a new diag.Message based on AuthorizationPolicyRuleRedundant.
+func NewAuthorizationPolicyRuleRedundant(r *resource.Instance, rule int, coveringRule int, action string, coveringPolicy string) diag.Message {
+	return diag.NewMessage(
+		AuthorizationPolicyRuleRedundant,
+		r,
+		rule,
+		coveringRule,
+		action,
+		coveringPolicy,
+	)
+}
+
+// NewAuthorizationPolicyDuplicateRule returns a new diag.Message based on AuthorizationPolicyDuplicateRule.
+func NewAuthorizationPolicyDuplicateRule(r *resource.Instance, rule int, duplicatedRule int, action string, duplicatedPolicy string) diag.Message {
+	return diag.NewMessage(
+		AuthorizationPolicyDuplicateRule,
+		r,
+		rule,
+		duplicatedRule,
+		action,
+		duplicatedPolicy,
+	)
+}
+
+// NewVirtualServiceRouteShadowed returns a new diag.Message based on VirtualServiceRouteShadowed.
+func NewVirtualServiceRouteShadowed(r *resource.Instance, route string, coveringRoutes string) diag.Message {
+	return diag.NewMessage(
+		VirtualServiceRouteShadowed,
+		r,
+		route,
+		coveringRoutes,
+	)
+}
+
+// NewVirtualServiceCatchAllRouteNotLast returns a new diag.Message based on VirtualServiceCatchAllRouteNotLast.
+func NewVirtualServiceCatchAllRouteNotLast(r *resource.Instance, route string, unreachableRoutes string) diag.Message {
+	return diag.NewMessage(
+		VirtualServiceCatchAllRouteNotLast,
+		r,
+		route,
+		unreachableRoutes,
+	)
+}
@@ -32,6 +32,8 @@ import (
 const (
 	MeshConfigKey   = "mesh"
 	MeshNetworksKey = "meshNetworks"
+	// CAIssuancePolicyKey is the key of the policy restricting the certificates issued by the istiod CA.
+	CAIssuancePolicyKey = "caIssuancePolicy"
 )
 
 // NewConfigMapSource builds a MeshConfigSource reading from ConfigMap "name" with key "key".
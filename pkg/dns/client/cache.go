@@ -0,0 +1,146 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/miekg/dns"
+
+	"istio.io/istio/pkg/slices"
+)
+
+type cacheKey struct {
+	name   string
+	qtype  uint16
+	qclass uint16
+}
+
+type cacheEntry struct {
+	response *dns.Msg
+	stored   time.Time
+	expires  time.Time
+}
+
+// responseCache is a bounded LRU cache of upstream responses. Positive responses are cached for the lowest
+// TTL of their answers. Negative (NXDOMAIN and NODATA) responses are cached as described in RFC 2308, for the
+// TTL of the SOA record in the authority section, or negativeTTL if there is none.
+// The TTLs of cached responses are decremented by the time they spent in the cache when served.
+type responseCache struct {
+	entries     *lru.Cache[cacheKey, cacheEntry]
+	negativeTTL time.Duration
+	// now is overridden in tests
+	now func() time.Time
+}
+
+func newResponseCache(size int, negativeTTL time.Duration) *responseCache {
+	// The size is always positive, so this cannot fail
+	entries, _ := lru.New[cacheKey, cacheEntry](size)
+	return &responseCache{
+		entries:     entries,
+		negativeTTL: negativeTTL,
+		now:         time.Now,
+	}
+}
+
+func keyFor(req *dns.Msg) (cacheKey, bool) {
+	// We only ever answer the first question, so only cache single question requests
+	if len(req.Question) != 1 {
+		return cacheKey{}, false
+	}
+	q := req.Question[0]
+	return cacheKey{name: strings.ToLower(q.Name), qtype: q.Qtype, qclass: q.Qclass}, true
+}
+
+// get returns a response to req from the cache, or nil if there is no unexpired entry for it.
+func (c *responseCache) get(req *dns.Msg) *dns.Msg {
+	key, ok := keyFor(req)
+	if !ok {
+		return nil
+	}
+	entry, f := c.entries.Get(key)
+	now := c.now()
+	if !f || !now.Before(entry.expires) {
+		if f {
+			c.entries.Remove(key)
+		}
+		cacheMisses.Increment()
+		return nil
+	}
+	cacheHits.Increment()
+
+	response := entry.response.Copy()
+	response.Id = req.Id
+	response.Question = req.Question
+	if req.IsEdns0() == nil {
+		// The response may have been cached for an EDNS request, but must not have an OPT record otherwise
+		response.Extra = slices.FilterInPlace(response.Extra, func(rr dns.RR) bool {
+			return rr.Header().Rrtype != dns.TypeOPT
+		})
+	}
+	elapsed := uint32(now.Sub(entry.stored).Seconds())
+	for _, rrs := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
+		for _, rr := range rrs {
+			if rr.Header().Rrtype == dns.TypeOPT {
+				// The TTL field of OPT records holds flags
+				continue
+			}
+			rr.Header().Ttl -= min(elapsed, rr.Header().Ttl)
+		}
+	}
+	return response
+}
+
+// put stores the upstream response to req, if it is cacheable.
+func (c *responseCache) put(req *dns.Msg, response *dns.Msg) {
+	key, ok := keyFor(req)
+	if !ok || response == nil || response.Truncated {
+		return
+	}
+	ttl := c.ttl(response)
+	if ttl <= 0 {
+		return
+	}
+	now := c.now()
+	c.entries.Add(key, cacheEntry{
+		response: response.Copy(),
+		stored:   now,
+		expires:  now.Add(ttl),
+	})
+}
+
+// ttl returns how long the response can be cached for. A zero TTL means it must not be cached.
+func (c *responseCache) ttl(response *dns.Msg) time.Duration {
+	switch {
+	case response.Rcode == dns.RcodeSuccess && len(response.Answer) > 0:
+		ttl := response.Answer[0].Header().Ttl
+		for _, rr := range response.Answer[1:] {
+			ttl = min(ttl, rr.Header().Ttl)
+		}
+		return time.Duration(ttl) * time.Second
+	case response.Rcode == dns.RcodeSuccess, response.Rcode == dns.RcodeNameError:
+		for _, rr := range response.Ns {
+			if soa, ok := rr.(*dns.SOA); ok {
+				return time.Duration(min(soa.Hdr.Ttl, soa.Minttl)) * time.Second
+			}
+		}
+		return c.negativeTTL
+	default:
+		// Server failures and refusals are likely transient, do not cache them
+		return 0
+	}
+}
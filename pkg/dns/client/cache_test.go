@@ -0,0 +1,153 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestDNSWithCache(t *testing.T) {
+	d := initDNS(t, false)
+	d.cache = newResponseCache(100, time.Minute)
+	testDNS(t, d)
+}
+
+func TestDNSTTL(t *testing.T) {
+	d := initDNS(t, false)
+	d.ttl = 5
+	fillTable(d)
+
+	m := new(dns.Msg)
+	m.SetQuestion("productpage.ns1.svc.cluster.local.", dns.TypeA)
+	res, _, err := (&dns.Client{Net: "udp"}).Exchange(m, d.dnsProxies[0].Address())
+	assert.NoError(t, err)
+	assert.Equal(t, len(res.Answer), 1)
+	assert.Equal(t, res.Answer[0].Header().Ttl, uint32(5))
+}
+
+func TestResponseCache(t *testing.T) {
+	now := time.Now()
+	newCache := func() *responseCache {
+		c := newResponseCache(2, 10*time.Second)
+		c.now = func() time.Time { return now }
+		return c
+	}
+	query := func(name string, qtype uint16) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetQuestion(name, qtype)
+		return m
+	}
+	reply := func(req *dns.Msg, rcode int, answers ...dns.RR) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		m.Rcode = rcode
+		m.Answer = answers
+		return m
+	}
+	soa := func(ttl, minttl uint32) dns.RR {
+		return &dns.SOA{
+			Hdr:    dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: ttl},
+			Ns:     "ns.example.com.",
+			Mbox:   "admin.example.com.",
+			Minttl: minttl,
+		}
+	}
+	ips := []netip.Addr{netip.MustParseAddr("1.1.1.1")}
+
+	t.Run("positive", func(t *testing.T) {
+		c := newCache()
+		req := query("example.com.", dns.TypeA)
+		c.put(req, reply(req, dns.RcodeSuccess, append(a("example.com.", ips, 60), a("example.com.", ips, 30)...)...))
+
+		now = now.Add(10 * time.Second)
+		req2 := query("EXAMPLE.com.", dns.TypeA)
+		got := c.get(req2)
+		assert.Equal(t, got != nil, true)
+		assert.Equal(t, got.Id, req2.Id)
+		assert.Equal(t, got.Question[0].Name, "EXAMPLE.com.")
+		assert.Equal(t, got.Answer[0].Header().Ttl, uint32(50))
+		assert.Equal(t, got.Answer[1].Header().Ttl, uint32(20))
+
+		// Expires with the lowest TTL of the answers
+		now = now.Add(20 * time.Second)
+		assert.Equal(t, c.get(req2) == nil, true)
+	})
+
+	t.Run("other type", func(t *testing.T) {
+		c := newCache()
+		req := query("example.com.", dns.TypeA)
+		c.put(req, reply(req, dns.RcodeSuccess, a("example.com.", ips, 60)...))
+		assert.Equal(t, c.get(query("example.com.", dns.TypeAAAA)) == nil, true)
+	})
+
+	t.Run("negative with SOA", func(t *testing.T) {
+		c := newCache()
+		req := query("missing.example.com.", dns.TypeA)
+		resp := reply(req, dns.RcodeNameError)
+		resp.Ns = []dns.RR{soa(300, 20)}
+		c.put(req, resp)
+
+		now = now.Add(15 * time.Second)
+		got := c.get(req)
+		assert.Equal(t, got != nil, true)
+		assert.Equal(t, got.Rcode, dns.RcodeNameError)
+		assert.Equal(t, got.Ns[0].Header().Ttl, uint32(285))
+
+		now = now.Add(5 * time.Second)
+		assert.Equal(t, c.get(req) == nil, true)
+	})
+
+	t.Run("negative without SOA", func(t *testing.T) {
+		c := newCache()
+		req := query("example.com.", dns.TypeAAAA)
+		c.put(req, reply(req, dns.RcodeSuccess))
+
+		now = now.Add(5 * time.Second)
+		assert.Equal(t, c.get(req) != nil, true)
+		now = now.Add(5 * time.Second)
+		assert.Equal(t, c.get(req) == nil, true)
+	})
+
+	t.Run("uncacheable", func(t *testing.T) {
+		c := newCache()
+		req := query("example.com.", dns.TypeA)
+		c.put(req, reply(req, dns.RcodeServerFailure))
+		assert.Equal(t, c.get(req) == nil, true)
+
+		resp := reply(req, dns.RcodeSuccess, a("example.com.", ips, 60)...)
+		resp.Truncated = true
+		c.put(req, resp)
+		assert.Equal(t, c.get(req) == nil, true)
+
+		c.put(req, reply(req, dns.RcodeSuccess, a("example.com.", ips, 0)...))
+		assert.Equal(t, c.get(req) == nil, true)
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		c := newCache()
+		for _, name := range []string{"a.example.com.", "b.example.com.", "c.example.com."} {
+			req := query(name, dns.TypeA)
+			c.put(req, reply(req, dns.RcodeSuccess, a(name, ips, 60)...))
+		}
+		assert.Equal(t, c.entries.Len(), 2)
+		assert.Equal(t, c.get(query("a.example.com.", dns.TypeA)) == nil, true)
+	})
+}
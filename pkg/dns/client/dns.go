@@ -16,6 +16,7 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/netip"
@@ -29,6 +30,7 @@ import (
 
 	"istio.io/istio/pilot/pkg/serviceregistry/provider"
 	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/protocol"
 	dnsProto "istio.io/istio/pkg/dns/proto"
 	istiolog "istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/slices"
@@ -59,6 +61,37 @@ type LocalDNSServer struct {
 
 	respondBeforeSync         bool
 	forwardToUpstreamParallel bool
+	// ttl is the TTL, in seconds, of the records served for hosts in the name table
+	ttl uint32
+	// cache holds upstream responses. It is nil if caching is disabled.
+	cache *responseCache
+	// upstreamProtocol overrides the protocol used to forward queries upstream, see Options.UpstreamProtocol
+	upstreamProtocol  string
+	upstreamTLSConfig *tls.Config
+	upstreamHealth    *upstreamHealth
+}
+
+// Options holds the tunable settings of the LocalDNSServer.
+type Options struct {
+	// ForwardToUpstreamParallel sends queries to all upstream nameservers in parallel, using the first response.
+	ForwardToUpstreamParallel bool
+	// TTL is the TTL of the records served for hosts in the name table. Defaults to 30s if unset.
+	TTL time.Duration
+	// CacheSize is the maximum number of upstream responses to cache. Caching is disabled if zero.
+	CacheSize int
+	// NegativeCacheTTL is how long NXDOMAIN and NODATA upstream responses are cached if they do not
+	// carry an SOA record to take the TTL from. Such responses are not cached if zero.
+	NegativeCacheTTL time.Duration
+	// UpstreamProtocol is the protocol used to forward queries to the upstream nameservers: UpstreamUDP,
+	// UpstreamTCP or UpstreamTLS. If unset, queries are forwarded with the protocol they were received with.
+	// With UpstreamTLS, the nameservers are queried on port 853.
+	UpstreamProtocol string
+	// UpstreamTLSServerName is the name to verify the certificates of the upstream nameservers against
+	// with UpstreamTLS.
+	UpstreamTLSServerName string
+	// UpstreamTLSCACert is the path to the CA certificates used to verify the upstream nameservers with
+	// UpstreamTLS. The system roots are used if unset.
+	UpstreamTLSCACert string
 }
 
 // LookupTable is borrowed from https://github.com/coredns/coredns/blob/master/plugin/hosts/hostsfile.go
@@ -78,19 +111,43 @@ type LookupTable struct {
 	// The cname records here (comprised of different variants of the hosts above,
 	// expanded by the search namespaces) pointing to the actual host.
 	cname map[string][]dns.RR
+	// The srv records for the named ports of the hosts above, keyed by _port._proto.host (like _http._tcp.productpage.ns1.)
+	srv map[string][]dns.RR
+	// The ptr records for the addresses of the hosts above, keyed by the reverse name (like 9.9.9.9.in-addr.arpa.)
+	ptr map[string][]dns.RR
+	// The TTL, in seconds, of all records above
+	ttl uint32
 }
 
 const (
 	// In case the client decides to honor the TTL, keep it low so that we can always serve
 	// the latest IP for a host.
-	// TODO: make it configurable
 	defaultTTLInSeconds = 30
 )
 
-func NewLocalDNSServer(proxyNamespace, proxyDomain string, addr string, forwardToUpstreamParallel bool) (*LocalDNSServer, error) {
+func NewLocalDNSServer(proxyNamespace, proxyDomain string, addr string, opts Options) (*LocalDNSServer, error) {
 	h := &LocalDNSServer{
 		proxyNamespace:            proxyNamespace,
-		forwardToUpstreamParallel: forwardToUpstreamParallel,
+		forwardToUpstreamParallel: opts.ForwardToUpstreamParallel,
+		ttl:                       defaultTTLInSeconds,
+	}
+	if opts.TTL > 0 {
+		h.ttl = uint32(opts.TTL.Seconds())
+	}
+	if opts.CacheSize > 0 {
+		h.cache = newResponseCache(opts.CacheSize, opts.NegativeCacheTTL)
+	}
+	h.upstreamHealth = newUpstreamHealth()
+	if _, err := upstreamNet(opts.UpstreamProtocol, ""); err != nil {
+		return nil, err
+	}
+	h.upstreamProtocol = opts.UpstreamProtocol
+	if h.upstreamProtocol == UpstreamTLS {
+		tlsConfig, err := upstreamTLSConfig(opts.UpstreamTLSServerName, opts.UpstreamTLSCACert)
+		if err != nil {
+			return nil, err
+		}
+		h.upstreamTLSConfig = tlsConfig
 	}
 
 	// proxyDomain could contain the namespace making it redundant.
@@ -141,6 +198,9 @@ func NewLocalDNSServer(proxyNamespace, proxyDomain string, addr string, forwardT
 		}
 		h.searchNamespaces = dnsConfig.Search
 	}
+	if h.upstreamProtocol == UpstreamTLS {
+		h.resolvConfServers = withPort(h.resolvConfServers, dotPort)
+	}
 
 	log.WithLabels("search", h.searchNamespaces, "servers", h.resolvConfServers).Debugf("initialized DNS")
 
@@ -191,8 +251,20 @@ func (h *LocalDNSServer) UpdateLookupTable(nt *dnsProto.NameTable) {
 		name4:    map[string][]dns.RR{},
 		name6:    map[string][]dns.RR{},
 		cname:    map[string][]dns.RR{},
-	}
-	h.BuildAlternateHosts(nt, lookupTable.buildDNSAnswers)
+		srv:      map[string][]dns.RR{},
+		ptr:      map[string][]dns.RR{},
+		ttl:      h.ttl,
+	}
+	h.buildAlternateHosts(nt, func(hostname string, ni *dnsProto.NameTable_NameInfo, altHosts map[string]struct{}, ipv4, ipv6 []netip.Addr) {
+		lookupTable.buildDNSAnswers(altHosts, ipv4, ipv6, h.searchNamespaces)
+		if strings.HasPrefix(hostname, "*") {
+			// Wildcard hosts cannot be the target of SRV or PTR records
+			return
+		}
+		lookupTable.buildSRVAnswers(hostname, altHosts, ni.Ports)
+		lookupTable.buildPTRAnswers(hostname, ipv4, ipv6)
+	})
+	lookupTable.sortPTRAnswers()
 	h.lookupTable.Store(lookupTable)
 	h.nameTable.Store(nt)
 	log.Debugf("updated lookup table with %d hosts", len(lookupTable.allHosts))
@@ -202,6 +274,16 @@ func (h *LocalDNSServer) UpdateLookupTable(nt *dnsProto.NameTable) {
 // calls the passed in function with the built alternate hosts.
 func (h *LocalDNSServer) BuildAlternateHosts(nt *dnsProto.NameTable,
 	apply func(map[string]struct{}, []netip.Addr, []netip.Addr, []string),
+) {
+	h.buildAlternateHosts(nt, func(_ string, _ *dnsProto.NameTable_NameInfo, altHosts map[string]struct{}, ipv4, ipv6 []netip.Addr) {
+		apply(altHosts, ipv4, ipv6, h.searchNamespaces)
+	})
+}
+
+// buildAlternateHosts is like BuildAlternateHosts, but additionally passes the fully qualified hostname
+// and the name info the alternate hosts were built from.
+func (h *LocalDNSServer) buildAlternateHosts(nt *dnsProto.NameTable,
+	apply func(string, *dnsProto.NameTable_NameInfo, map[string]struct{}, []netip.Addr, []netip.Addr),
 ) {
 	for hostname, ni := range nt.Table {
 		// Given a host
@@ -222,12 +304,21 @@ func (h *LocalDNSServer) BuildAlternateHosts(nt *dnsProto.NameTable,
 			// malformed ips
 			continue
 		}
-		apply(altHosts, ipv4, ipv6, h.searchNamespaces)
+		if !strings.HasSuffix(hostname, ".") {
+			hostname += "."
+		}
+		apply(strings.ToLower(hostname), ni, altHosts, ipv4, ipv6)
 	}
 }
 
 // upstream sends the request to the upstream server, with associated logs and metrics
 func (h *LocalDNSServer) upstream(proxy *dnsProxy, req *dns.Msg, hostname string) *dns.Msg {
+	if h.cache != nil {
+		if response := h.cache.get(req); response != nil {
+			log.Debugf("response for hostname %q found in upstream cache: %v", hostname, response)
+			return response
+		}
+	}
 	upstreamRequests.Increment()
 	start := time.Now()
 	// We did not find the host in our internal cache. Query upstream and return the response as is.
@@ -235,6 +326,9 @@ func (h *LocalDNSServer) upstream(proxy *dnsProxy, req *dns.Msg, hostname string
 	response := h.queryUpstream(proxy.upstreamClient, req, log)
 	requestDuration.Record(time.Since(start).Seconds())
 	log.Debugf("upstream response for hostname %q : %v", hostname, response)
+	if h.cache != nil {
+		h.cache.put(req, response)
+	}
 	return response
 }
 
@@ -281,7 +375,15 @@ func (h *LocalDNSServer) ServeDNS(proxy *dnsProxy, w dns.ResponseWriter, req *dn
 	// clients usually do not do more than one query either.
 	answers, hostFound := lookupTable.lookupHost(req.Question[0].Qtype, hostname)
 
-	if hostFound {
+	if !hostFound && lookupTable.isUnknownServicePort(hostname) {
+		response = new(dns.Msg)
+		response.SetReply(req)
+		// The service is known to us, but it has no such port. We are the authority for its
+		// SRV names, so the name does not exist.
+		response.Authoritative = true
+		response.Rcode = dns.RcodeNameError
+		log.Debugf("response for hostname %q (found=false): %v", hostname, response)
+	} else if hostFound {
 		response = new(dns.Msg)
 		response.SetReply(req)
 		// We are the authority here, since we control DNS for known hostnames
@@ -395,10 +497,9 @@ func (h *LocalDNSServer) queryUpstream(upstreamClient *dns.Client, req *dns.Msg,
 	}
 
 	var response *dns.Msg
-	servers := slices.Clone(h.resolvConfServers)
-	roundRobinShuffle(servers)
-	for _, upstream := range servers {
-		cResponse, _, err := upstreamClient.Exchange(req, upstream)
+	for _, upstream := range h.upstreamHealth.order(h.resolvConfServers) {
+		cResponse, rtt, err := upstreamClient.Exchange(req, upstream)
+		h.upstreamHealth.record(upstream, rtt, err)
 		if err == nil {
 			response = cResponse
 			break
@@ -432,7 +533,11 @@ func (h *LocalDNSServer) queryUpstreamParallel(upstreamClient *dns.Client, req *
 
 	queryOne := func(upstream string) {
 		// Note: After DialContext in ExchangeContext is called, this function cannot be cancelled by context.
-		cResponse, _, err := upstreamClient.ExchangeContext(ctx, req, upstream)
+		cResponse, rtt, err := upstreamClient.ExchangeContext(ctx, req, upstream)
+		if ctx.Err() == nil {
+			// Do not penalize upstreams that were cancelled because another one responded first
+			h.upstreamHealth.record(upstream, rtt, err)
+		}
 		if err == nil {
 			// Only reserve first response and ignore others.
 			select {
@@ -448,7 +553,12 @@ func (h *LocalDNSServer) queryUpstreamParallel(upstreamClient *dns.Client, req *
 		}
 	}
 
-	for _, upstream := range h.resolvConfServers {
+	// Only query the healthy upstreams, unless there are none.
+	servers := slices.Filter(h.resolvConfServers, h.upstreamHealth.healthy)
+	if len(servers) == 0 {
+		servers = h.resolvConfServers
+	}
+	for _, upstream := range servers {
 		go queryOne(upstream)
 	}
 
@@ -461,7 +571,7 @@ func (h *LocalDNSServer) queryUpstreamParallel(upstreamClient *dns.Client, req *
 		case <-errCh:
 			errorsCount++
 			// All servers returned error - return failure.
-			if errorsCount == len(h.resolvConfServers) {
+			if errorsCount == len(servers) {
 				scope.Infof("all upstream failed")
 				return serverFailure(req)
 			}
@@ -556,8 +666,11 @@ func (table *LookupTable) lookupHost(qtype uint16, hostname string) ([]dns.RR, b
 		ipAnswers = table.name4[hostname]
 	case dns.TypeAAAA:
 		ipAnswers = table.name6[hostname]
+	case dns.TypeSRV:
+		ipAnswers = table.srv[hostname]
+	case dns.TypePTR:
+		ipAnswers = table.ptr[hostname]
 	default:
-		// TODO: handle PTR records for reverse dns lookups
 		return nil, false
 	}
 
@@ -612,10 +725,10 @@ func (table *LookupTable) buildDNSAnswers(altHosts map[string]struct{}, ipv4 []n
 		h = strings.ToLower(h)
 		table.allHosts.Insert(h)
 		if len(ipv4) > 0 {
-			table.name4[h] = a(h, ipv4)
+			table.name4[h] = a(h, ipv4, table.ttl)
 		}
 		if len(ipv6) > 0 {
-			table.name6[h] = aaaa(h, ipv6)
+			table.name6[h] = aaaa(h, ipv6, table.ttl)
 		}
 		if len(searchNamespaces) > 0 {
 			// NOTE: Right now, rather than storing one expanded host for each one of the search namespace
@@ -633,20 +746,79 @@ func (table *LookupTable) buildDNSAnswers(altHosts map[string]struct{}, ipv4 []n
 			// then the expanded host productpage.ns1.svc.cluster.local is a valid hostname
 			// that is likely to be already present in the altHosts
 			if _, exists := altHosts[expandedHost]; !exists {
-				table.cname[expandedHost] = cname(expandedHost, h)
+				table.cname[expandedHost] = cname(expandedHost, h, table.ttl)
 				table.allHosts.Insert(expandedHost)
 			}
 		}
 	}
 }
 
+// isUnknownServicePort returns true if hostname has the form of an SRV name (_port._proto.host) for a known
+// host, but is not a name we have records for. This means the host does not have such a port.
+func (table *LookupTable) isUnknownServicePort(hostname string) bool {
+	labels := dns.SplitDomainName(hostname)
+	if len(labels) < 3 || !strings.HasPrefix(labels[0], "_") || (labels[1] != "_tcp" && labels[1] != "_udp") {
+		return false
+	}
+	return table.allHosts.Contains(strings.Join(labels[2:], ".") + ".")
+}
+
+// buildSRVAnswers stores SRV records for each named port of the host, for each of its alternate hosts.
+// Following the Kubernetes DNS specification, the records are named _port-name._port-protocol.host and point
+// to the fully qualified hostname.
+func (table *LookupTable) buildSRVAnswers(hostname string, altHosts map[string]struct{}, ports []*dnsProto.NameTable_Port) {
+	for _, p := range ports {
+		if p.Name == "" {
+			continue
+		}
+		proto := "_tcp."
+		if protocol.Parse(p.Protocol) == protocol.UDP {
+			proto = "_udp."
+		}
+		for h := range altHosts {
+			name := strings.ToLower("_" + p.Name + "." + proto + h)
+			table.allHosts.Insert(name)
+			table.srv[name] = append(table.srv[name], srv(name, hostname, p.Port, table.ttl))
+		}
+	}
+}
+
+// buildPTRAnswers stores PTR records pointing to the fully qualified hostname for each of its addresses.
+// An address may be shared by multiple hosts, in which case there is a record for each of them.
+func (table *LookupTable) buildPTRAnswers(hostname string, ipv4 []netip.Addr, ipv6 []netip.Addr) {
+	for _, ips := range [][]netip.Addr{ipv4, ipv6} {
+		for _, ip := range ips {
+			name, err := dns.ReverseAddr(ip.String())
+			if err != nil {
+				continue
+			}
+			if slices.FindFunc(table.ptr[name], func(rr dns.RR) bool {
+				return rr.(*dns.PTR).Ptr == hostname
+			}) != nil {
+				continue
+			}
+			table.allHosts.Insert(name)
+			table.ptr[name] = append(table.ptr[name], ptr(name, hostname, table.ttl))
+		}
+	}
+}
+
+// sortPTRAnswers orders the PTR records of each address, since they are built from an unordered name table.
+func (table *LookupTable) sortPTRAnswers() {
+	for _, answers := range table.ptr {
+		slices.SortFunc(answers, func(a, b dns.RR) int {
+			return strings.Compare(a.(*dns.PTR).Ptr, b.(*dns.PTR).Ptr)
+		})
+	}
+}
+
 // Borrowed from https://github.com/coredns/coredns/blob/master/plugin/hosts/hosts.go
 // a takes a slice of ip string and returns a slice of A RRs.
-func a(host string, ips []netip.Addr) []dns.RR {
+func a(host string, ips []netip.Addr, ttl uint32) []dns.RR {
 	answers := make([]dns.RR, len(ips))
 	for i, ip := range ips {
 		r := new(dns.A)
-		r.Hdr = dns.RR_Header{Name: host, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: defaultTTLInSeconds}
+		r.Hdr = dns.RR_Header{Name: host, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: ttl}
 		r.A = ip.AsSlice()
 		answers[i] = r
 	}
@@ -654,29 +826,57 @@ func a(host string, ips []netip.Addr) []dns.RR {
 }
 
 // aaaa takes a slice of ip string and returns a slice of AAAA RRs.
-func aaaa(host string, ips []netip.Addr) []dns.RR {
+func aaaa(host string, ips []netip.Addr, ttl uint32) []dns.RR {
 	answers := make([]dns.RR, len(ips))
 	for i, ip := range ips {
 		r := new(dns.AAAA)
-		r.Hdr = dns.RR_Header{Name: host, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: defaultTTLInSeconds}
+		r.Hdr = dns.RR_Header{Name: host, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: ttl}
 		r.AAAA = ip.AsSlice()
 		answers[i] = r
 	}
 	return answers
 }
 
-func cname(host string, targetHost string) []dns.RR {
+func cname(host string, targetHost string, ttl uint32) []dns.RR {
 	answer := new(dns.CNAME)
 	answer.Hdr = dns.RR_Header{
 		Name:   host,
 		Rrtype: dns.TypeCNAME,
 		Class:  dns.ClassINET,
-		Ttl:    defaultTTLInSeconds,
+		Ttl:    ttl,
 	}
 	answer.Target = targetHost
 	return []dns.RR{answer}
 }
 
+func srv(name string, targetHost string, port uint32, ttl uint32) dns.RR {
+	answer := new(dns.SRV)
+	answer.Hdr = dns.RR_Header{
+		Name:   name,
+		Rrtype: dns.TypeSRV,
+		Class:  dns.ClassINET,
+		Ttl:    ttl,
+	}
+	// Matches Kubernetes DNS, which uses equal priority and weight for every record
+	answer.Priority = 0
+	answer.Weight = 100
+	answer.Port = uint16(port)
+	answer.Target = targetHost
+	return answer
+}
+
+func ptr(name string, targetHost string, ttl uint32) dns.RR {
+	answer := new(dns.PTR)
+	answer.Hdr = dns.RR_Header{
+		Name:   name,
+		Rrtype: dns.TypePTR,
+		Class:  dns.ClassINET,
+		Ttl:    ttl,
+	}
+	answer.Ptr = targetHost
+	return answer
+}
+
 // Size returns if buffer size *advertised* in the requests OPT record.
 // Or when the request was over TCP, we return the maximum allowed size of 64K.
 func size(proto string, r *dns.Msg) int {
@@ -43,7 +43,7 @@ func TestDNS(t *testing.T) {
 
 func TestBuildAlternateHosts(t *testing.T) {
 	// Create the server instance without starting it, as it's unnecessary for this test
-	d, err := NewLocalDNSServer("ns1", "ns1.svc.cluster.local", "localhost:0", false)
+	d, err := NewLocalDNSServer("ns1", "ns1.svc.cluster.local", "localhost:0", Options{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -131,6 +131,7 @@ func testDNS(t *testing.T, d *LocalDNSServer) {
 		host                     string
 		id                       int
 		queryAAAA                bool
+		queryType                uint16
 		expected                 []dns.RR
 		expectResolutionFailure  int
 		expectExternalResolution bool
@@ -139,13 +140,13 @@ func testDNS(t *testing.T, d *LocalDNSServer) {
 		{
 			name:     "success: non k8s host in local cache",
 			host:     "www.google.com.",
-			expected: a("www.google.com.", []netip.Addr{netip.MustParseAddr("1.1.1.1")}),
+			expected: a("www.google.com.", []netip.Addr{netip.MustParseAddr("1.1.1.1")}, defaultTTLInSeconds),
 		},
 		{
 			name: "success: non k8s host with search namespace yields cname+A record",
 			host: "www.google.com.ns1.svc.cluster.local.",
-			expected: append(cname("www.google.com.ns1.svc.cluster.local.", "www.google.com."),
-				a("www.google.com.", []netip.Addr{netip.MustParseAddr("1.1.1.1")})...),
+			expected: append(cname("www.google.com.ns1.svc.cluster.local.", "www.google.com.", defaultTTLInSeconds),
+				a("www.google.com.", []netip.Addr{netip.MustParseAddr("1.1.1.1")}, defaultTTLInSeconds)...),
 		},
 		{
 			name:                     "success: non k8s host not in local cache",
@@ -155,23 +156,23 @@ func testDNS(t *testing.T, d *LocalDNSServer) {
 		{
 			name:     "success: k8s host - fqdn",
 			host:     "productpage.ns1.svc.cluster.local.",
-			expected: a("productpage.ns1.svc.cluster.local.", []netip.Addr{netip.MustParseAddr("9.9.9.9")}),
+			expected: a("productpage.ns1.svc.cluster.local.", []netip.Addr{netip.MustParseAddr("9.9.9.9")}, defaultTTLInSeconds),
 		},
 		{
 			name:     "success: k8s host - name.namespace",
 			host:     "productpage.ns1.",
-			expected: a("productpage.ns1.", []netip.Addr{netip.MustParseAddr("9.9.9.9")}),
+			expected: a("productpage.ns1.", []netip.Addr{netip.MustParseAddr("9.9.9.9")}, defaultTTLInSeconds),
 		},
 		{
 			name:     "success: k8s host - shortname",
 			host:     "productpage.",
-			expected: a("productpage.", []netip.Addr{netip.MustParseAddr("9.9.9.9")}),
+			expected: a("productpage.", []netip.Addr{netip.MustParseAddr("9.9.9.9")}, defaultTTLInSeconds),
 		},
 		{
 			name: "success: k8s host (name.namespace) with search namespace yields cname+A record",
 			host: "productpage.ns1.ns1.svc.cluster.local.",
-			expected: append(cname("productpage.ns1.ns1.svc.cluster.local.", "productpage.ns1."),
-				a("productpage.ns1.", []netip.Addr{netip.MustParseAddr("9.9.9.9")})...),
+			expected: append(cname("productpage.ns1.ns1.svc.cluster.local.", "productpage.ns1.", defaultTTLInSeconds),
+				a("productpage.ns1.", []netip.Addr{netip.MustParseAddr("9.9.9.9")}, defaultTTLInSeconds)...),
 		},
 		{
 			name:      "success: AAAA query for IPv4 k8s host (name.namespace) with search namespace",
@@ -181,17 +182,17 @@ func testDNS(t *testing.T, d *LocalDNSServer) {
 		{
 			name:     "success: k8s host - non local namespace - name.namespace",
 			host:     "example.ns2.",
-			expected: a("example.ns2.", []netip.Addr{netip.MustParseAddr("10.10.10.10")}),
+			expected: a("example.ns2.", []netip.Addr{netip.MustParseAddr("10.10.10.10")}, defaultTTLInSeconds),
 		},
 		{
 			name:     "success: k8s host - non local namespace - fqdn",
 			host:     "example.ns2.svc.cluster.local.",
-			expected: a("example.ns2.svc.cluster.local.", []netip.Addr{netip.MustParseAddr("10.10.10.10")}),
+			expected: a("example.ns2.svc.cluster.local.", []netip.Addr{netip.MustParseAddr("10.10.10.10")}, defaultTTLInSeconds),
 		},
 		{
 			name:     "success: k8s host - non local namespace - name.namespace.svc",
 			host:     "example.ns2.svc.",
-			expected: a("example.ns2.svc.", []netip.Addr{netip.MustParseAddr("10.10.10.10")}),
+			expected: a("example.ns2.svc.", []netip.Addr{netip.MustParseAddr("10.10.10.10")}, defaultTTLInSeconds),
 		},
 		{
 			name:                    "failure: k8s host - non local namespace - shortname",
@@ -201,27 +202,27 @@ func testDNS(t *testing.T, d *LocalDNSServer) {
 		{
 			name:     "success: alt host - name",
 			host:     "svc-with-alt.",
-			expected: a("svc-with-alt.", []netip.Addr{netip.MustParseAddr("15.15.15.15")}),
+			expected: a("svc-with-alt.", []netip.Addr{netip.MustParseAddr("15.15.15.15")}, defaultTTLInSeconds),
 		},
 		{
 			name:     "success: alt host - name.namespace",
 			host:     "svc-with-alt.ns1.",
-			expected: a("svc-with-alt.ns1.", []netip.Addr{netip.MustParseAddr("15.15.15.15")}),
+			expected: a("svc-with-alt.ns1.", []netip.Addr{netip.MustParseAddr("15.15.15.15")}, defaultTTLInSeconds),
 		},
 		{
 			name:     "success: alt host - name.namespace.svc",
 			host:     "svc-with-alt.ns1.svc.",
-			expected: a("svc-with-alt.ns1.svc.", []netip.Addr{netip.MustParseAddr("15.15.15.15")}),
+			expected: a("svc-with-alt.ns1.svc.", []netip.Addr{netip.MustParseAddr("15.15.15.15")}, defaultTTLInSeconds),
 		},
 		{
 			name:     "success: alt host - name.namespace.svc.cluster.local",
 			host:     "svc-with-alt.ns1.svc.cluster.local.",
-			expected: a("svc-with-alt.ns1.svc.cluster.local.", []netip.Addr{netip.MustParseAddr("15.15.15.15")}),
+			expected: a("svc-with-alt.ns1.svc.cluster.local.", []netip.Addr{netip.MustParseAddr("15.15.15.15")}, defaultTTLInSeconds),
 		},
 		{
 			name:     "success: alt host - name.namespace.svc.clusterset.local",
 			host:     "svc-with-alt.ns1.svc.clusterset.local.",
-			expected: a("svc-with-alt.ns1.svc.clusterset.local.", []netip.Addr{netip.MustParseAddr("15.15.15.15")}),
+			expected: a("svc-with-alt.ns1.svc.clusterset.local.", []netip.Addr{netip.MustParseAddr("15.15.15.15")}, defaultTTLInSeconds),
 		},
 		{
 			name: "success: remote cluster k8s svc - same ns and different domain - fqdn",
@@ -233,7 +234,7 @@ func testDNS(t *testing.T, d *LocalDNSServer) {
 					netip.MustParseAddr("14.14.14.14"),
 					netip.MustParseAddr("12.12.12.12"),
 					netip.MustParseAddr("11.11.11.11"),
-				}),
+				}, defaultTTLInSeconds),
 		},
 		{
 			name: "success: remote cluster k8s svc round robin",
@@ -245,7 +246,7 @@ func testDNS(t *testing.T, d *LocalDNSServer) {
 					netip.MustParseAddr("14.14.14.14"),
 					netip.MustParseAddr("11.11.11.11"),
 					netip.MustParseAddr("12.12.12.12"),
-				}),
+				}, defaultTTLInSeconds),
 		},
 		{
 			name:                    "failure: remote cluster k8s svc - same ns and different domain - name.namespace",
@@ -255,45 +256,45 @@ func testDNS(t *testing.T, d *LocalDNSServer) {
 		{
 			name:     "success: TypeA query returns A records only",
 			host:     "dual.localhost.",
-			expected: a("dual.localhost.", []netip.Addr{netip.MustParseAddr("2.2.2.2")}),
+			expected: a("dual.localhost.", []netip.Addr{netip.MustParseAddr("2.2.2.2")}, defaultTTLInSeconds),
 		},
 		{
 			name:     "success: wild card returns A record correctly",
 			host:     "foo.wildcard.",
-			expected: a("foo.wildcard.", []netip.Addr{netip.MustParseAddr("10.10.10.10")}),
+			expected: a("foo.wildcard.", []netip.Addr{netip.MustParseAddr("10.10.10.10")}, defaultTTLInSeconds),
 		},
 		{
 			name:     "success: specific wild card returns A record correctly",
 			host:     "a.b.wildcard.",
-			expected: a("a.b.wildcard.", []netip.Addr{netip.MustParseAddr("11.11.11.11")}),
+			expected: a("a.b.wildcard.", []netip.Addr{netip.MustParseAddr("11.11.11.11")}, defaultTTLInSeconds),
 		},
 		{
 			name: "success: wild card with with search namespace chained pointer correctly",
 			host: "foo.wildcard.ns1.svc.cluster.local.",
-			expected: append(cname("foo.wildcard.ns1.svc.cluster.local.", "*.wildcard."),
-				a("*.wildcard.", []netip.Addr{netip.MustParseAddr("10.10.10.10")})...),
+			expected: append(cname("foo.wildcard.ns1.svc.cluster.local.", "*.wildcard.", defaultTTLInSeconds),
+				a("*.wildcard.", []netip.Addr{netip.MustParseAddr("10.10.10.10")}, defaultTTLInSeconds)...),
 		},
 		{
 			name:     "success: wild card with domain returns A record correctly",
 			host:     "foo.svc.mesh.company.net.",
-			expected: a("foo.svc.mesh.company.net.", []netip.Addr{netip.MustParseAddr("10.1.2.3")}),
+			expected: a("foo.svc.mesh.company.net.", []netip.Addr{netip.MustParseAddr("10.1.2.3")}, defaultTTLInSeconds),
 		},
 		{
 			name:     "success: wild card with namespace with domain returns A record correctly",
 			host:     "foo.foons.svc.mesh.company.net.",
-			expected: a("foo.foons.svc.mesh.company.net.", []netip.Addr{netip.MustParseAddr("10.1.2.3")}),
+			expected: a("foo.foons.svc.mesh.company.net.", []netip.Addr{netip.MustParseAddr("10.1.2.3")}, defaultTTLInSeconds),
 		},
 		{
 			name: "success: wild card with search domain returns A record correctly",
 			host: "foo.svc.mesh.company.net.ns1.svc.cluster.local.",
-			expected: append(cname("foo.svc.mesh.company.net.ns1.svc.cluster.local.", "*.svc.mesh.company.net."),
-				a("*.svc.mesh.company.net.", []netip.Addr{netip.MustParseAddr("10.1.2.3")})...),
+			expected: append(cname("foo.svc.mesh.company.net.ns1.svc.cluster.local.", "*.svc.mesh.company.net.", defaultTTLInSeconds),
+				a("*.svc.mesh.company.net.", []netip.Addr{netip.MustParseAddr("10.1.2.3")}, defaultTTLInSeconds)...),
 		},
 		{
 			name:      "success: TypeAAAA query returns AAAA records only",
 			host:      "dual.localhost.",
 			queryAAAA: true,
-			expected:  aaaa("dual.localhost.", []netip.Addr{netip.MustParseAddr("2001:db8:0:0:0:ff00:42:8329")}),
+			expected:  aaaa("dual.localhost.", []netip.Addr{netip.MustParseAddr("2001:db8:0:0:0:ff00:42:8329")}, defaultTTLInSeconds),
 		},
 		{
 			// This is not a NXDOMAIN, but empty response
@@ -332,10 +333,66 @@ func testDNS(t *testing.T, d *LocalDNSServer) {
 			expectResolutionFailure: dns.RcodeSuccess,
 			expected:                giantResponse[:29],
 		},
+		{
+			name:      "success: SRV query for named port - fqdn",
+			host:      "_http._tcp.productpage.ns1.svc.cluster.local.",
+			queryType: dns.TypeSRV,
+			expected: []dns.RR{
+				srv("_http._tcp.productpage.ns1.svc.cluster.local.", "productpage.ns1.svc.cluster.local.", 9080, defaultTTLInSeconds),
+			},
+		},
+		{
+			name:      "success: SRV query for named port - shortname",
+			host:      "_grpc._tcp.productpage.",
+			queryType: dns.TypeSRV,
+			expected:  []dns.RR{srv("_grpc._tcp.productpage.", "productpage.ns1.svc.cluster.local.", 9090, defaultTTLInSeconds)},
+		},
+		{
+			name:      "success: SRV query for udp port",
+			host:      "_dns._udp.productpage.ns1.",
+			queryType: dns.TypeSRV,
+			expected:  []dns.RR{srv("_dns._udp.productpage.ns1.", "productpage.ns1.svc.cluster.local.", 53, defaultTTLInSeconds)},
+		},
+		{
+			name:                    "failure: SRV query for unknown port of known host",
+			host:                    "_unknown._tcp.productpage.ns1.svc.cluster.local.",
+			queryType:               dns.TypeSRV,
+			expectResolutionFailure: dns.RcodeNameError,
+		},
+		{
+			// This is not a NXDOMAIN, but empty response
+			name:      "success: SRV query for known host",
+			host:      "productpage.ns1.svc.cluster.local.",
+			queryType: dns.TypeSRV,
+		},
+		{
+			name:      "success: PTR query for known address",
+			host:      "9.9.9.9.in-addr.arpa.",
+			queryType: dns.TypePTR,
+			expected:  []dns.RR{ptr("9.9.9.9.in-addr.arpa.", "productpage.ns1.svc.cluster.local.", defaultTTLInSeconds)},
+		},
+		{
+			name:      "success: PTR query for address shared by multiple hosts",
+			host:      "2.2.2.2.in-addr.arpa.",
+			queryType: dns.TypePTR,
+			expected: []dns.RR{
+				ptr("2.2.2.2.in-addr.arpa.", "dual.localhost.", defaultTTLInSeconds),
+				ptr("2.2.2.2.in-addr.arpa.", "ipv4.localhost.", defaultTTLInSeconds),
+			},
+		},
+		{
+			name:      "success: PTR query for known ipv6 address",
+			host:      "9.2.3.8.2.4.0.0.0.0.f.f.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.",
+			queryType: dns.TypePTR,
+			expected: []dns.RR{
+				ptr("9.2.3.8.2.4.0.0.0.0.f.f.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.", "dual.localhost.", defaultTTLInSeconds),
+				ptr("9.2.3.8.2.4.0.0.0.0.f.f.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.", "ipv6.localhost.", defaultTTLInSeconds),
+			},
+		},
 		{
 			name:     "success: hostname with a period",
 			host:     "example.localhost.",
-			expected: a("example.localhost.", []netip.Addr{netip.MustParseAddr("3.3.3.3")}),
+			expected: a("example.localhost.", []netip.Addr{netip.MustParseAddr("3.3.3.3")}, defaultTTLInSeconds),
 		},
 	}
 
@@ -373,6 +430,9 @@ func testDNS(t *testing.T, d *LocalDNSServer) {
 				if tt.queryAAAA {
 					q = dns.TypeAAAA
 				}
+				if tt.queryType != 0 {
+					q = tt.queryType
+				}
 				m.SetQuestion(tt.host, q)
 				if tt.modifyReq != nil {
 					tt.modifyReq(m)
@@ -488,7 +548,7 @@ var giantResponse = func() []dns.RR {
 	for i := 0; i < 64; i++ {
 		ips = append(ips, netip.MustParseAddr(fmt.Sprintf("240.0.0.%d", i)))
 	}
-	return a("aaaaaaaaaaaa.aaaaaa.", ips)
+	return a("aaaaaaaaaaaa.aaaaaa.", ips, defaultTTLInSeconds)
 }()
 
 func makeUpstream(t test.Failer, responses map[string]string) string {
@@ -504,7 +564,7 @@ func makeUpstream(t test.Failer, responses map[string]string) string {
 	for hn, desiredResp := range responses {
 		mux.HandleFunc(hn, func(resp dns.ResponseWriter, msg *dns.Msg) {
 			answer := dns.Msg{
-				Answer: a(hn, []netip.Addr{netip.MustParseAddr(desiredResp)}),
+				Answer: a(hn, []netip.Addr{netip.MustParseAddr(desiredResp)}, defaultTTLInSeconds),
 			}
 			answer.SetReply(msg)
 			answer.Rcode = dns.RcodeSuccess
@@ -589,7 +649,7 @@ func makeUpstream(t test.Failer, responses map[string]string) string {
 
 func initDNS(t test.Failer, forwardToUpstreamParallel bool) *LocalDNSServer {
 	srv := makeUpstream(t, map[string]string{"www.bing.com.": "1.1.1.1"})
-	testAgentDNS, err := NewLocalDNSServer("ns1", "ns1.svc.cluster.local", "localhost:0", forwardToUpstreamParallel)
+	testAgentDNS, err := NewLocalDNSServer("ns1", "ns1.svc.cluster.local", "localhost:0", Options{ForwardToUpstreamParallel: forwardToUpstreamParallel})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -613,6 +673,11 @@ func fillTable(server *LocalDNSServer) {
 				Registry:  "Kubernetes",
 				Namespace: "ns1",
 				Shortname: "productpage",
+				Ports: []*dnsProto.NameTable_Port{
+					{Name: "http", Port: 9080, Protocol: "HTTP"},
+					{Name: "grpc", Port: 9090, Protocol: "GRPC"},
+					{Name: "dns", Port: 53, Protocol: "UDP"},
+				},
 			},
 			"example.ns2.svc.cluster.local": {
 				Ips:       []string{"10.10.10.10"},
@@ -668,7 +733,7 @@ func fillTable(server *LocalDNSServer) {
 }
 
 // reflect.DeepEqual doesn't seem to work well for dns.RR
-// as the Rdlength field is not updated in the a(), or aaaa() calls.
+// as the Rdlength field is not updated in the a() or aaaa() calls.
 // so zero them out before doing reflect.Deepequal
 func equalsDNSrecords(got []dns.RR, want []dns.RR) bool {
 	for i := range got {
@@ -19,6 +19,8 @@ import (
 )
 
 var (
+	upstreamServerTag = monitoring.CreateLabel("upstream")
+
 	requests = monitoring.NewSum(
 		"dns_requests_total",
 		"Total number of DNS requests.",
@@ -34,9 +36,35 @@ var (
 		"Total number of DNS failures.",
 	)
 
+	cacheHits = monitoring.NewSum(
+		"dns_upstream_cache_hits_total",
+		"Total number of DNS requests for upstream hosts answered from the cache.",
+	)
+
+	cacheMisses = monitoring.NewSum(
+		"dns_upstream_cache_misses_total",
+		"Total number of DNS requests for upstream hosts not found in the cache.",
+	)
+
 	requestDuration = monitoring.NewDistribution(
 		"dns_upstream_request_duration_seconds",
 		"Total time in seconds Istio takes to get DNS response from upstream.",
 		[]float64{.001, .005, 0.01, 0.1, 1, 5},
 	)
+
+	upstreamServerRequestDuration = monitoring.NewDistribution(
+		"dns_upstream_server_request_duration_seconds",
+		"Time in seconds an upstream nameserver takes to respond to successful DNS requests, by upstream.",
+		[]float64{.001, .005, 0.01, 0.1, 1, 5},
+	)
+
+	upstreamServerFailures = monitoring.NewSum(
+		"dns_upstream_server_failures_total",
+		"Total number of DNS requests an upstream nameserver failed to respond to, by upstream.",
+	)
+
+	upstreamServerHealthy = monitoring.NewGauge(
+		"dns_upstream_server_healthy",
+		"Whether the upstream nameserver is considered healthy (1) or not (0), by upstream.",
+	)
 )
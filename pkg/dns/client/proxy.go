@@ -33,11 +33,16 @@ type dnsProxy struct {
 }
 
 func newDNSProxy(protocol, addr string, resolver *LocalDNSServer) (*dnsProxy, error) {
+	network, err := upstreamNet(resolver.upstreamProtocol, protocol)
+	if err != nil {
+		return nil, err
+	}
 	p := &dnsProxy{
 		serveMux: dns.NewServeMux(),
 		server:   &dns.Server{},
 		upstreamClient: &dns.Client{
-			Net:          protocol,
+			Net:          network,
+			TLSConfig:    resolver.upstreamTLSConfig,
 			DialTimeout:  5 * time.Second,
 			ReadTimeout:  5 * time.Second,
 			WriteTimeout: 5 * time.Second,
@@ -46,7 +51,6 @@ func newDNSProxy(protocol, addr string, resolver *LocalDNSServer) (*dnsProxy, er
 		resolver: resolver,
 	}
 
-	var err error
 	p.serveMux.Handle(".", p)
 	p.server.Handler = p.serveMux
 	if protocol == "udp" {
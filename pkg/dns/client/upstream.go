@@ -0,0 +1,176 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/slices"
+)
+
+const (
+	// UpstreamUDP forwards queries to upstream nameservers over UDP.
+	UpstreamUDP = "udp"
+	// UpstreamTCP forwards queries to upstream nameservers over TCP.
+	UpstreamTCP = "tcp"
+	// UpstreamTLS forwards queries to upstream nameservers over DNS-over-TLS (RFC 7858).
+	UpstreamTLS = "tls"
+
+	// dotPort is the well known port for DNS-over-TLS
+	dotPort = "853"
+
+	// unhealthyThreshold is the number of consecutive failures after which an upstream is considered unhealthy.
+	unhealthyThreshold = 3
+	// unhealthyInterval is how long an unhealthy upstream is only used as a last resort. After that, it is tried
+	// as if it was healthy again; a single failure marks it unhealthy for another interval.
+	unhealthyInterval = 30 * time.Second
+)
+
+// upstreamNet returns the network of the dns.Client used to forward queries with the given upstream protocol,
+// for queries received over downstreamNet.
+func upstreamNet(upstreamProtocol, downstreamNet string) (string, error) {
+	switch upstreamProtocol {
+	case "":
+		return downstreamNet, nil
+	case UpstreamUDP, UpstreamTCP:
+		return upstreamProtocol, nil
+	case UpstreamTLS:
+		return "tcp-tls", nil
+	default:
+		return "", fmt.Errorf("unknown upstream protocol %q, must be one of %q, %q or %q",
+			upstreamProtocol, UpstreamUDP, UpstreamTCP, UpstreamTLS)
+	}
+}
+
+// upstreamTLSConfig builds the configuration used to verify upstream nameservers with DNS-over-TLS.
+func upstreamTLSConfig(serverName, caCertFile string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if caCertFile != "" {
+		caCert, err := os.ReadFile(caCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read upstream CA certificate: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to parse upstream CA certificate %s", caCertFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// withPort replaces the port of the upstream nameserver addresses.
+func withPort(servers []string, port string) []string {
+	return slices.Map(servers, func(s string) string {
+		host, _, err := net.SplitHostPort(s)
+		if err != nil {
+			return s
+		}
+		return net.JoinHostPort(host, port)
+	})
+}
+
+type upstreamState struct {
+	consecutiveFailures int
+	unhealthyUntil      time.Time
+}
+
+// upstreamHealth tracks the health of upstream nameservers, based on the outcome of the queries sent to them.
+// An upstream failing unhealthyThreshold queries in a row is considered unhealthy for unhealthyInterval.
+type upstreamHealth struct {
+	mu       sync.Mutex
+	upstream map[string]*upstreamState
+	// now is overridden in tests
+	now func() time.Time
+}
+
+func newUpstreamHealth() *upstreamHealth {
+	return &upstreamHealth{
+		upstream: map[string]*upstreamState{},
+		now:      time.Now,
+	}
+}
+
+// healthy returns whether the upstream nameserver is considered healthy.
+func (u *upstreamHealth) healthy(server string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.healthyLocked(server, u.now())
+}
+
+func (u *upstreamHealth) healthyLocked(server string, now time.Time) bool {
+	s := u.upstream[server]
+	return s == nil || !now.Before(s.unhealthyUntil)
+}
+
+// order returns the upstream nameservers in the order they should be queried. Healthy upstreams come first,
+// in a random order to spread the load, followed by the unhealthy ones, which are only used as a last resort.
+func (u *upstreamHealth) order(servers []string) []string {
+	servers = slices.Clone(servers)
+	roundRobinShuffle(servers)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	now := u.now()
+	slices.SortStableFunc(servers, func(a, b string) int {
+		ha, hb := u.healthyLocked(a, now), u.healthyLocked(b, now)
+		switch {
+		case ha == hb:
+			return 0
+		case ha:
+			return -1
+		default:
+			return 1
+		}
+	})
+	return servers
+}
+
+// record updates the health of the upstream nameserver with the outcome of a query, and records its metrics.
+func (u *upstreamHealth) record(server string, latency time.Duration, err error) {
+	label := upstreamServerTag.Value(server)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	s := u.upstream[server]
+	if s == nil {
+		s = &upstreamState{}
+		u.upstream[server] = s
+	}
+	if err == nil {
+		upstreamServerRequestDuration.With(label).Record(latency.Seconds())
+		s.consecutiveFailures = 0
+		s.unhealthyUntil = time.Time{}
+		upstreamServerHealthy.With(label).Record(1)
+		return
+	}
+	upstreamServerFailures.With(label).Increment()
+	s.consecutiveFailures++
+	if s.consecutiveFailures >= unhealthyThreshold {
+		now := u.now()
+		if u.healthyLocked(server, now) {
+			log.Warnf("upstream nameserver %s failed %d queries in a row, marking it unhealthy: %v", server, s.consecutiveFailures, err)
+		}
+		s.unhealthyUntil = now.Add(unhealthyInterval)
+		upstreamServerHealthy.With(label).Record(0)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/testcerts"
+)
+
+func TestUpstreamHealth(t *testing.T) {
+	now := time.Now()
+	u := newUpstreamHealth()
+	u.now = func() time.Time { return now }
+	servers := []string{"1.1.1.1:53", "2.2.2.2:53"}
+	errTimeout := errors.New("timeout")
+
+	for i := 0; i < unhealthyThreshold-1; i++ {
+		u.record("1.1.1.1:53", 0, errTimeout)
+	}
+	assert.Equal(t, u.healthy("1.1.1.1:53"), true)
+
+	u.record("1.1.1.1:53", 0, errTimeout)
+	assert.Equal(t, u.healthy("1.1.1.1:53"), false)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, u.order(servers), []string{"2.2.2.2:53", "1.1.1.1:53"})
+	}
+
+	// After the interval, the upstream is tried again, but a single failure marks it unhealthy again
+	now = now.Add(unhealthyInterval)
+	assert.Equal(t, u.healthy("1.1.1.1:53"), true)
+	u.record("1.1.1.1:53", 0, errTimeout)
+	assert.Equal(t, u.healthy("1.1.1.1:53"), false)
+
+	// A success resets it
+	now = now.Add(unhealthyInterval)
+	u.record("1.1.1.1:53", time.Millisecond, nil)
+	u.record("1.1.1.1:53", 0, errTimeout)
+	assert.Equal(t, u.healthy("1.1.1.1:53"), true)
+}
+
+func TestUpstreamNet(t *testing.T) {
+	cases := []struct {
+		upstream   string
+		downstream string
+		want       string
+	}{
+		{"", "udp", "udp"},
+		{"", "tcp", "tcp"},
+		{UpstreamTCP, "udp", "tcp"},
+		{UpstreamUDP, "tcp", "udp"},
+		{UpstreamTLS, "udp", "tcp-tls"},
+	}
+	for _, tt := range cases {
+		got, err := upstreamNet(tt.upstream, tt.downstream)
+		assert.NoError(t, err)
+		assert.Equal(t, got, tt.want)
+	}
+	_, err := upstreamNet("https", "udp")
+	assert.Error(t, err)
+}
+
+func TestUpstreamFailover(t *testing.T) {
+	live := makeUpstream(t, map[string]string{"www.bing.com.": "1.1.1.1"})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	dead := l.Addr().String()
+	_ = l.Close()
+
+	d, err := NewLocalDNSServer("ns1", "ns1.svc.cluster.local", "localhost:0", Options{UpstreamProtocol: UpstreamTCP})
+	assert.NoError(t, err)
+	d.resolvConfServers = []string{dead, live}
+	d.StartDNS()
+	fillTable(d)
+	t.Cleanup(d.Close)
+
+	client := &dns.Client{Net: "udp", Timeout: 3 * time.Second}
+	for i := 0; i < 30; i++ {
+		m := new(dns.Msg)
+		m.SetQuestion("www.bing.com.", dns.TypeA)
+		res, _, err := client.Exchange(m, d.dnsProxies[0].Address())
+		assert.NoError(t, err)
+		assert.Equal(t, res.Rcode, dns.RcodeSuccess)
+	}
+	assert.Equal(t, d.upstreamHealth.healthy(dead), false)
+	assert.Equal(t, d.upstreamHealth.healthy(live), true)
+}
+
+func TestDNSOverTLS(t *testing.T) {
+	cert, err := tls.X509KeyPair(testcerts.ServerCert, testcerts.ServerKey)
+	assert.NoError(t, err)
+	mux := dns.NewServeMux()
+	mux.HandleFunc("www.bing.com.", func(w dns.ResponseWriter, m *dns.Msg) {
+		res := new(dns.Msg)
+		res.SetReply(m)
+		res.Answer = a("www.bing.com.", []netip.Addr{netip.MustParseAddr("1.1.1.1")}, defaultTTLInSeconds)
+		_ = w.WriteMsg(res)
+	})
+	up := make(chan struct{})
+	server := &dns.Server{
+		Addr:              "127.0.0.1:0",
+		Net:               "tcp-tls",
+		TLSConfig:         &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
+		Handler:           mux,
+		NotifyStartedFunc: func() { close(up) },
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil {
+			log.Warnf("listen error: %v", err)
+		}
+	}()
+	select {
+	case <-time.After(time.Second * 10):
+		t.Fatal("setup timeout")
+	case <-up:
+	}
+	t.Cleanup(func() { _ = server.Shutdown() })
+
+	caCert := filepath.Join(t.TempDir(), "ca.pem")
+	assert.NoError(t, os.WriteFile(caCert, testcerts.CACert, 0o644))
+	d, err := NewLocalDNSServer("ns1", "ns1.svc.cluster.local", "localhost:0", Options{
+		UpstreamProtocol:  UpstreamTLS,
+		UpstreamTLSCACert: caCert,
+	})
+	assert.NoError(t, err)
+	// The server certificate is issued for 127.0.0.1, which is verified when no server name is set
+	d.resolvConfServers = []string{server.Listener.Addr().String()}
+	d.StartDNS()
+	fillTable(d)
+	t.Cleanup(d.Close)
+
+	m := new(dns.Msg)
+	m.SetQuestion("www.bing.com.", dns.TypeA)
+	res, _, err := (&dns.Client{Net: "udp"}).Exchange(m, d.dnsProxies[0].Address())
+	assert.NoError(t, err)
+	assert.Equal(t, res.Rcode, dns.RcodeSuccess)
+	assert.Equal(t, len(res.Answer), 1)
+	assert.Equal(t, res.Answer[0].(*dns.A).A.String(), "1.1.1.1")
+}
@@ -131,6 +131,7 @@ func BuildNameTable(cfg Config) *dnsProto.NameTable {
 				nameInfo := &dnsProto.NameTable_NameInfo{
 					Ips:      addressList,
 					Registry: string(svc.Attributes.ServiceRegistry),
+					Ports:    buildPorts(svc),
 				}
 				if svc.Attributes.ServiceRegistry == provider.Kubernetes &&
 					!strings.HasSuffix(hostName.String(), "."+constants.DefaultClusterSetLocalDomain) {
@@ -150,6 +151,7 @@ func BuildNameTable(cfg Config) *dnsProto.NameTable {
 				if svc.Attributes.ServiceRegistry == provider.Kubernetes {
 					ni.Ips = addressList
 					ni.Registry = string(provider.Kubernetes)
+					ni.Ports = buildPorts(svc)
 					if !strings.HasSuffix(hostName.String(), "."+constants.DefaultClusterSetLocalDomain) {
 						ni.Namespace = svc.Attributes.Namespace
 						ni.Shortname = svc.Attributes.Name
@@ -162,3 +164,19 @@ func BuildNameTable(cfg Config) *dnsProto.NameTable {
 	}
 	return out
 }
+
+// buildPorts returns the named ports of the service, which the agent uses to answer SRV queries.
+func buildPorts(svc *model.Service) []*dnsProto.NameTable_Port {
+	var ports []*dnsProto.NameTable_Port
+	for _, p := range svc.Ports {
+		if p.Name == "" {
+			continue
+		}
+		ports = append(ports, &dnsProto.NameTable_Port{
+			Name:     p.Name,
+			Port:     uint32(p.Port),
+			Protocol: string(p.Protocol),
+		})
+	}
+	return ports
+}
@@ -230,6 +230,13 @@ func TestNameTable(t *testing.T) {
 		},
 	}
 
+	headlessPorts := []*dnsProto.NameTable_Port{{Name: "tcp-port", Port: 9000, Protocol: "TCP"}}
+	wildcardPorts := []*dnsProto.NameTable_Port{
+		{Name: "tcp-port", Port: 9000, Protocol: "TCP"},
+		{Name: "http-port", Port: 8000, Protocol: "HTTP"},
+	}
+	mysqlPorts := []*dnsProto.NameTable_Port{{Name: "tcp", Port: 3306, Protocol: "TCP"}}
+
 	push := model.NewPushContext()
 	push.Mesh = mesh
 	push.AddPublicServices([]*model.Service{headlessService})
@@ -324,6 +331,7 @@ func TestNameTable(t *testing.T) {
 						Registry:  "Kubernetes",
 						Shortname: "headless-svc",
 						Namespace: "testns",
+						Ports:     headlessPorts,
 					},
 				},
 			},
@@ -357,6 +365,7 @@ func TestNameTable(t *testing.T) {
 						Registry:  "Kubernetes",
 						Shortname: "headless-svc",
 						Namespace: "testns",
+						Ports:     headlessPorts,
 					},
 				},
 			},
@@ -396,6 +405,7 @@ func TestNameTable(t *testing.T) {
 						Registry:  "Kubernetes",
 						Shortname: "headless-svc",
 						Namespace: "testns",
+						Ports:     headlessPorts,
 					},
 				},
 			},
@@ -436,6 +446,7 @@ func TestNameTable(t *testing.T) {
 						Registry:  "Kubernetes",
 						Shortname: "headless-svc",
 						Namespace: "testns",
+						Ports:     headlessPorts,
 					},
 				},
 			},
@@ -458,6 +469,7 @@ func TestNameTable(t *testing.T) {
 						Registry:  "Kubernetes",
 						Shortname: "headless-svc",
 						Namespace: "testns",
+						Ports:     headlessPorts,
 					},
 				},
 			},
@@ -473,6 +485,7 @@ func TestNameTable(t *testing.T) {
 						Registry:  "Kubernetes",
 						Shortname: "wildcard-svc",
 						Namespace: "testns",
+						Ports:     wildcardPorts,
 					},
 				},
 			},
@@ -507,6 +520,7 @@ func TestNameTable(t *testing.T) {
 						Registry:  "Kubernetes",
 						Shortname: "headless-svc",
 						Namespace: "testns",
+						Ports:     headlessPorts,
 					},
 				},
 			},
@@ -522,6 +536,7 @@ func TestNameTable(t *testing.T) {
 						Registry:  "Kubernetes",
 						Shortname: "wildcard-svc",
 						Namespace: "testns",
+						Ports:     wildcardPorts,
 					},
 				},
 			},
@@ -543,6 +558,7 @@ func TestNameTable(t *testing.T) {
 					"foo.bar.com": {
 						Ips:      []string{"1.2.3.4", "9.6.7.8", "19.6.7.8", "9.16.7.8"},
 						Registry: "External",
+						Ports:    headlessPorts,
 					},
 				},
 			},
@@ -561,6 +577,7 @@ func TestNameTable(t *testing.T) {
 					"dual.foo.bar": {
 						Ips:      []string{"2001:2::", "10.0.0.8"},
 						Registry: "External",
+						Ports:    mysqlPorts,
 					},
 				},
 			},
@@ -574,6 +591,7 @@ func TestNameTable(t *testing.T) {
 					"foo.bar.com": {
 						Ips:      []string{"1.2.3.4", "19.6.7.8", "9.16.7.8"},
 						Registry: "External",
+						Ports:    headlessPorts,
 					},
 				},
 			},
@@ -587,6 +605,7 @@ func TestNameTable(t *testing.T) {
 					"foo.bar.com": {
 						Ips:      []string{"1.2.3.4", "19.6.7.8", "9.16.7.8"},
 						Registry: "External",
+						Ports:    headlessPorts,
 					},
 				},
 			},
@@ -605,6 +624,7 @@ func TestNameTable(t *testing.T) {
 					serviceWithVIP1.Hostname.String(): {
 						Ips:      []string{serviceWithVIP1.DefaultAddress, serviceWithVIP2.DefaultAddress},
 						Registry: provider.External.String(),
+						Ports:    mysqlPorts,
 					},
 				},
 			},
@@ -625,6 +645,7 @@ func TestNameTable(t *testing.T) {
 						Registry:  provider.Kubernetes.String(),
 						Shortname: decoratedService.Attributes.Name,
 						Namespace: decoratedService.Attributes.Namespace,
+						Ports:     mysqlPorts,
 					},
 				},
 			},
@@ -645,6 +666,7 @@ func TestNameTable(t *testing.T) {
 						Registry:  provider.Kubernetes.String(),
 						Shortname: decoratedService.Attributes.Name,
 						Namespace: decoratedService.Attributes.Namespace,
+						Ports:     mysqlPorts,
 					},
 				},
 			},
@@ -41,6 +41,14 @@ type Config struct {
 	Headers      http.Header
 	TLS          *tls.Config
 	Timeout      *time.Duration
+	// Pool, if set, is used to share connections to the proxy with other dialers using the same pool.
+	// Otherwise, connections are only reused by this dialer.
+	Pool *Pool
+	// Identity identifies the client identity and TLS settings used to connect to the proxy. Dialers sharing a Pool
+	// only share connections if they have the same ProxyAddress and Identity. If unset, it is derived from the TLS
+	// certificate and server verification settings; dialers using TLS callbacks, such as GetClientCertificate or
+	// VerifyPeerCertificate, do not share connections unless it is set.
+	Identity string
 }
 
 type Dialer interface {
@@ -50,8 +58,18 @@ type Dialer interface {
 
 // NewDialer creates a Dialer that proxies connections over HBONE to the configured proxy.
 func NewDialer(cfg Config) Dialer {
-	var transport *http2.Transport
+	return &dialer{
+		cfg:       cfg,
+		transport: newTransport(cfg),
+	}
+}
 
+// newTransport creates the transport used to send CONNECT requests to the proxy.
+func newTransport(cfg Config) http.RoundTripper {
+	if cfg.Pool != nil {
+		return cfg.Pool.roundTripper(cfg)
+	}
+	var transport *http2.Transport
 	if cfg.TLS != nil {
 		transport = &http2.Transport{
 			TLSClientConfig: cfg.TLS,
@@ -69,15 +87,12 @@ func NewDialer(cfg Config) Dialer {
 			},
 		}
 	}
-	return &dialer{
-		cfg:       cfg,
-		transport: transport,
-	}
+	return transport
 }
 
 type dialer struct {
 	cfg       Config
-	transport *http2.Transport
+	transport http.RoundTripper
 }
 
 // DialContext connects to `address` via the HBONE proxy.
@@ -98,7 +113,7 @@ func (d dialer) Dial(network, address string) (c net.Conn, err error) {
 	return d.DialContext(context.Background(), network, address)
 }
 
-func hbone(conn io.ReadWriteCloser, address string, req Config, transport *http2.Transport, shouldCopy bool) (*http.Response, io.WriteCloser, error) {
+func hbone(conn io.ReadWriteCloser, address string, req Config, transport http.RoundTripper, shouldCopy bool) (*http.Response, io.WriteCloser, error) {
 	t0 := time.Now()
 
 	url := "http://" + req.ProxyAddress
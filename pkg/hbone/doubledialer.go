@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/http"
 	"time"
 
 	"golang.org/x/net/http2"
@@ -28,37 +29,17 @@ import (
 
 // NewDialer creates a Dialer that proxies connections over HBONE to the configured proxy.
 func NewDoubleDialer(outerCfg Config, innerTLSConfig *tls.Config) Dialer {
-	var outerTransport *http2.Transport
-
-	if outerCfg.TLS != nil {
-		outerTransport = &http2.Transport{
-			TLSClientConfig: outerCfg.TLS,
-		}
-	} else {
-		outerTransport = &http2.Transport{
-			// For h2c
-			AllowHTTP: true,
-			DialTLSContext: func(ctx context.Context, network, addr string, tlsCfg *tls.Config) (net.Conn, error) {
-				d := net.Dialer{}
-				if outerCfg.Timeout != nil {
-					d.Timeout = *outerCfg.Timeout
-				}
-				return d.Dial(network, addr)
-			},
-		}
-	}
-
 	return &doubleDialer{
 		outerCfg:       outerCfg,
 		innerTLSConfig: innerTLSConfig,
-		outerTransport: outerTransport,
+		outerTransport: newTransport(outerCfg),
 	}
 }
 
 type doubleDialer struct {
 	outerCfg       Config
 	innerTLSConfig *tls.Config
-	outerTransport *http2.Transport
+	outerTransport http.RoundTripper
 }
 
 // DialContext connects to `address` via the HBONE proxy.
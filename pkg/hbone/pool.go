@@ -0,0 +1,259 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hbone
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+	"golang.org/x/net/http2"
+
+	"istio.io/istio/pkg/slices"
+)
+
+// PoolConfig defines the configuration for a Pool. All fields are optional.
+type PoolConfig struct {
+	// MaxConcurrentStreams limits the number of CONNECT streams sent over a single connection. The limit advertised by
+	// the proxy is always respected; this allows spreading load over more connections than the proxy requires.
+	MaxConcurrentStreams int
+	// IdleTimeout is the time after which a connection without any streams is closed. Defaults to 90s.
+	IdleTimeout time.Duration
+}
+
+// PoolStats holds statistics of a Pool.
+type PoolStats struct {
+	// Connections is the number of open connections
+	Connections int
+	// ConnectionsCreated is the number of connections established by the pool
+	ConnectionsCreated uint64
+	// Streams is the number of CONNECT streams sent through the pool
+	Streams uint64
+	// ReusedStreams is the number of CONNECT streams sent over an already established connection
+	ReusedStreams uint64
+}
+
+// Pool keeps HTTP/2 connections to HBONE proxies, sending multiple CONNECT streams over each.
+// Connections are shared by all dialers using the pool with the same proxy address and client identity.
+// A Pool is safe for concurrent use, and should be shared rather than created for each Dialer.
+type Pool struct {
+	cfg       PoolConfig
+	transport *http2.Transport
+
+	mu    sync.Mutex
+	conns map[poolKey][]*http2.ClientConn
+
+	connectionsCreated atomic.Uint64
+	streams            atomic.Uint64
+	reusedStreams      atomic.Uint64
+}
+
+// poolKey identifies connections that can be shared.
+type poolKey struct {
+	address  string
+	identity string
+}
+
+// NewPool creates a Pool with the given configuration.
+func NewPool(cfg PoolConfig) *Pool {
+	if cfg.IdleTimeout == 0 {
+		cfg.IdleTimeout = 90 * time.Second
+	}
+	return &Pool{
+		cfg: cfg,
+		transport: &http2.Transport{
+			AllowHTTP:       true,
+			IdleConnTimeout: cfg.IdleTimeout,
+		},
+		conns: map[poolKey][]*http2.ClientConn{},
+	}
+}
+
+// Stats returns the current statistics of the pool.
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	open := 0
+	for _, conns := range p.conns {
+		for _, cc := range conns {
+			if !cc.State().Closed {
+				open++
+			}
+		}
+	}
+	p.mu.Unlock()
+	return PoolStats{
+		Connections:        open,
+		ConnectionsCreated: p.connectionsCreated.Load(),
+		Streams:            p.streams.Load(),
+		ReusedStreams:      p.reusedStreams.Load(),
+	}
+}
+
+// Close closes all connections of the pool. Open streams are interrupted.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, conns := range p.conns {
+		for _, cc := range conns {
+			_ = cc.Close()
+		}
+	}
+	p.conns = map[poolKey][]*http2.ClientConn{}
+}
+
+// roundTripper returns a RoundTripper sending requests over the pooled connections for cfg.
+func (p *Pool) roundTripper(cfg Config) http.RoundTripper {
+	identity := cfg.Identity
+	if identity == "" {
+		identity = tlsIdentity(cfg.TLS)
+	}
+	return &pooledRoundTripper{
+		pool: p,
+		cfg:  cfg,
+		key:  poolKey{address: cfg.ProxyAddress, identity: identity},
+	}
+}
+
+type pooledRoundTripper struct {
+	pool *Pool
+	cfg  Config
+	key  poolKey
+}
+
+func (r *pooledRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	cc, err := r.pool.get(req.Context(), r.key, r.cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cc.RoundTrip(req)
+}
+
+// get returns a connection with a stream reserved for a new request, establishing one if none can take it.
+func (p *Pool) get(ctx context.Context, key poolKey, cfg Config) (*http2.ClientConn, error) {
+	p.streams.Inc()
+	if cc := p.reserve(key); cc != nil {
+		p.reusedStreams.Inc()
+		return cc, nil
+	}
+	// Connect without holding the lock, so a slow proxy does not block others.
+	// Concurrent dials for the same key may each establish a connection; all are kept for later streams.
+	cc, err := p.connect(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	p.connectionsCreated.Inc()
+	if !cc.ReserveNewRequest() {
+		_ = cc.Close()
+		return nil, fmt.Errorf("new connection to %v cannot take requests", cfg.ProxyAddress)
+	}
+	p.mu.Lock()
+	p.conns[key] = append(p.conns[key], cc)
+	p.mu.Unlock()
+	return cc, nil
+}
+
+// reserve returns an existing connection for key with a stream reserved, or nil if there is none.
+func (p *Pool) reserve(key poolKey) *http2.ClientConn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	conns := slices.FilterInPlace(p.conns[key], func(cc *http2.ClientConn) bool {
+		st := cc.State()
+		return !st.Closed && !st.Closing
+	})
+	if len(conns) == 0 {
+		delete(p.conns, key)
+		return nil
+	}
+	p.conns[key] = conns
+	for _, cc := range conns {
+		if p.cfg.MaxConcurrentStreams > 0 {
+			st := cc.State()
+			if st.StreamsActive+st.StreamsReserved+st.StreamsPending >= p.cfg.MaxConcurrentStreams {
+				continue
+			}
+		}
+		if cc.ReserveNewRequest() {
+			return cc
+		}
+	}
+	return nil
+}
+
+func (p *Pool) connect(ctx context.Context, cfg Config) (*http2.ClientConn, error) {
+	d := net.Dialer{}
+	if cfg.Timeout != nil {
+		d.Timeout = *cfg.Timeout
+	}
+	conn, err := d.DialContext(ctx, "tcp", cfg.ProxyAddress)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.TLS != nil {
+		tlsCfg := cfg.TLS.Clone()
+		tlsCfg.NextProtos = []string{http2.NextProtoTLS}
+		if tlsCfg.ServerName == "" {
+			host, _, _ := net.SplitHostPort(cfg.ProxyAddress)
+			tlsCfg.ServerName = host
+		}
+		tc := tls.Client(conn, tlsCfg)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		if p := tc.ConnectionState().NegotiatedProtocol; p != http2.NextProtoTLS {
+			_ = conn.Close()
+			return nil, fmt.Errorf("proxy %v negotiated protocol %q, expected %q", cfg.ProxyAddress, p, http2.NextProtoTLS)
+		}
+		conn = tc
+	}
+	cc, err := p.transport.NewClientConn(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	log.Debugf("established pooled connection to %v", cfg.ProxyAddress)
+	return cc, nil
+}
+
+// tlsIdentity returns a key for the client identity presented by cfg and the verification of the server. Connections
+// are only shared between dialers presenting the same certificate to the same server name, and verifying the server
+// the same way. Configs with callbacks cannot be compared, so their connections are not shared with other configs.
+func tlsIdentity(cfg *tls.Config) string {
+	if cfg == nil {
+		return "plaintext"
+	}
+	if cfg.GetClientCertificate != nil || cfg.VerifyPeerCertificate != nil || cfg.VerifyConnection != nil {
+		return fmt.Sprintf("config/%p", cfg)
+	}
+	identity := "anonymous"
+	if len(cfg.Certificates) > 0 && len(cfg.Certificates[0].Certificate) > 0 {
+		sum := sha256.Sum256(cfg.Certificates[0].Certificate[0])
+		identity = hex.EncodeToString(sum[:])
+	}
+	// Cert pools are compared by pointer, which Clone preserves. A nil pool uses the system roots.
+	roots := "system"
+	if cfg.RootCAs != nil {
+		roots = fmt.Sprintf("%p", cfg.RootCAs)
+	}
+	return fmt.Sprintf("%s/%s/roots=%s/insecure=%t/versions=%d-%d/ciphers=%v",
+		identity, cfg.ServerName, roots, cfg.InsecureSkipVerify, cfg.MinVersion, cfg.MaxVersion, cfg.CipherSuites)
+}
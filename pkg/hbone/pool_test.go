@@ -0,0 +1,170 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hbone
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+func dialAll(t *testing.T, dialers []Dialer, address string) []net.Conn {
+	t.Helper()
+	var conns []net.Conn
+	for _, d := range dialers {
+		c, err := d.Dial("tcp", address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			c.Close()
+		})
+		roundTrip(t, c, "hello")
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+func TestPool(t *testing.T) {
+	upstream := newEchoServer(t)
+	_, addr := startServer(t, ServerConfig{})
+
+	t.Run("reuse", func(t *testing.T) {
+		pool := NewPool(PoolConfig{})
+		defer pool.Close()
+		d1 := NewDialer(Config{ProxyAddress: addr, Pool: pool})
+		d2 := NewDialer(Config{ProxyAddress: addr, Pool: pool})
+		dialAll(t, []Dialer{d1, d1, d2}, upstream)
+		assert.Equal(t, pool.Stats(), PoolStats{
+			Connections:        1,
+			ConnectionsCreated: 1,
+			Streams:            3,
+			ReusedStreams:      2,
+		})
+	})
+
+	t.Run("identity", func(t *testing.T) {
+		pool := NewPool(PoolConfig{})
+		defer pool.Close()
+		d1 := NewDialer(Config{ProxyAddress: addr, Pool: pool, Identity: "a"})
+		d2 := NewDialer(Config{ProxyAddress: addr, Pool: pool, Identity: "b"})
+		dialAll(t, []Dialer{d1, d2, d2}, upstream)
+		assert.Equal(t, pool.Stats().ConnectionsCreated, uint64(2))
+	})
+
+	t.Run("max streams", func(t *testing.T) {
+		pool := NewPool(PoolConfig{MaxConcurrentStreams: 2})
+		defer pool.Close()
+		d := NewDialer(Config{ProxyAddress: addr, Pool: pool})
+		conns := dialAll(t, []Dialer{d, d, d}, upstream)
+		assert.Equal(t, pool.Stats().ConnectionsCreated, uint64(2))
+
+		// Once a stream completes, its connection can be used again
+		conns[0].Close()
+		retry.UntilSuccessOrFail(t, func() error {
+			c, err := d.Dial("tcp", upstream)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			roundTrip(t, c, "hello")
+			return nil
+		}, retry.Timeout(5*time.Second))
+		assert.Equal(t, pool.Stats().ConnectionsCreated, uint64(2))
+	})
+
+	t.Run("idle timeout", func(t *testing.T) {
+		pool := NewPool(PoolConfig{IdleTimeout: 100 * time.Millisecond})
+		defer pool.Close()
+		d := NewDialer(Config{ProxyAddress: addr, Pool: pool})
+		conns := dialAll(t, []Dialer{d}, upstream)
+		assert.Equal(t, pool.Stats().Connections, 1)
+		conns[0].Close()
+		retry.UntilOrFail(t, func() bool {
+			return pool.Stats().Connections == 0
+		}, retry.Timeout(5*time.Second))
+	})
+}
+
+func TestPoolTLS(t *testing.T) {
+	upstream := newEchoServer(t)
+	serverCert := newTestCert(t, "spiffe://cluster.local/ns/default/sa/server")
+	certA := newTestCert(t, "spiffe://cluster.local/ns/default/sa/a")
+	certB := newTestCert(t, "spiffe://cluster.local/ns/default/sa/b")
+	_, addr := startServer(t, ServerConfig{
+		TLS: &tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    clientCAs(t, certA, certB),
+			MinVersion:   tls.VersionTLS12,
+		},
+	})
+	a := clientTLSConfig(certA)
+	b := clientTLSConfig(certB)
+
+	pool := NewPool(PoolConfig{})
+	defer pool.Close()
+	dialers := []Dialer{
+		NewDialer(Config{ProxyAddress: addr, Pool: pool, TLS: a}),
+		NewDialer(Config{ProxyAddress: addr, Pool: pool, TLS: a.Clone()}),
+		NewDialer(Config{ProxyAddress: addr, Pool: pool, TLS: b}),
+	}
+	dialAll(t, dialers, upstream)
+	// Connections are shared between the dialers with the same certificate only
+	assert.Equal(t, pool.Stats(), PoolStats{
+		Connections:        2,
+		ConnectionsCreated: 2,
+		Streams:            3,
+		ReusedStreams:      1,
+	})
+}
+
+func TestTLSIdentity(t *testing.T) {
+	cert := newTestCert(t, "spiffe://cluster.local/ns/default/sa/a")
+	roots := x509.NewCertPool()
+	strict := &tls.Config{Certificates: []tls.Certificate{cert}, RootCAs: roots, MinVersion: tls.VersionTLS13}
+	insecure := strict.Clone()
+	insecure.InsecureSkipVerify = true
+	otherRoots := strict.Clone()
+	otherRoots.RootCAs = x509.NewCertPool()
+	systemRoots := strict.Clone()
+	systemRoots.RootCAs = nil
+	oldVersion := strict.Clone()
+	oldVersion.MinVersion = tls.VersionTLS12
+	verifyPeer := strict.Clone()
+	verifyPeer.VerifyPeerCertificate = func([][]byte, [][]*x509.Certificate) error { return nil }
+
+	assert.Equal(t, tlsIdentity(strict), tlsIdentity(strict.Clone()))
+	for name, cfg := range map[string]*tls.Config{
+		"insecure":     insecure,
+		"other roots":  otherRoots,
+		"system roots": systemRoots,
+		"old version":  oldVersion,
+		"verify peer":  verifyPeer,
+	} {
+		if tlsIdentity(cfg) == tlsIdentity(strict) {
+			t.Errorf("%s: expected connections not to be shared", name)
+		}
+	}
+	// Configs with callbacks are not shared, even with their clones
+	if tlsIdentity(verifyPeer) == tlsIdentity(verifyPeer.Clone()) {
+		t.Error("expected configs with callbacks not to be shared")
+	}
+}
@@ -17,8 +17,10 @@ package hbone
 import (
 	"context"
 	"crypto/tls"
+	"io"
 	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,6 +28,9 @@ import (
 	"golang.org/x/net/http2"
 
 	"istio.io/istio/pkg/h2c"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/security/pkg/pki/util"
 )
 
 func NewDoubleHBONEServer(tlsConfig *tls.Config) *http.Server {
@@ -168,3 +173,275 @@ func handleConnect(w http.ResponseWriter, r *http.Request) bool {
 	log.Infof("connection closed in %v", time.Since(t0))
 	return false
 }
+
+// ServerConfig defines the configuration for a Server. All fields are optional.
+type ServerConfig struct {
+	// TLS, if set, is used to terminate TLS on incoming connections. Client certificates are always verified against
+	// ClientCAs: RequestClientCert and RequireAnyClientCert are upgraded to VerifyClientCertIfGiven and
+	// RequireAndVerifyClientCert. To authorize requests based on the peer identity, ClientAuth should be set to
+	// RequireAndVerifyClientCert.
+	TLS *tls.Config
+	// Authorize is called for each CONNECT request before connecting upstream. If an error is returned, the request
+	// is rejected with a 403.
+	Authorize func(ctx context.Context, req *ConnectRequest) error
+	// Dial connects to the upstream for a CONNECT request. By default, a TCP connection is opened to req.Target.
+	Dial func(ctx context.Context, req *ConnectRequest) (net.Conn, error)
+	// DialTimeout bounds the time spent connecting upstream. Defaults to 10s.
+	DialTimeout time.Duration
+	// OnClose is called when a tunnel is closed, with its accounting.
+	OnClose func(req *ConnectRequest, stats ConnectionStats)
+}
+
+// ConnectRequest describes an incoming HBONE CONNECT request.
+type ConnectRequest struct {
+	// Target is the CONNECT authority, in <host>:<port> form
+	Target string
+	// RemoteAddr is the address of the client connection
+	RemoteAddr string
+	// PeerIdentities are the SPIFFE identities of the verified client certificate. Empty when TLS is not used, or the
+	// client did not present a certificate.
+	PeerIdentities []string
+	// Headers are the request headers, such as Forwarded and Baggage
+	Headers http.Header
+}
+
+// ConnectionStats holds the accounting of a single tunnel.
+type ConnectionStats struct {
+	// BytesReceived is the number of bytes sent by the client and forwarded upstream
+	BytesReceived int64
+	// BytesSent is the number of bytes sent by the upstream and forwarded to the client
+	BytesSent int64
+	// Duration is the time the tunnel was open
+	Duration time.Duration
+}
+
+// Server is an HBONE server, terminating CONNECT requests and proxying them upstream.
+// Unlike NewServer, it allows authorizing requests, customizing how upstreams are dialed, accounting each tunnel,
+// and draining gracefully.
+type Server struct {
+	cfg    ServerConfig
+	server *http.Server
+
+	mu       sync.Mutex
+	draining bool
+	tunnels  map[*tunnel]struct{}
+	active   sync.WaitGroup
+}
+
+// tunnel is an open CONNECT stream. It is closed by closing both sides.
+type tunnel struct {
+	downstream io.Closer
+	upstream   net.Conn
+}
+
+func (t *tunnel) close() {
+	_ = t.downstream.Close()
+	_ = t.upstream.Close()
+}
+
+// NewServerWithConfig creates a Server with the given configuration.
+func NewServerWithConfig(cfg ServerConfig) *Server {
+	if cfg.DialTimeout == 0 {
+		cfg.DialTimeout = 10 * time.Second
+	}
+	s := &Server{
+		cfg:     cfg,
+		tunnels: map[*tunnel]struct{}{},
+	}
+	h2Server := &http2.Server{}
+	s.server = &http.Server{
+		Handler: h2c.NewHandler(http.HandlerFunc(s.handleConnect), h2Server),
+	}
+	// Configure HTTP/2 for TLS connections as well; this also sends a GOAWAY to clients on Shutdown.
+	_ = http2.ConfigureServer(s.server, h2Server)
+	return s
+}
+
+// Serve accepts connections on l, serving HBONE requests. Like http.Server, it always returns a non-nil error;
+// after Shutdown or Close, http.ErrServerClosed is returned.
+func (s *Server) Serve(l net.Listener) error {
+	if s.cfg.TLS != nil {
+		cfg := s.cfg.TLS.Clone()
+		if len(cfg.NextProtos) == 0 {
+			cfg.NextProtos = []string{"h2"}
+		}
+		cfg.ClientAuth = verifiedClientAuth(cfg.ClientAuth)
+		l = tls.NewListener(l, cfg)
+	}
+	return s.server.Serve(l)
+}
+
+// Shutdown drains the server: listeners are closed, new CONNECT requests are rejected, and open tunnels are
+// allowed to complete. If ctx expires first, remaining tunnels are closed and the context error is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.draining = true
+	s.mu.Unlock()
+	err := s.server.Shutdown(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		s.active.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		log.Warnf("closing %d HBONE tunnels after drain timeout", s.ActiveConnections())
+		_ = s.server.Close()
+		s.closeTunnels()
+		return ctx.Err()
+	}
+}
+
+// Close immediately closes the server and all open tunnels.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.draining = true
+	s.mu.Unlock()
+	err := s.server.Close()
+	s.closeTunnels()
+	return err
+}
+
+// ActiveConnections returns the number of open tunnels.
+func (s *Server) ActiveConnections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.tunnels)
+}
+
+func (s *Server) closeTunnels() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for t := range s.tunnels {
+		t.close()
+	}
+}
+
+// track registers an open tunnel. If the server is draining, false is returned and the tunnel should not be used.
+func (s *Server) track(t *tunnel) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining {
+		return false
+	}
+	s.tunnels[t] = struct{}{}
+	s.active.Add(1)
+	return true
+}
+
+func (s *Server) untrack(t *tunnel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tunnels, t)
+	s.active.Done()
+}
+
+func (s *Server) dial(ctx context.Context, req *ConnectRequest) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.DialTimeout)
+	defer cancel()
+	if s.cfg.Dial != nil {
+		return s.cfg.Dial(ctx, req)
+	}
+	return (&net.Dialer{}).DialContext(ctx, "tcp", req.Target)
+}
+
+func (s *Server) handleConnect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.mu.Lock()
+	draining := s.draining
+	s.mu.Unlock()
+	if draining {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	req := &ConnectRequest{
+		Target:         r.Host,
+		RemoteAddr:     r.RemoteAddr,
+		PeerIdentities: peerIdentities(r.TLS),
+		Headers:        r.Header,
+	}
+	log := log.WithLabels("host", req.Target, "source", req.RemoteAddr)
+	if s.cfg.Authorize != nil {
+		if err := s.cfg.Authorize(r.Context(), req); err != nil {
+			log.Infof("CONNECT denied for %v: %v", req.PeerIdentities, err)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+	}
+
+	dst, err := s.dial(r.Context(), req)
+	if err != nil {
+		log.Errorf("failed to dial upstream: %v", err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	t := &tunnel{downstream: r.Body, upstream: dst}
+	if !s.track(t) {
+		_ = dst.Close()
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	defer s.untrack(t)
+
+	t0 := time.Now()
+	log.Debugf("CONNECT established")
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+
+	var sent int64
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		// downstream (hbone client) <-- upstream (app)
+		sent = copyBuffered(w, dst, log.WithLabels("name", "dst to w"))
+		if err := r.Body.Close(); err != nil {
+			log.Infof("connection to hbone client is not closed: %v", err)
+		}
+		wg.Done()
+	}()
+	// downstream (hbone client) --> upstream (app)
+	received := copyBuffered(dst, r.Body, log.WithLabels("name", "body to dst"))
+	wg.Wait()
+	_ = dst.Close()
+
+	stats := ConnectionStats{
+		BytesReceived: received,
+		BytesSent:     sent,
+		Duration:      time.Since(t0),
+	}
+	log.Debugf("connection closed in %v (received %d bytes, sent %d bytes)", stats.Duration, stats.BytesReceived, stats.BytesSent)
+	if s.cfg.OnClose != nil {
+		s.cfg.OnClose(req, stats)
+	}
+}
+
+// verifiedClientAuth returns the ClientAuth mode verifying client certificates, if any are requested. Identities of
+// unverified certificates could be claimed by anyone, so they are never accepted.
+func verifiedClientAuth(auth tls.ClientAuthType) tls.ClientAuthType {
+	switch auth {
+	case tls.RequestClientCert:
+		return tls.VerifyClientCertIfGiven
+	case tls.RequireAnyClientCert:
+		return tls.RequireAndVerifyClientCert
+	default:
+		return auth
+	}
+}
+
+// peerIdentities returns the SPIFFE identities of the client certificate, if it was verified.
+func peerIdentities(state *tls.ConnectionState) []string {
+	if state == nil || len(state.PeerCertificates) == 0 || len(state.VerifiedChains) == 0 {
+		return nil
+	}
+	ids, _ := util.ExtractIDs(state.PeerCertificates[0].Extensions)
+	return slices.FilterInPlace(ids, func(id string) bool {
+		return strings.HasPrefix(id, spiffe.URIPrefix)
+	})
+}
@@ -0,0 +1,309 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hbone
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func newEchoServer(t testing.TB) string {
+	n, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			c, err := n.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _ = io.Copy(c, c)
+				c.Close()
+			}()
+		}
+	}()
+	t.Cleanup(func() {
+		n.Close()
+	})
+	return n.Addr().String()
+}
+
+func startServer(t *testing.T, cfg ServerConfig) (*Server, string) {
+	s := NewServerWithConfig(cfg)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = s.Serve(l)
+	}()
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+	return s, l.Addr().String()
+}
+
+// newTestCert creates a self-signed certificate for identity, usable both as a client and server certificate.
+func newTestCert(t testing.TB, identity string) tls.Certificate {
+	certPEM, keyPEM, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:         identity,
+		TTL:          time.Hour,
+		IsSelfSigned: true,
+		IsClient:     true,
+		IsServer:     true,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// clientCAs returns a pool trusting the given self-signed certificates.
+func clientCAs(t testing.TB, certs ...tls.Certificate) *x509.CertPool {
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		pool.AddCert(leaf)
+	}
+	return pool
+}
+
+func roundTrip(t *testing.T, c net.Conn, data string) {
+	t.Helper()
+	if _, err := c.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, len(data))
+	if _, err := io.ReadFull(c, buf); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(buf), data)
+}
+
+func TestServer(t *testing.T) {
+	allowed := newEchoServer(t)
+	denied := newEchoServer(t)
+	closed := make(chan ConnectionStats, 1)
+	_, addr := startServer(t, ServerConfig{
+		Authorize: func(ctx context.Context, req *ConnectRequest) error {
+			if req.Target != allowed {
+				return fmt.Errorf("target %v is not allowed", req.Target)
+			}
+			return nil
+		},
+		OnClose: func(req *ConnectRequest, stats ConnectionStats) {
+			closed <- stats
+		},
+	})
+	d := NewDialer(Config{ProxyAddress: addr})
+
+	_, err := d.Dial("tcp", denied)
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+
+	c, err := d.Dial("tcp", allowed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip(t, c, "hello")
+	roundTrip(t, c, "world!")
+	c.Close()
+	select {
+	case stats := <-closed:
+		assert.Equal(t, stats.BytesReceived, int64(11))
+		assert.Equal(t, stats.BytesSent, int64(11))
+	case <-time.After(5 * time.Second):
+		t.Fatal("tunnel was not closed")
+	}
+}
+
+func TestServerUpstreamDial(t *testing.T) {
+	upstream := newEchoServer(t)
+	_, addr := startServer(t, ServerConfig{
+		Dial: func(ctx context.Context, req *ConnectRequest) (net.Conn, error) {
+			// Send all requests to the same upstream, regardless of the target
+			return (&net.Dialer{}).DialContext(ctx, "tcp", upstream)
+		},
+	})
+	d := NewDialer(Config{ProxyAddress: addr})
+	c, err := d.Dial("tcp", "example.com:80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	roundTrip(t, c, "hello")
+}
+
+func TestServerPeerIdentity(t *testing.T) {
+	identity := "spiffe://cluster.local/ns/default/sa/client"
+	cert := newTestCert(t, identity)
+	upstream := newEchoServer(t)
+	peers := make(chan []string, 1)
+	_, addr := startServer(t, ServerConfig{
+		TLS: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    clientCAs(t, cert),
+			MinVersion:   tls.VersionTLS12,
+		},
+		Authorize: func(ctx context.Context, req *ConnectRequest) error {
+			peers <- req.PeerIdentities
+			return nil
+		},
+	})
+	d := NewDialer(Config{
+		ProxyAddress: addr,
+		TLS:          clientTLSConfig(cert),
+	})
+	c, err := d.Dial("tcp", upstream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	roundTrip(t, c, "hello")
+	assert.Equal(t, <-peers, []string{identity})
+}
+
+func TestServerUnverifiedPeer(t *testing.T) {
+	serverCert := newTestCert(t, "spiffe://cluster.local/ns/default/sa/server")
+	trusted := newTestCert(t, "spiffe://cluster.local/ns/default/sa/client")
+	// Self-signed, claiming the trusted identity
+	untrusted := newTestCert(t, "spiffe://cluster.local/ns/default/sa/client")
+	upstream := newEchoServer(t)
+	authorized := make(chan []string, 1)
+	_, addr := startServer(t, ServerConfig{
+		TLS: &tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			// Upgraded to RequireAndVerifyClientCert
+			ClientAuth: tls.RequireAnyClientCert,
+			ClientCAs:  clientCAs(t, trusted),
+			MinVersion: tls.VersionTLS12,
+		},
+		Authorize: func(ctx context.Context, req *ConnectRequest) error {
+			authorized <- req.PeerIdentities
+			return nil
+		},
+	})
+	d := NewDialer(Config{
+		ProxyAddress: addr,
+		TLS:          clientTLSConfig(untrusted),
+	})
+	if c, err := d.Dial("tcp", upstream); err == nil {
+		c.Close()
+		t.Fatal("expected the unverified client certificate to be rejected")
+	}
+	select {
+	case ids := <-authorized:
+		t.Fatalf("unverified client was authorized as %v", ids)
+	default:
+	}
+}
+
+func TestPeerIdentitiesUnverified(t *testing.T) {
+	cert := newTestCert(t, "spiffe://cluster.local/ns/default/sa/client")
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}}
+	assert.Equal(t, peerIdentities(state), nil)
+	state.VerifiedChains = [][]*x509.Certificate{{leaf}}
+	assert.Equal(t, peerIdentities(state), []string{"spiffe://cluster.local/ns/default/sa/client"})
+}
+
+func clientTLSConfig(cert tls.Certificate) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		// nolint: gosec
+		InsecureSkipVerify: true,
+	}
+}
+
+func TestServerShutdown(t *testing.T) {
+	upstream := newEchoServer(t)
+	s, addr := startServer(t, ServerConfig{})
+	d := NewDialer(Config{ProxyAddress: addr})
+
+	t.Run("drain", func(t *testing.T) {
+		c, err := d.Dial("tcp", upstream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roundTrip(t, c, "hello")
+		assert.Equal(t, s.ActiveConnections(), 1)
+
+		done := make(chan error, 1)
+		go func() {
+			done <- s.Shutdown(context.Background())
+		}()
+		// Open tunnels are not interrupted by the drain
+		roundTrip(t, c, "world")
+		select {
+		case err := <-done:
+			t.Fatalf("shutdown completed with an open tunnel: %v", err)
+		case <-time.After(100 * time.Millisecond):
+		}
+		c.Close()
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("shutdown did not complete")
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		s, addr := startServer(t, ServerConfig{})
+		d := NewDialer(Config{ProxyAddress: addr})
+		c, err := d.Dial("tcp", upstream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		roundTrip(t, c, "hello")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		if err := s.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected deadline exceeded, got %v", err)
+		}
+		retry.UntilOrFail(t, func() bool {
+			return s.ActiveConnections() == 0
+		}, retry.Timeout(5*time.Second))
+	})
+}
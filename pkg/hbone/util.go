@@ -30,7 +30,8 @@ var bufferPoolCopy = sync.Pool{New: func() any {
 	return make([]byte, 0, 32*1024)
 }}
 
-func copyBuffered(dst io.Writer, src io.Reader, log *istiolog.Scope) {
+// copyBuffered copies from src to dst until either fails, returning the number of bytes written.
+func copyBuffered(dst io.Writer, src io.Reader, log *istiolog.Scope) int64 {
 	buf1 := bufferPoolCopy.Get().([]byte)
 	// nolint: staticcheck
 	defer bufferPoolCopy.Put(buf1)
@@ -52,6 +53,7 @@ func copyBuffered(dst io.Writer, src io.Reader, log *istiolog.Scope) {
 	//if rt, ok := dst.(io.ReaderFrom); ok {
 	//	return rt.ReadFrom(src)
 	//}
+	var written int64
 	for {
 		if srcc, ok := src.(net.Conn); ok {
 			// Best effort
@@ -62,6 +64,9 @@ func copyBuffered(dst io.Writer, src io.Reader, log *istiolog.Scope) {
 		if nr > 0 { // before dealing with the read error
 			nw, ew := dst.Write(buf[0:nr])
 			log.Debugf("write %v/%v", nw, ew)
+			if nw > 0 {
+				written += int64(nw)
+			}
 			if f, ok := dst.(http.Flusher); ok {
 				f.Flush()
 			}
@@ -69,13 +74,13 @@ func copyBuffered(dst io.Writer, src io.Reader, log *istiolog.Scope) {
 				ew = io.ErrShortWrite
 			}
 			if ew != nil {
-				return
+				return written
 			}
 		}
 		if err != nil {
 			// read is already closed - we need to close out
 			_ = closeWriter(dst)
-			return
+			return written
 		}
 	}
 }
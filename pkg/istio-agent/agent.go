@@ -161,6 +161,19 @@ type AgentOptions struct {
 	DNSAddr string
 	// DNSForwardParallel indicates whether the agent should send parallel DNS queries to all upstream nameservers.
 	DNSForwardParallel bool
+	// DNSTTL is the TTL of the DNS records the agent serves for hosts known to istiod.
+	DNSTTL time.Duration
+	// DNSCacheSize is the maximum number of upstream DNS responses cached by the agent. Zero disables caching.
+	DNSCacheSize int
+	// DNSNegativeCacheTTL is how long upstream NXDOMAIN and NODATA responses without an SOA record are cached.
+	DNSNegativeCacheTTL time.Duration
+	// DNSUpstreamProtocol is the protocol used to forward DNS queries to upstream nameservers: udp, tcp or tls.
+	// If empty, queries are forwarded with the protocol they were received with.
+	DNSUpstreamProtocol string
+	// DNSUpstreamTLSServerName is the name used to verify upstream nameservers with DNS-over-TLS.
+	DNSUpstreamTLSServerName string
+	// DNSUpstreamTLSCACert is the path to the CA certificates used to verify upstream nameservers with DNS-over-TLS.
+	DNSUpstreamTLSCACert string
 	// ProxyType is the type of proxy we are configured to handle
 	ProxyType model.NodeType
 	// ProxyNamespace to use for local dns resolution
@@ -542,7 +555,15 @@ func (a *Agent) startFileWatcher(ctx context.Context, filePath string, handler f
 func (a *Agent) initLocalDNSServer() (err error) {
 	if a.isDNSServerEnabled() {
 		if a.localDNSServer, err = dnsClient.NewLocalDNSServer(a.cfg.ProxyNamespace, a.cfg.ProxyDomain, a.cfg.DNSAddr,
-			a.cfg.DNSForwardParallel); err != nil {
+			dnsClient.Options{
+				ForwardToUpstreamParallel: a.cfg.DNSForwardParallel,
+				TTL:                       a.cfg.DNSTTL,
+				CacheSize:                 a.cfg.DNSCacheSize,
+				NegativeCacheTTL:          a.cfg.DNSNegativeCacheTTL,
+				UpstreamProtocol:          a.cfg.DNSUpstreamProtocol,
+				UpstreamTLSServerName:     a.cfg.DNSUpstreamTLSServerName,
+				UpstreamTLSCACert:         a.cfg.DNSUpstreamTLSCACert,
+			}); err != nil {
 			return err
 		}
 		a.localDNSServer.StartDNS()
@@ -20,7 +20,10 @@ import (
 
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/security"
+	"istio.io/istio/security/pkg/nodeagent/caclient"
+	"istio.io/istio/security/pkg/nodeagent/caclient/providers/acme"
 	citadel "istio.io/istio/security/pkg/nodeagent/caclient/providers/citadel"
+	"istio.io/istio/security/pkg/nodeagent/caclient/providers/vault"
 )
 
 // WARNING WARNING WARNING
@@ -65,8 +68,42 @@ func createCitadel(opts *security.Options, a RootCertProvider) (security.Client,
 	return citadel.NewCitadelClient(opts, tlsOpts)
 }
 
+// httpCATLSOptions returns the TLS options to connect to a CA using an HTTP API.
+// The CA is verified with the same root as for Citadel, so CA_ROOT_CA should be set to SYSTEM for public CAs.
+func httpCATLSOptions(opts *security.Options, a RootCertProvider) (*caclient.TLSOptions, error) {
+	rootCert, err := a.FindRootCAForCA()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find root CA cert for CA: %v", err)
+	}
+	if rootCert == "" {
+		log.Infof("Using CA %s cert with system certs", opts.CAEndpoint)
+	} else {
+		log.Infof("Using CA %s cert with certs: %s", opts.CAEndpoint, rootCert)
+	}
+	key, cert := a.GetKeyCertsForCA()
+	return &caclient.TLSOptions{RootCert: rootCert, Key: key, Cert: cert}, nil
+}
+
+func createVault(opts *security.Options, a RootCertProvider) (security.Client, error) {
+	tlsOpts, err := httpCATLSOptions(opts, a)
+	if err != nil {
+		return nil, err
+	}
+	return vault.NewVaultClient(opts, tlsOpts)
+}
+
+func createACME(opts *security.Options, a RootCertProvider) (security.Client, error) {
+	tlsOpts, err := httpCATLSOptions(opts, a)
+	if err != nil {
+		return nil, err
+	}
+	return acme.NewACMEClient(opts, tlsOpts)
+}
+
 func init() {
 	providers["Citadel"] = createCitadel
+	providers[security.VaultCAProvider] = createVault
+	providers[security.ACMECAProvider] = createACME
 }
 
 func createCAClient(opts *security.Options, a RootCertProvider) (security.Client, error) {
@@ -17,6 +17,7 @@ package krt
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"istio.io/istio/pkg/kube/controllers"
 	"istio.io/istio/pkg/kube/kclient"
@@ -150,6 +151,8 @@ type manyCollection[I, O any] struct {
 	mu              sync.Mutex
 	collectionState multiIndex[I, O]
 	dependencyState dependencyState[I]
+	// dependencies is the total number of dependencies of all inputs
+	dependencies int
 
 	// internal indexes
 	indexes []collectionIndex[I, O]
@@ -169,6 +172,10 @@ type manyCollection[I, O any] struct {
 	onPrimaryInputEventHandler func(o []Event[I])
 
 	syncer Syncer
+
+	metrics collectionMetrics
+	// debugger, if set, may trace recomputations. See DebugHandler.EnableTracing.
+	debugger *DebugHandler
 }
 
 type collectionIndex[I, O any] struct {
@@ -358,14 +365,21 @@ func (h *manyCollection[I, O]) onPrimaryInputEvent(items []Event[I]) {
 		}
 		items[idx] = ev
 	}
-	h.onPrimaryInputEventLocked(items)
+	h.onPrimaryInputEventLocked(items, h.parent.(internalCollection[I]).name(), func() []string {
+		return slices.Map(items, func(e Event[I]) string {
+			return GetKey(e.Latest())
+		})
+	})
 }
 
 // onPrimaryInputEventLocked takes a list of I's that changed and reruns the handler over them.
+// trigger is the name of the collection whose change caused the recomputation, and triggerKeys returns the keys of
+// the changed objects in it; these are only used for tracing.
 // This should be called with recomputeMu acquired.
-func (h *manyCollection[I, O]) onPrimaryInputEventLocked(items []Event[I]) {
+func (h *manyCollection[I, O]) onPrimaryInputEventLocked(items []Event[I], trigger string, triggerKeys func() []string) {
 	var events []Event[O]
 	recomputedResults := make([]map[Key[O]]O, len(items))
+	start := time.Now()
 
 	pendingDepStateUpdates := make(map[Key[I]]*collectionDependencyTracker[I, O], len(items))
 	for idx, a := range items {
@@ -377,7 +391,9 @@ func (h *manyCollection[I, O]) onPrimaryInputEventLocked(items []Event[I]) {
 		iKey := getTypedKey(i)
 
 		ctx := &collectionDependencyTracker[I, O]{manyCollection: h, key: iKey}
+		transformationStart := time.Now()
 		results := slices.GroupUnique(h.transformation(ctx, i), getTypedKey[O])
+		h.metrics.transformationDuration.Record(time.Since(transformationStart).Seconds())
 		recomputedResults[idx] = results
 		// Store new dependency state, to insert in the next loop under the lock
 		pendingDepStateUpdates[iKey] = ctx
@@ -411,6 +427,7 @@ func (h *manyCollection[I, O]) onPrimaryInputEventLocked(items []Event[I]) {
 			}
 			delete(h.collectionState.mappings, iKey)
 			delete(h.collectionState.inputs, iKey)
+			h.dependencies -= len(h.dependencyState.objectDependencies[iKey])
 			h.dependencyState.delete(iKey)
 		} else {
 			ctx := pendingDepStateUpdates[iKey]
@@ -426,6 +443,7 @@ func (h *manyCollection[I, O]) onPrimaryInputEventLocked(items []Event[I]) {
 				}
 				h.log.WithLabels("iKey", iKey).Debugf("would discard result, but it is the first so including it")
 			}
+			h.dependencies += len(ctx.d) - len(h.dependencyState.objectDependencies[iKey])
 			h.dependencyState.update(iKey, ctx.d)
 			newKeys := sets.New(maps.Keys(results)...)
 			oldKeys := h.collectionState.mappings[iKey]
@@ -471,6 +489,26 @@ func (h *manyCollection[I, O]) onPrimaryInputEventLocked(items []Event[I]) {
 		}
 	}
 
+	h.metrics.dependencies.RecordInt(int64(h.dependencies))
+	for _, e := range events {
+		h.metrics.recordEvent(e.Event)
+	}
+	if tracer := h.debugger.activeTracer(); tracer != nil && len(items) > 0 {
+		tracer.record(TraceEvent{
+			Time:        start,
+			Collection:  h.collectionName,
+			Trigger:     trigger,
+			TriggerKeys: triggerKeys(),
+			Recomputed: slices.Map(items, func(e Event[I]) string {
+				return GetKey(e.Latest())
+			}),
+			Events: slices.Map(events, func(e Event[O]) string {
+				return e.Event.String() + "/" + GetKey(e.Latest())
+			}),
+			Duration: time.Since(start),
+		})
+	}
+
 	// Short circuit if we have nothing to do
 	if len(events) == 0 {
 		return
@@ -549,6 +587,8 @@ func newManyCollection[I, O any](
 		synced:                     make(chan struct{}),
 		stop:                       opts.stop,
 		onPrimaryInputEventHandler: onPrimaryInputEventHandler,
+		metrics:                    newCollectionMetrics(opts.name),
+		debugger:                   opts.debugger,
 	}
 	h.syncer = channelSyncer{
 		name:   h.collectionName,
@@ -595,11 +635,12 @@ func (h *manyCollection[I, O]) runQueue() {
 
 // Handler is called when a dependency changes. We will take as inputs the item that changed.
 // Then we find all of our own values (I) that changed and onPrimaryInputEvent() them
-func (h *manyCollection[I, O]) onSecondaryDependencyEvent(sourceCollection collectionUID, events []Event[any]) {
+func (h *manyCollection[I, O]) onSecondaryDependencyEvent(sourceCollection collectionUID, sourceName string, events []Event[any]) {
 	// A secondary dependency changed...
 	// Got an event. Now we need to find out who depends on it..
 	changedInputKeys := h.dependencyState.changedInputKeys(sourceCollection, events)
 	h.log.Debugf("event size %v, impacts %v objects", len(events), len(changedInputKeys))
+	h.metrics.recomputeFanout.RecordInt(int64(len(changedInputKeys)))
 
 	toRun := make([]Event[I], 0, len(changedInputKeys))
 	// Now we have the set of input keys that changed. We need to recompute all of these.
@@ -632,7 +673,11 @@ func (h *manyCollection[I, O]) onSecondaryDependencyEvent(sourceCollection colle
 			})
 		}
 	}
-	h.onPrimaryInputEventLocked(toRun)
+	h.onPrimaryInputEventLocked(toRun, sourceName, func() []string {
+		return slices.Map(events, func(e Event[any]) string {
+			return GetKey(e.Latest())
+		})
+	})
 }
 
 // nolint: unused // it is used to implement interface
@@ -725,7 +770,7 @@ func (i *collectionDependencyTracker[I, O]) registerDependency(
 		syncer.WaitUntilSynced(i.stop)
 		register(func(o []Event[any]) {
 			i.queue.Push(func() error {
-				i.onSecondaryDependencyEvent(d.id, o)
+				i.onSecondaryDependencyEvent(d.id, d.collectionName, o)
 				return nil
 			})
 		}).WaitUntilSynced(i.stop)
@@ -17,12 +17,40 @@ package krt
 import (
 	"encoding/json"
 	"sync"
+	"sync/atomic"
 )
 
 // DebugHandler allows attaching a variety of collections to it and then dumping them
 type DebugHandler struct {
 	debugCollections []DebugCollection
 	mu               sync.RWMutex
+	tracer           atomic.Pointer[eventTracer]
+}
+
+// EnableTracing records the last size recomputations of the derived collections attached to the handler,
+// along with the inputs that triggered them. These can be retrieved with Trace. A size of zero disables tracing.
+func (p *DebugHandler) EnableTracing(size int) {
+	if size <= 0 {
+		p.tracer.Store(nil)
+		return
+	}
+	p.tracer.Store(newEventTracer(size))
+}
+
+// Trace returns the recorded recomputations, oldest first. See EnableTracing.
+func (p *DebugHandler) Trace() []TraceEvent {
+	t := p.activeTracer()
+	if t == nil {
+		return nil
+	}
+	return t.list()
+}
+
+func (p *DebugHandler) activeTracer() *eventTracer {
+	if p == nil {
+		return nil
+	}
+	return p.tracer.Load()
 }
 
 func (p *DebugHandler) MarshalJSON() ([]byte, error) {
@@ -0,0 +1,80 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package krt
+
+import (
+	"istio.io/istio/pkg/kube/controllers"
+	"istio.io/istio/pkg/monitoring"
+)
+
+var (
+	collectionTag = monitoring.CreateLabel("collection")
+	eventTypeTag  = monitoring.CreateLabel("type")
+
+	collectionEvents = monitoring.NewSum(
+		"krt_collection_events_total",
+		"Total number of events emitted by derived krt collections, by collection and event type.",
+	)
+
+	transformationDuration = monitoring.NewDistribution(
+		"krt_transformation_duration_seconds",
+		"Time in seconds taken by a derived krt collection to run its transformation for a single input.",
+		[]float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1},
+	)
+
+	collectionDependencies = monitoring.NewGauge(
+		"krt_collection_dependencies",
+		"Total number of dependencies fetched by the inputs of a derived krt collection.",
+	)
+
+	recomputeFanout = monitoring.NewDistribution(
+		"krt_recompute_fanout",
+		"Number of inputs of a derived krt collection recomputed because of a change to one of its dependencies.",
+		[]float64{0, 1, 10, 100, 1000, 10000},
+	)
+)
+
+// collectionMetrics holds the metrics of a single collection, with their labels already applied.
+type collectionMetrics struct {
+	adds                   monitoring.Metric
+	updates                monitoring.Metric
+	deletes                monitoring.Metric
+	transformationDuration monitoring.Metric
+	dependencies           monitoring.Metric
+	recomputeFanout        monitoring.Metric
+}
+
+func newCollectionMetrics(name string) collectionMetrics {
+	c := collectionTag.Value(name)
+	return collectionMetrics{
+		adds:                   collectionEvents.With(c, eventTypeTag.Value(controllers.EventAdd.String())),
+		updates:                collectionEvents.With(c, eventTypeTag.Value(controllers.EventUpdate.String())),
+		deletes:                collectionEvents.With(c, eventTypeTag.Value(controllers.EventDelete.String())),
+		transformationDuration: transformationDuration.With(c),
+		dependencies:           collectionDependencies.With(c),
+		recomputeFanout:        recomputeFanout.With(c),
+	}
+}
+
+func (m collectionMetrics) recordEvent(e controllers.EventType) {
+	switch e {
+	case controllers.EventAdd:
+		m.adds.Increment()
+	case controllers.EventUpdate:
+		m.updates.Increment()
+	case controllers.EventDelete:
+		m.deletes.Increment()
+	}
+}
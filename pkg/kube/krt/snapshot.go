@@ -0,0 +1,186 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package krt
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"istio.io/istio/pkg/file"
+	"istio.io/istio/pkg/kube/controllers"
+	"istio.io/istio/pkg/ptr"
+	"istio.io/istio/pkg/slices"
+)
+
+// snapshotVersion is the version of the snapshot file format. Snapshots with a different version are ignored.
+const snapshotVersion = 1
+
+type snapshotFile[T any] struct {
+	Version    int    `json:"version"`
+	Collection string `json:"collection"`
+	Objects    []T    `json:"objects"`
+}
+
+// NewSnapshotCollection wraps a collection, persisting its state to a local file so it can be served again right
+// away the next time the process starts, rather than waiting for the collection to be rebuilt from its inputs.
+//
+// On creation, the objects are restored from the snapshot at path, and the returned collection is immediately
+// synced with them. Once the live collection is synced, the returned collection reconciles to its state, emitting
+// events for the objects that changed since the snapshot was taken, and follows it from then on.
+// If there is no usable snapshot, the returned collection is only synced once the live collection is.
+//
+// The snapshot is written once the live collection is synced, then every interval if it changed, and when the
+// collection is stopped. Objects are stored as JSON, so T must round trip through encoding/json; types that do not
+// (such as protobuf messages) should implement json.Marshaler and json.Unmarshaler.
+//
+// As restored objects may be stale, this is only suitable for collections where serving slightly outdated state
+// for a short time is preferable to serving no state at all.
+func NewSnapshotCollection[T any](live Collection[T], path string, interval time.Duration, opts ...CollectionOption) Collection[T] {
+	o := buildCollectionOptions(opts...)
+	if o.name == "" {
+		o.name = fmt.Sprintf("Snapshot[%v]", ptr.TypeName[T]())
+	}
+	opts = append(opts, WithName(o.name))
+
+	restored, err := readSnapshot[T](path, o.name)
+	if err != nil {
+		log.Warnf("%v: failed to restore snapshot %v: %v", o.name, path, err)
+	}
+	synced := make(chan struct{})
+	var syncer Syncer = channelSyncer{name: o.name, synced: synced}
+	if restored != nil {
+		log.Infof("%v: restored %d objects from snapshot %v", o.name, len(restored), path)
+		syncer = alwaysSynced{}
+	}
+	c := NewStaticCollection[T](syncer, restored, opts...)
+
+	s := &snapshotter[T]{
+		live:       live,
+		collection: c,
+		path:       path,
+		name:       o.name,
+	}
+	go s.run(o.stop, interval, synced)
+	return c
+}
+
+type snapshotter[T any] struct {
+	live       Collection[T]
+	collection StaticCollection[T]
+	path       string
+	name       string
+	dirty      atomic.Bool
+}
+
+func (s *snapshotter[T]) run(stop <-chan struct{}, interval time.Duration, synced chan struct{}) {
+	if !s.live.WaitUntilSynced(stop) {
+		return
+	}
+	// The initial events update the restored objects to their live state, unless they are unchanged.
+	reg := s.live.RegisterBatch(func(events []Event[T]) {
+		for _, e := range events {
+			if e.Event == controllers.EventDelete {
+				s.collection.DeleteObject(GetKey(*e.Old))
+			} else {
+				s.collection.ConditionalUpdateObject(*e.New)
+			}
+		}
+		s.dirty.Store(true)
+	}, true)
+	if !reg.WaitUntilSynced(stop) {
+		return
+	}
+	// Objects that were restored but no longer exist were not part of the initial events.
+	s.collection.DeleteObjects(func(obj T) bool {
+		return s.live.GetKey(GetKey(obj)) == nil
+	})
+	close(synced)
+	log.Infof("%v: reconciled snapshot with live state", s.name)
+	s.save()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if s.dirty.Load() {
+				s.save()
+			}
+		case <-stop:
+			if s.dirty.Load() {
+				s.save()
+			}
+			return
+		}
+	}
+}
+
+func (s *snapshotter[T]) save() {
+	s.dirty.Store(false)
+	objects := s.collection.List()
+	slices.SortFunc(objects, func(a, b T) int {
+		return cmp.Compare(GetKey(a), GetKey(b))
+	})
+	if err := writeSnapshot(s.path, s.name, objects); err != nil {
+		log.Warnf("%v: failed to write snapshot %v: %v", s.name, s.path, err)
+		return
+	}
+	log.Debugf("%v: wrote %d objects to snapshot %v", s.name, len(objects), s.path)
+}
+
+// readSnapshot reads the objects from the snapshot at path. If there is no snapshot, nil is returned.
+func readSnapshot[T any](path string, collection string) ([]T, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshot snapshotFile[T]
+	if err := json.Unmarshal(b, &snapshot); err != nil {
+		return nil, err
+	}
+	if snapshot.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported version %d", snapshot.Version)
+	}
+	if snapshot.Collection != collection {
+		return nil, fmt.Errorf("snapshot is for collection %q", snapshot.Collection)
+	}
+	if snapshot.Objects == nil {
+		// An empty snapshot is still a valid state
+		snapshot.Objects = []T{}
+	}
+	return snapshot.Objects, nil
+}
+
+func writeSnapshot[T any](path string, collection string, objects []T) error {
+	b, err := json.Marshal(snapshotFile[T]{
+		Version:    snapshotVersion,
+		Collection: collection,
+		Objects:    objects,
+	})
+	if err != nil {
+		return err
+	}
+	return file.AtomicWrite(path, b, 0o600)
+}
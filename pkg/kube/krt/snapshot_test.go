@@ -0,0 +1,121 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package krt_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/krt"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+// manualSyncer is a krt.Syncer which is synced once closed
+type manualSyncer chan struct{}
+
+func (m manualSyncer) WaitUntilSynced(stop <-chan struct{}) bool {
+	return kube.WaitForCacheSync("manual", stop, m.HasSynced)
+}
+
+func (m manualSyncer) HasSynced() bool {
+	select {
+	case <-m:
+		return true
+	default:
+		return false
+	}
+}
+
+func sortedPods(c krt.Collection[SizedPod]) func() []SizedPod {
+	return func() []SizedPod {
+		return slices.SortBy(c.List(), func(p SizedPod) string {
+			return p.ResourceName()
+		})
+	}
+}
+
+func TestSnapshotCollection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pods.json")
+	a := SizedPod{Named: Named{"ns", "a"}, Size: "small"}
+	b := SizedPod{Named: Named{"ns", "b"}, Size: "small"}
+	c := SizedPod{Named: Named{"ns", "c"}, Size: "small"}
+	d := SizedPod{Named: Named{"ns", "d"}, Size: "small"}
+
+	// First start: there is no snapshot, so we wait for the live state
+	stop := make(chan struct{})
+	opts := krt.NewOptionsBuilder(stop, "test", nil)
+	synced := make(manualSyncer)
+	live := krt.NewStaticCollection[SizedPod](synced, []SizedPod{a, b}, opts.WithName("live")...)
+	snap := krt.NewSnapshotCollection[SizedPod](live, path, time.Hour, opts.WithName("snapshot")...)
+	assert.Equal(t, snap.HasSynced(), false)
+	close(synced)
+	assert.Equal(t, snap.WaitUntilSynced(test.NewStop(t)), true)
+	assert.Equal(t, sortedPods(snap)(), []SizedPod{a, b})
+	assert.EventuallyEqual(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, true)
+
+	live.UpdateObject(c)
+	assert.EventuallyEqual(t, sortedPods(snap), []SizedPod{a, b, c})
+	// The latest state is persisted on stop
+	before, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	close(stop)
+	assert.EventuallyEqual(t, func() bool {
+		after, _ := os.ReadFile(path)
+		return string(after) != string(before)
+	}, true)
+
+	// Restart: the snapshot is served until the live state is synced
+	stop = make(chan struct{})
+	t.Cleanup(func() { close(stop) })
+	opts = krt.NewOptionsBuilder(stop, "test", nil)
+	synced = make(manualSyncer)
+	changed := SizedPod{Named: Named{"ns", "c"}, Size: "large"}
+	live = krt.NewStaticCollection[SizedPod](synced, []SizedPod{b, changed, d}, opts.WithName("live")...)
+	snap = krt.NewSnapshotCollection[SizedPod](live, path, time.Hour, opts.WithName("snapshot")...)
+	assert.Equal(t, snap.HasSynced(), true)
+	assert.Equal(t, sortedPods(snap)(), []SizedPod{a, b, c})
+
+	tracker := assert.NewTracker[string](t)
+	snap.Register(TrackerHandler[SizedPod](tracker))
+	tracker.WaitUnordered("add/ns/a", "add/ns/b", "add/ns/c")
+
+	// Once synced, only the differences are sent
+	close(synced)
+	tracker.WaitUnordered("update/ns/c", "add/ns/d", "delete/ns/a")
+	assert.Equal(t, sortedPods(snap)(), []SizedPod{b, changed, d})
+	tracker.Empty()
+}
+
+func TestSnapshotCollectionMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pods.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"version":1,"collection":"other","objects":[]}`), 0o600))
+
+	opts := testOptions(t)
+	synced := make(manualSyncer)
+	live := krt.NewStaticCollection[SizedPod](synced, nil, opts.WithName("live")...)
+	snap := krt.NewSnapshotCollection[SizedPod](live, path, time.Hour, opts.WithName("snapshot")...)
+	// A snapshot of another collection is not used
+	assert.Equal(t, snap.HasSynced(), false)
+	close(synced)
+	assert.Equal(t, snap.WaitUntilSynced(test.NewStop(t)), true)
+}
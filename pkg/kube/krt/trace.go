@@ -0,0 +1,70 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package krt
+
+import (
+	"sync"
+	"time"
+)
+
+// TraceEvent records a single recomputation of a derived collection: what triggered it, and the events it emitted.
+type TraceEvent struct {
+	Time time.Time `json:"time"`
+	// Collection is the name of the recomputed collection
+	Collection string `json:"collection"`
+	// Trigger is the name of the collection whose change caused the recomputation. This is either the input collection,
+	// or a collection fetched by the transformation.
+	Trigger string `json:"trigger"`
+	// TriggerKeys are the keys of the changed objects in the Trigger collection
+	TriggerKeys []string `json:"triggerKeys,omitempty"`
+	// Recomputed are the keys of the inputs the transformation was run for
+	Recomputed []string `json:"recomputed,omitempty"`
+	// Events are the resulting events, in the form <type>/<key>
+	Events []string `json:"events,omitempty"`
+	// Duration is the time taken to run the transformations
+	Duration time.Duration `json:"duration"`
+}
+
+// eventTracer is a ring buffer holding the latest TraceEvents.
+type eventTracer struct {
+	mu     sync.Mutex
+	events []TraceEvent
+	next   int
+	full   bool
+}
+
+func newEventTracer(size int) *eventTracer {
+	return &eventTracer{events: make([]TraceEvent, size)}
+}
+
+func (t *eventTracer) record(e TraceEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events[t.next] = e
+	t.next = (t.next + 1) % len(t.events)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// list returns the recorded events, oldest first.
+func (t *eventTracer) list() []TraceEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.full {
+		return append([]TraceEvent{}, t.events[:t.next]...)
+	}
+	return append(append([]TraceEvent{}, t.events[t.next:]...), t.events[:t.next]...)
+}
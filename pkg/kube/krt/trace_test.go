@@ -0,0 +1,73 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package krt_test
+
+import (
+	"testing"
+
+	"istio.io/istio/pkg/kube/krt"
+	"istio.io/istio/pkg/monitoring/monitortest"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestCollectionTracingAndMetrics(t *testing.T) {
+	mt := monitortest.New(t)
+	debugger := new(krt.DebugHandler)
+	debugger.EnableTracing(3)
+	opts := krt.NewOptionsBuilder(test.NewStop(t), "trace", debugger)
+
+	inputs := krt.NewStaticCollection[Named](nil, []Named{{"ns", "a"}, {"ns", "b"}}, opts.WithName("inputs")...)
+	sizes := krt.NewStaticCollection[SizedPod](nil, []SizedPod{
+		{Named: Named{"ns", "a"}, Size: "small"},
+		{Named: Named{"ns", "b"}, Size: "small"},
+	}, opts.WithName("sizes")...)
+	outputs := krt.NewCollection(inputs, func(ctx krt.HandlerContext, i Named) *SizedPod {
+		return krt.FetchOne(ctx, sizes, krt.FilterKey(i.ResourceName()))
+	}, opts.WithName("outputs")...)
+	outputs.WaitUntilSynced(test.NewStop(t))
+	assert.EventuallyEqual(t, func() int { return len(outputs.List()) }, 2)
+
+	sizes.UpdateObject(SizedPod{Named: Named{"ns", "a"}, Size: "large"})
+	assert.EventuallyEqual(t, func() string {
+		return outputs.GetKey("ns/a").Size
+	}, "large")
+
+	trace := debugger.Trace()
+	last := trace[len(trace)-1]
+	assert.Equal(t, last.Collection, "trace/outputs")
+	assert.Equal(t, last.Trigger, "trace/sizes")
+	assert.Equal(t, last.TriggerKeys, []string{"ns/a"})
+	assert.Equal(t, last.Recomputed, []string{"ns/a"})
+	assert.Equal(t, last.Events, []string{"update/ns/a"})
+
+	mt.Assert("krt_collection_events_total", map[string]string{"collection": "trace/outputs", "type": "add"}, monitortest.Exactly(2))
+	mt.Assert("krt_collection_events_total", map[string]string{"collection": "trace/outputs", "type": "update"}, monitortest.Exactly(1))
+	mt.Assert("krt_collection_dependencies", map[string]string{"collection": "trace/outputs"}, monitortest.Exactly(2))
+
+	// The trace is bounded, keeping the latest events
+	for _, name := range []string{"c", "d", "e"} {
+		inputs.UpdateObject(Named{"ns", name})
+	}
+	assert.EventuallyEqual(t, func() []string {
+		return slices.Map(debugger.Trace(), func(e krt.TraceEvent) string {
+			return e.Recomputed[0]
+		})
+	}, []string{"ns/c", "ns/d", "ns/e"})
+
+	debugger.EnableTracing(0)
+	assert.Equal(t, debugger.Trace(), nil)
+}
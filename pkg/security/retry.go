@@ -27,11 +27,17 @@ import (
 
 var caLog = log.RegisterScope("ca", "ca client")
 
+// caRetryMax is the maximum number of attempts of a CA call.
+const caRetryMax = 5
+
+// caRetryBackoff is the backoff between attempts of a CA call.
+var caRetryBackoff = wrapBackoffWithMetrics(retry.BackoffExponentialWithJitter(100*time.Millisecond, 0.1))
+
 // CARetryOptions returns the default retry options recommended for CA calls
 // This includes 5 retries, with backoff from 100ms -> 1.6s with jitter.
 var CARetryOptions = []retry.CallOption{
-	retry.WithMax(5),
-	retry.WithBackoff(wrapBackoffWithMetrics(retry.BackoffExponentialWithJitter(100*time.Millisecond, 0.1))),
+	retry.WithMax(caRetryMax),
+	retry.WithBackoff(caRetryBackoff),
 	retry.WithCodes(codes.Canceled, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted, codes.Internal, codes.Unavailable),
 }
 
@@ -42,6 +48,30 @@ func CARetryInterceptor() grpc.DialOption {
 	return grpc.WithUnaryInterceptor(retry.UnaryClientInterceptor(CARetryOptions...))
 }
 
+// CARetryBackoff returns the time to wait before the given retry of a CA call, following the same policy as
+// CARetryOptions. A negative duration is returned once no more attempts should be made.
+// This is intended for CA clients that do not use gRPC.
+func CARetryBackoff(attempt uint) time.Duration {
+	if attempt >= caRetryMax {
+		return -1
+	}
+	return caRetryBackoff(attempt)
+}
+
+// CARetry calls f until it succeeds, returns an error for which retryable is false, or the attempts are exhausted,
+// following the same policy as CARetryOptions. This is intended for CA clients that do not use gRPC.
+func CARetry(f func() error, retryable func(error) bool) error {
+	for attempt := uint(0); ; attempt++ {
+		if attempt > 0 {
+			time.Sleep(CARetryBackoff(attempt))
+		}
+		err := f()
+		if err == nil || !retryable(err) || attempt+1 >= caRetryMax {
+			return err
+		}
+	}
+}
+
 // grpcretry has no hooks to trigger logic on failure (https://github.com/grpc-ecosystem/go-grpc-middleware/issues/375)
 // Instead, we can wrap the backoff hook to log/increment metrics before returning the backoff result.
 func wrapBackoffWithMetrics(bf retry.BackoffFunc) retry.BackoffFunc {
@@ -108,6 +108,12 @@ const (
 	// GCE is Credential fetcher type of Google plugin
 	GCE = "GoogleComputeEngine"
 
+	// AWS is Credential fetcher type of AWS plugin, presenting the EC2 instance identity document
+	AWS = "AWSInstanceIdentity"
+
+	// Azure is Credential fetcher type of Azure plugin, presenting the managed identity token of the VM
+	Azure = "AzureManagedIdentity"
+
 	// JWT is a Credential fetcher type that reads from a JWT token file
 	JWT = "JWT"
 
@@ -123,6 +129,12 @@ const (
 	// GkeWorkloadCertificateProvider uses the GKE workload certificates
 	GkeWorkloadCertificateProvider = "GkeWorkloadCertificate"
 
+	// VaultCAProvider uses the PKI secrets engine of a Vault server for workload certificate signing
+	VaultCAProvider = "Vault"
+
+	// ACMECAProvider uses an ACME server for workload certificate signing
+	ACMECAProvider = "ACME"
+
 	// FileRootSystemCACert is a unique resource name signaling that the system CA certificate should be used
 	FileRootSystemCACert = "file-root:system"
 )
@@ -193,10 +205,10 @@ type Options struct {
 	ClusterID string
 
 	// The type of Elliptical Signature algorithm to use
-	// when generating private keys. Currently only ECDSA is supported.
+	// when generating private keys. ECDSA and ED25519 are supported.
 	ECCSigAlg string
 
-	// The type of curve to use when generating private keys with ECC. Currently only ECDSA is supported.
+	// The type of curve to use when generating private keys with ECDSA.
 	ECCCurve string
 
 	// FileMountedCerts indicates whether the proxy is using file
@@ -241,6 +253,9 @@ type Options struct {
 	// credential identity provider
 	CredIdentityProvider string
 
+	// The resource to request the Azure managed identity token for, with the AzureManagedIdentity credential fetcher.
+	AzureManagedIdentityResource string
+
 	// Namespace corresponding to workload
 	WorkloadNamespace string
 
@@ -269,6 +284,35 @@ type Options struct {
 
 	// Extra headers to add to the CA connection.
 	CAHeaders map[string]string
+
+	// VaultCA configures the Vault CA provider.
+	VaultCA VaultCAOptions
+
+	// ACMECA configures the ACME CA provider.
+	ACMECA ACMECAOptions
+}
+
+// VaultCAOptions configures the Vault CA provider. The CAEndpoint is the address of the Vault server.
+type VaultCAOptions struct {
+	// AuthPath is the mount path of the auth method used to log in with the workload token, such as "kubernetes".
+	AuthPath string
+	// Role is the role to log in as.
+	Role string
+	// SignPath is the path of the PKI sign endpoint, in the form <mount>/sign/<role>.
+	SignPath string
+	// Namespace is the Vault namespace, if any.
+	Namespace string
+}
+
+// ACMECAOptions configures the ACME CA provider. The CAEndpoint is the ACME directory URL.
+type ACMECAOptions struct {
+	// AccountKeyFile is the PEM encoded account private key. If the file does not exist, a key is generated and
+	// written to it. If unset, a new account is registered on each start.
+	AccountKeyFile string
+	// EABKeyID is the key identifier for external account binding, if required by the server.
+	EABKeyID string
+	// EABHMACKeyFile is the file holding the base64url encoded HMAC key for external account binding.
+	EABHMACKeyFile string
 }
 
 // Client interface defines the clients need to implement to talk to CA for CSR.
@@ -365,6 +409,8 @@ func (ac *AuthContext) Header(header string) []string {
 type Caller struct {
 	AuthSource AuthSource
 	Identities []string
+	// AuthenticatorType is the type of the authenticator which authenticated the caller.
+	AuthenticatorType string
 
 	KubernetesInfo KubernetesInfo
 }
@@ -402,6 +448,7 @@ func (am *authenticationManager) authenticate(ctx context.Context) *Caller {
 		u, err := authn.Authenticate(req)
 		if u != nil && len(u.Identities) > 0 && err == nil {
 			securityLog.Debugf("Authentication successful through auth source %v", u.AuthSource)
+			u.AuthenticatorType = authn.AuthenticatorType()
 			return u
 		}
 		am.authFailMsgs = append(am.authFailMsgs, fmt.Sprintf("Authenticator %s: %v", authn.AuthenticatorType(), err))
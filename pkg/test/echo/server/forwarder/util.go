@@ -17,6 +17,8 @@ package forwarder
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net"
 	"net/http"
@@ -48,6 +50,16 @@ func writeForwardedHeaders(out *bytes.Buffer, requestID int, header http.Header)
 	}
 }
 
+// hbonePool is shared by all HBONE requests, so requests through the same proxy reuse connections rather than
+// performing a TLS handshake each time.
+var hbonePool = hbone.NewPool(hbone.PoolConfig{})
+
+// hboneIdentity identifies the TLS settings of an HBONE request. Only requests with the same settings share connections.
+func hboneIdentity(r *proto.HBONE) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%v", r.GetCert(), r.GetCaCert(), r.GetInsecureSkipVerify())))
+	return hex.EncodeToString(sum[:])
+}
+
 type SpecificVersionDialer struct {
 	network string
 	inner   hbone.Dialer
@@ -70,6 +82,8 @@ func newDialer(cfg *Config) hbone.Dialer {
 			ProxyAddress: cfg.Request.DoubleHbone.GetAddress(),
 			Headers:      cfg.hboneHeaders,
 			TLS:          cfg.hboneTLSConfig,
+			Pool:         hbonePool,
+			Identity:     hboneIdentity(cfg.Request.DoubleHbone),
 		}, cfg.innerHboneTLSConfig)
 	}
 	if cfg.Request.Hbone.GetAddress() != "" {
@@ -77,6 +91,8 @@ func newDialer(cfg *Config) hbone.Dialer {
 			ProxyAddress: cfg.Request.Hbone.GetAddress(),
 			Headers:      cfg.hboneHeaders,
 			TLS:          cfg.hboneTLSConfig,
+			Pool:         hbonePool,
+			Identity:     hboneIdentity(cfg.Request.Hbone),
 		})
 		return out
 	}
@@ -0,0 +1,79 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	traceapi "go.opentelemetry.io/otel/trace"
+)
+
+// Attribute is an attribute of a span.
+type Attribute = attribute.KeyValue
+
+// String returns a string attribute.
+func String(key, value string) Attribute {
+	return attribute.String(key, value)
+}
+
+// StringSlice returns a string slice attribute.
+func StringSlice(key string, value []string) Attribute {
+	return attribute.StringSlice(key, value)
+}
+
+// Bool returns a bool attribute.
+func Bool(key string, value bool) Attribute {
+	return attribute.Bool(key, value)
+}
+
+// Int returns an int attribute.
+func Int(key string, value int) Attribute {
+	return attribute.Int(key, value)
+}
+
+// RecordedSpan is a span for work whose timing was recorded beforehand, so it starts and ends at given times.
+type RecordedSpan struct {
+	span traceapi.Span
+}
+
+// StartAt starts a span at the given time.
+func StartAt(ctx context.Context, name string, start time.Time) (context.Context, RecordedSpan) {
+	ctx, span := tracer().Start(ctx, name, traceapi.WithTimestamp(start))
+	return ctx, RecordedSpan{span: span}
+}
+
+// IsRecording returns whether the span is recorded. It is not if no tracing provider is initialized, in which case
+// setting attributes can be skipped.
+func (s RecordedSpan) IsRecording() bool {
+	return s.span.IsRecording()
+}
+
+// SetAttributes sets attributes of the span.
+func (s RecordedSpan) SetAttributes(attrs ...Attribute) {
+	s.span.SetAttributes(attrs...)
+}
+
+// SetError marks the span as failed, with the error message.
+func (s RecordedSpan) SetError(msg string) {
+	s.span.SetStatus(codes.Error, msg)
+}
+
+// EndAt ends the span at the given time.
+func (s RecordedSpan) EndAt(end time.Time) {
+	s.span.End(traceapi.WithTimestamp(end))
+}
@@ -36,8 +36,7 @@ import (
 // Most OTLP aspects are configured by Environment variables, but the actual client we use needs to be explicitly defined.
 // So we can parse the env vars ourselves and set up the correct client.
 func newExporter() (trace.SpanExporter, error) {
-	set := os.Getenv("OTEL_TRACES_EXPORTER") == "otlp" || os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
-	if !set {
+	if !ExporterConfigured() {
 		return nil, nil
 	}
 
@@ -63,6 +62,13 @@ func newExporter() (trace.SpanExporter, error) {
 	return otlptrace.New(context.Background(), c)
 }
 
+// ExporterConfigured returns whether an OTLP exporter is configured by the environment variables. Without it, the
+// spans created after Initialize are discarded.
+func ExporterConfigured() bool {
+	return os.Getenv("OTEL_TRACES_EXPORTER") == "otlp" || os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
 // newResource returns a resource describing this application.
 func newResource() *resource.Resource {
 	r, _ := resource.Merge(
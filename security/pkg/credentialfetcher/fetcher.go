@@ -22,10 +22,19 @@ import (
 	"istio.io/istio/security/pkg/credentialfetcher/plugin"
 )
 
-func NewCredFetcher(credtype, trustdomain, jwtPath, identityProvider string) (security.CredFetcher, error) {
+func NewCredFetcher(credtype, trustdomain, jwtPath, identityProvider, azureResource string) (security.CredFetcher, error) {
 	switch credtype {
 	case security.GCE:
 		return plugin.CreateGCEPlugin(trustdomain, jwtPath, identityProvider), nil
+	case security.AWS:
+		return plugin.CreateAWSPlugin(jwtPath, identityProvider), nil
+	case security.Azure:
+		// The managed identity token is requested for a resource registered in Microsoft Entra ID; the trust domain
+		// is not a valid resource.
+		if azureResource == "" {
+			return nil, fmt.Errorf("AZURE_MANAGED_IDENTITY_RESOURCE must be set for the %s credential fetcher", security.Azure)
+		}
+		return plugin.CreateAzurePlugin(azureResource, jwtPath, identityProvider), nil
 	case security.JWT, "":
 		// If unset, also default to JWT for backwards compatibility
 		if jwtPath == "" {
@@ -15,6 +15,8 @@
 package credentialfetcher
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"istio.io/istio/pkg/security"
@@ -27,6 +29,7 @@ func TestNewCredFetcher(t *testing.T) {
 		trustdomain      string
 		jwtPath          string
 		identityProvider string
+		azureResource    string
 		expectedErr      string
 		expectedToken    string
 		expectedIdp      string
@@ -40,6 +43,28 @@ func TestNewCredFetcher(t *testing.T) {
 			expectedToken:    "",
 			expectedIdp:      "GoogleComputeEngine",
 		},
+		"aws test": {
+			fetcherType:      security.AWS,
+			trustdomain:      "cluster.local",
+			jwtPath:          "/var/run/secrets/tokens/istio-token",
+			identityProvider: security.AWS,
+			expectedIdp:      "AWSInstanceIdentity",
+		},
+		"azure test": {
+			fetcherType:      security.Azure,
+			trustdomain:      "cluster.local",
+			jwtPath:          "/var/run/secrets/tokens/istio-token",
+			identityProvider: security.Azure,
+			azureResource:    "api://istio",
+			expectedIdp:      "AzureManagedIdentity",
+		},
+		"azure without resource": {
+			fetcherType:      security.Azure,
+			trustdomain:      "cluster.local",
+			jwtPath:          "/var/run/secrets/tokens/istio-token",
+			identityProvider: security.Azure,
+			expectedErr:      "AZURE_MANAGED_IDENTITY_RESOURCE must be set for the AzureManagedIdentity credential fetcher",
+		},
 		"mock test": {
 			fetcherType:      security.Mock,
 			trustdomain:      "",
@@ -66,7 +91,7 @@ func TestNewCredFetcher(t *testing.T) {
 		t.Run(id, func(t *testing.T) {
 			t.Parallel()
 			cf, err := NewCredFetcher(
-				tc.fetcherType, tc.trustdomain, tc.jwtPath, tc.identityProvider)
+				tc.fetcherType, tc.trustdomain, tc.jwtPath, tc.identityProvider, tc.azureResource)
 			if cf != nil {
 				defer cf.Stop()
 			}
@@ -100,3 +125,29 @@ func TestNewCredFetcher(t *testing.T) {
 	// Restore token refresh for other tests.
 	plugin.SetTokenRotation(true)
 }
+
+func TestNewCredFetcherAzureResource(t *testing.T) {
+	plugin.SetTokenRotation(false)
+	defer plugin.SetTokenRotation(true)
+	ms, err := plugin.StartAzureMetadataServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		ms.Stop()
+		os.Unsetenv(plugin.AzureMetadataEndpointEnv)
+	}()
+
+	cf, err := NewCredFetcher(security.Azure, "cluster.local", filepath.Join(t.TempDir(), "istio-token"), security.Azure, "api://istio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cf.Stop()
+	if _, err := cf.GetPlatformCredential(); err != nil {
+		t.Fatal(err)
+	}
+	// The configured resource is requested, not the trust domain
+	if got := ms.Resource(); got != "api://istio" {
+		t.Errorf("requested resource %q, expected %q", got, "api://istio")
+	}
+}
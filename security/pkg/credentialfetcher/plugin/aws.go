@@ -0,0 +1,135 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This is AWS plugin of credentialfetcher.
+
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/http"
+	"istio.io/istio/pkg/log"
+)
+
+var awscredLog = log.RegisterScope("awscred", "AWS credential fetcher for istio agent")
+
+const (
+	// AWSMetadataEndpointEnv overrides the address of the EC2 instance metadata service, as in the AWS SDKs.
+	AWSMetadataEndpointEnv = "AWS_EC2_METADATA_SERVICE_ENDPOINT"
+	awsMetadataEndpoint    = "http://169.254.169.254"
+
+	awsTokenPath   = "/latest/api/token"
+	awsPKCS7Path   = "/latest/dynamic/instance-identity/pkcs7"
+	awsTokenTTL    = 6 * time.Hour
+	awsIMDSTimeout = 5 * time.Second
+)
+
+// AWSPlugin is the plugin object.
+type AWSPlugin struct {
+	// endpoint is the address of the instance metadata service.
+	endpoint string
+
+	// The location to save the identity document
+	jwtPath string
+
+	// identity provider
+	identityProvider string
+
+	// IMDSv2 session token, and its expiration time.
+	sessionToken  string
+	sessionExpiry time.Time
+	mutex         sync.Mutex
+}
+
+// CreateAWSPlugin creates an AWS credential fetcher plugin. Return the pointer to the created plugin.
+func CreateAWSPlugin(jwtPath, identityProvider string) *AWSPlugin {
+	endpoint := awsMetadataEndpoint
+	if e := os.Getenv(AWSMetadataEndpointEnv); e != "" {
+		endpoint = strings.TrimSuffix(e, "/")
+	}
+	return &AWSPlugin{
+		endpoint:         endpoint,
+		jwtPath:          jwtPath,
+		identityProvider: identityProvider,
+	}
+}
+
+// GetPlatformCredential fetches the PKCS7 signed instance identity document of the EC2 instance from
+// the instance metadata service, using IMDSv2, and writes it to jwtPath.
+// The signature embeds the identity document, so it is the only credential to present: the verifier checks it
+// against the AWS public certificate for the region and reads the instance ID, account and region from it.
+// Note: this function only works in an EC2 instance environment.
+func (p *AWSPlugin) GetPlatformCredential() (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.jwtPath == "" {
+		return "", fmt.Errorf("jwtPath is unset")
+	}
+	token, err := p.getSessionToken()
+	if err != nil {
+		awscredLog.Errorf("Failed to get IMDSv2 session token from metadata service: %v", err)
+		return "", err
+	}
+	resp, err := http.GET(p.endpoint+awsPKCS7Path, awsIMDSTimeout, map[string]string{
+		"X-aws-ec2-metadata-token": token,
+	})
+	if err != nil {
+		// The token may have been invalidated, for instance if the instance was stopped
+		p.sessionToken = ""
+		awscredLog.Errorf("Failed to get instance identity document from metadata service: %v", err)
+		return "", err
+	}
+	// The signature is base64 encoded, split over several lines.
+	credential := strings.Join(strings.Fields(resp.String()), "")
+	if credential == "" {
+		return "", fmt.Errorf("empty instance identity document")
+	}
+	awscredLog.Debugf("Got AWS instance identity document: %d", len(credential))
+	if err := os.WriteFile(p.jwtPath, []byte(credential), 0o640); err != nil {
+		awscredLog.Errorf("Encountered error when writing instance identity document: %v", err)
+		return "", err
+	}
+	return credential, nil
+}
+
+// getSessionToken returns the IMDSv2 session token, requesting a new one if it is about to expire.
+func (p *AWSPlugin) getSessionToken() (string, error) {
+	if p.sessionToken != "" && time.Now().Before(p.sessionExpiry) {
+		return p.sessionToken, nil
+	}
+	resp, err := http.PUT(p.endpoint+awsTokenPath, awsIMDSTimeout, map[string]string{
+		"X-aws-ec2-metadata-token-ttl-seconds": strconv.Itoa(int(awsTokenTTL.Seconds())),
+	})
+	if err != nil {
+		return "", err
+	}
+	p.sessionToken = strings.TrimSpace(resp.String())
+	// Renew the token ahead of its expiration
+	p.sessionExpiry = time.Now().Add(awsTokenTTL - time.Minute)
+	return p.sessionToken, nil
+}
+
+// GetIdentityProvider returns the name of the identity provider that can authenticate the workload credential.
+func (p *AWSPlugin) GetIdentityProvider() string {
+	return p.identityProvider
+}
+
+func (p *AWSPlugin) Stop() {}
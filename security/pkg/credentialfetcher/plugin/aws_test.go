@@ -0,0 +1,69 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestAWSPlugin(t *testing.T) {
+	document := strings.Repeat("MIAGCSqGSIb3DQEHAqCAMIACAQExCzAJBgUrDgMCGgUAMIAGCSqGSIb3DQEHAaCA", 3) + "JIAEggHbewog"
+	ms, err := StartAWSMetadataServer(document)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		ms.Stop()
+		os.Unsetenv(AWSMetadataEndpointEnv)
+	})
+
+	jwtPath := filepath.Join(t.TempDir(), "istio-token")
+	p := CreateAWSPlugin(jwtPath, security.AWS)
+	defer p.Stop()
+	assert.Equal(t, p.GetIdentityProvider(), security.AWS)
+
+	for range 2 {
+		credential, err := p.GetPlatformCredential()
+		assert.NoError(t, err)
+		// The line breaks of the signature are removed
+		assert.Equal(t, credential, document)
+		written, err := os.ReadFile(jwtPath)
+		assert.NoError(t, err)
+		assert.Equal(t, string(written), document)
+	}
+	// The session token is reused
+	assert.Equal(t, ms.NumGetSessionTokenCall(), 1)
+	assert.Equal(t, ms.NumGetDocumentCall(), 2)
+
+	// A rejected session token is renewed on the next request
+	ms.ExpireSessionToken()
+	_, err = p.GetPlatformCredential()
+	assert.Error(t, err)
+	credential, err := p.GetPlatformCredential()
+	assert.NoError(t, err)
+	assert.Equal(t, credential, document)
+	assert.Equal(t, ms.NumGetSessionTokenCall(), 2)
+}
+
+func TestAWSPluginNoJWTPath(t *testing.T) {
+	p := CreateAWSPlugin("", security.AWS)
+	if _, err := p.GetPlatformCredential(); err == nil || err.Error() != "jwtPath is unset" {
+		t.Fatalf("expected jwtPath error, got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This is Azure plugin of credentialfetcher.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/http"
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/security/pkg/util"
+)
+
+var azurecredLog = log.RegisterScope("azurecred", "Azure credential fetcher for istio agent")
+
+const (
+	// AzureMetadataEndpointEnv overrides the address of the Azure instance metadata service.
+	AzureMetadataEndpointEnv = "AZURE_METADATA_ENDPOINT"
+	azureMetadataEndpoint    = "http://169.254.169.254"
+
+	azureTokenPath       = "/metadata/identity/oauth2/token"
+	azureTokenAPIVersion = "2018-02-01"
+	azureIMDSTimeout     = 5 * time.Second
+)
+
+// AzurePlugin is the plugin object.
+type AzurePlugin struct {
+	// endpoint is the address of the instance metadata service.
+	endpoint string
+
+	// aud is the resource the managed identity token is requested for, which must be agreed upon by
+	// both the instance and the system verifying the instance's identity.
+	aud string
+
+	// The location to save the identity token
+	jwtPath string
+
+	// identity provider
+	identityProvider string
+
+	// token refresh
+	rotationTicker *time.Ticker
+	closing        chan bool
+	tokenCache     string
+	// mutex lock is required to avoid race condition when updating token file and token cache.
+	tokenMutex sync.RWMutex
+}
+
+// CreateAzurePlugin creates an Azure credential fetcher plugin. Return the pointer to the created plugin.
+func CreateAzurePlugin(audience, jwtPath, identityProvider string) *AzurePlugin {
+	endpoint := azureMetadataEndpoint
+	if e := os.Getenv(AzureMetadataEndpointEnv); e != "" {
+		endpoint = strings.TrimSuffix(e, "/")
+	}
+	p := &AzurePlugin{
+		endpoint:         endpoint,
+		aud:              audience,
+		jwtPath:          jwtPath,
+		identityProvider: identityProvider,
+		closing:          make(chan bool),
+	}
+	if rotateToken {
+		go p.startTokenRotationJob()
+	}
+	return p
+}
+
+func (p *AzurePlugin) Stop() {
+	close(p.closing)
+}
+
+func (p *AzurePlugin) startTokenRotationJob() {
+	// Wake up once in a while and refresh the managed identity token.
+	p.rotationTicker = time.NewTicker(rotationInterval)
+	for {
+		select {
+		case <-p.rotationTicker.C:
+			p.rotate()
+		case <-p.closing:
+			if p.rotationTicker != nil {
+				p.rotationTicker.Stop()
+			}
+			return
+		}
+	}
+}
+
+func (p *AzurePlugin) rotate() {
+	if p.shouldRotate(time.Now()) {
+		if _, err := p.GetPlatformCredential(); err != nil {
+			azurecredLog.Errorf("credential refresh failed: %+v", err)
+		}
+	}
+}
+
+func (p *AzurePlugin) shouldRotate(now time.Time) bool {
+	p.tokenMutex.RLock()
+	defer p.tokenMutex.RUnlock()
+
+	if p.tokenCache == "" {
+		return true
+	}
+	exp, err := util.GetExp(p.tokenCache)
+	// When fails to get expiration time from token, always refresh the token.
+	if err != nil || exp.IsZero() {
+		return true
+	}
+	rotate := now.After(exp.Add(-gracePeriod))
+	azurecredLog.Debugf("credential expiration: %s, grace period: %s, should rotate: %t",
+		exp.String(), gracePeriod.String(), rotate)
+	return rotate
+}
+
+type azureTokenResponse struct {
+	AccessToken string `json:"access_token"`
+}
+
+// GetPlatformCredential fetches the managed identity token of the Azure VM from the instance metadata service,
+// and write it to jwtPath.
+// Note: this function only works in an Azure VM environment, with a managed identity assigned to the VM.
+func (p *AzurePlugin) GetPlatformCredential() (string, error) {
+	p.tokenMutex.Lock()
+	defer p.tokenMutex.Unlock()
+
+	if p.jwtPath == "" {
+		return "", fmt.Errorf("jwtPath is unset")
+	}
+	query := url.Values{}
+	query.Set("api-version", azureTokenAPIVersion)
+	query.Set("resource", p.aud)
+	resp, err := http.GET(p.endpoint+azureTokenPath+"?"+query.Encode(), azureIMDSTimeout, map[string]string{
+		"Metadata": "true",
+	})
+	if err != nil {
+		azurecredLog.Errorf("Failed to get managed identity token from metadata service: %v", err)
+		return "", err
+	}
+	var token azureTokenResponse
+	if err := json.Unmarshal(resp.Bytes(), &token); err != nil {
+		return "", fmt.Errorf("invalid managed identity token response: %v", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("empty managed identity token")
+	}
+	// Update token cache.
+	p.tokenCache = token.AccessToken
+	azurecredLog.Debugf("Got Azure managed identity token: %d", len(token.AccessToken))
+	if err := os.WriteFile(p.jwtPath, []byte(token.AccessToken), 0o640); err != nil {
+		azurecredLog.Errorf("Encountered error when writing managed identity token: %v", err)
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// GetIdentityProvider returns the name of the identity provider that can authenticate the workload credential.
+func (p *AzurePlugin) GetIdentityProvider() string {
+	return p.identityProvider
+}
@@ -0,0 +1,81 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+func startAzureMetadataServer(t *testing.T) *AzureMetadataServer {
+	ms, err := StartAzureMetadataServer()
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		ms.Stop()
+		os.Unsetenv(AzureMetadataEndpointEnv)
+	})
+	return ms
+}
+
+func TestAzurePlugin(t *testing.T) {
+	SetTokenRotation(false)
+	t.Cleanup(func() {
+		SetTokenRotation(true)
+	})
+	ms := startAzureMetadataServer(t)
+	ms.setToken(thirdPartyJwt)
+
+	jwtPath := filepath.Join(t.TempDir(), "istio-token")
+	p := CreateAzurePlugin("api://istio", jwtPath, security.Azure)
+	defer p.Stop()
+	assert.Equal(t, p.GetIdentityProvider(), security.Azure)
+
+	token, err := p.GetPlatformCredential()
+	assert.NoError(t, err)
+	assert.Equal(t, token, thirdPartyJwt)
+	assert.Equal(t, ms.Resource(), "api://istio")
+	written, err := os.ReadFile(jwtPath)
+	assert.NoError(t, err)
+	assert.Equal(t, string(written), thirdPartyJwt)
+	// thirdPartyJwt is expired
+	assert.Equal(t, p.shouldRotate(time.Now()), true)
+}
+
+func TestAzurePluginTokenRotation(t *testing.T) {
+	rotationInterval = 100 * time.Millisecond
+	SetTokenRotation(true)
+	t.Cleanup(func() {
+		rotationInterval = 5 * time.Minute
+	})
+	ms := startAzureMetadataServer(t)
+
+	jwtPath := filepath.Join(t.TempDir(), "istio-token")
+	p := CreateAzurePlugin("api://istio", jwtPath, security.Azure)
+	defer p.Stop()
+
+	// Tokens without expiration are always refreshed
+	retry.UntilOrFail(t, func() bool {
+		return ms.NumGetTokenCall() >= 3
+	}, retry.Timeout(5*time.Second))
+	written, err := os.ReadFile(jwtPath)
+	assert.NoError(t, err)
+	assert.Equal(t, string(written)[:len(fakeTokenPrefix)], fakeTokenPrefix)
+}
@@ -121,3 +121,158 @@ func (ms *MetadataServer) getToken(w http.ResponseWriter, req *http.Request) {
 func (ms *MetadataServer) Stop() {
 	ms.server.Close()
 }
+
+const awsSessionToken = "fake-session-token"
+
+// AWSMetadataServer mocks the EC2 instance metadata service. Only IMDSv2 requests are accepted.
+type AWSMetadataServer struct {
+	server *httptest.Server
+
+	numGetSessionTokenCall int
+	numGetDocumentCall     int
+	sessionToken           string
+	document               string
+	mutex                  sync.RWMutex
+}
+
+// StartAWSMetadataServer starts a mock EC2 instance metadata service, returning the PKCS7 signature document.
+func StartAWSMetadataServer(document string) (*AWSMetadataServer, error) {
+	ms := &AWSMetadataServer{sessionToken: awsSessionToken, document: document}
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT "+awsTokenPath, ms.getSessionToken)
+	mux.HandleFunc("GET "+awsPKCS7Path, ms.getDocument)
+	ms.server = httptest.NewServer(mux)
+	if err := os.Setenv(AWSMetadataEndpointEnv, ms.server.URL); err != nil {
+		ms.Stop()
+		return nil, err
+	}
+	return ms, nil
+}
+
+// NumGetSessionTokenCall returns the number of IMDSv2 session token requests.
+func (ms *AWSMetadataServer) NumGetSessionTokenCall() int {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	return ms.numGetSessionTokenCall
+}
+
+// NumGetDocumentCall returns the number of instance identity document requests.
+func (ms *AWSMetadataServer) NumGetDocumentCall() int {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	return ms.numGetDocumentCall
+}
+
+// ExpireSessionToken invalidates the session tokens issued so far.
+func (ms *AWSMetadataServer) ExpireSessionToken() {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.sessionToken = fmt.Sprintf("%s-%d", awsSessionToken, ms.numGetSessionTokenCall)
+}
+
+func (ms *AWSMetadataServer) getSessionToken(w http.ResponseWriter, req *http.Request) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	if req.Header.Get("X-aws-ec2-metadata-token-ttl-seconds") == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	ms.numGetSessionTokenCall++
+	fmt.Fprint(w, ms.sessionToken)
+}
+
+func (ms *AWSMetadataServer) getDocument(w http.ResponseWriter, req *http.Request) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	if req.Header.Get("X-aws-ec2-metadata-token") != ms.sessionToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	ms.numGetDocumentCall++
+	// Like the real service, split the signature over lines of 64 characters.
+	for doc := ms.document; doc != ""; {
+		n := min(64, len(doc))
+		fmt.Fprintln(w, doc[:n])
+		doc = doc[n:]
+	}
+}
+
+func (ms *AWSMetadataServer) Stop() {
+	ms.server.Close()
+}
+
+// AzureMetadataServer mocks the managed identity token endpoint of the Azure instance metadata service.
+type AzureMetadataServer struct {
+	server *httptest.Server
+
+	numGetTokenCall int
+	credential      string
+	resource        string
+	mutex           sync.RWMutex
+}
+
+// StartAzureMetadataServer starts a mock Azure instance metadata service.
+func StartAzureMetadataServer() (*AzureMetadataServer, error) {
+	ms := &AzureMetadataServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+azureTokenPath, ms.getToken)
+	ms.server = httptest.NewServer(mux)
+	if err := os.Setenv(AzureMetadataEndpointEnv, ms.server.URL); err != nil {
+		ms.Stop()
+		return nil, err
+	}
+	return ms, nil
+}
+
+func (ms *AzureMetadataServer) setToken(t string) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.credential = t
+}
+
+// NumGetTokenCall returns the number of token fetching request.
+func (ms *AzureMetadataServer) NumGetTokenCall() int {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	return ms.numGetTokenCall
+}
+
+// Resource returns the resource of the last token fetching request.
+func (ms *AzureMetadataServer) Resource() string {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	return ms.resource
+}
+
+// Reset resets members to default values.
+func (ms *AzureMetadataServer) Reset() {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	ms.numGetTokenCall = 0
+	ms.credential = ""
+	ms.resource = ""
+}
+
+func (ms *AzureMetadataServer) getToken(w http.ResponseWriter, req *http.Request) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	if req.Header.Get("Metadata") != "true" || req.URL.Query().Get("api-version") == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	ms.numGetTokenCall++
+	ms.resource = req.URL.Query().Get("resource")
+	token := fmt.Sprintf("%s%d", fakeTokenPrefix, ms.numGetTokenCall)
+	if ms.credential != "" {
+		token = ms.credential
+	}
+	fmt.Fprintf(w, `{"access_token":%q,"token_type":"Bearer","resource":%q}`, token, ms.resource)
+}
+
+func (ms *AzureMetadataServer) Stop() {
+	ms.server.Close()
+}
@@ -16,6 +16,8 @@ package cache
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"crypto/tls"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -754,6 +756,26 @@ func TestProxyConfigAnchorsTriggerWorkloadCertUpdate(t *testing.T) {
 	u.Expect(map[string]int{security.RootCertReqResourceName: 2, security.WorkloadKeyCertResourceName: 1})
 }
 
+func TestWorkloadAgentGenerateSecretEd25519(t *testing.T) {
+	fakeCACli, err := mock.NewMockCAClient(time.Hour, true)
+	if err != nil {
+		t.Fatalf("Error creating Mock CA client: %v", err)
+	}
+	sc := createCache(t, fakeCACli, func(resourceName string) {}, security.Options{ECCSigAlg: string(pkiutil.Ed25519SigAlg)})
+
+	secret, err := sc.GenerateSecret(security.WorkloadKeyCertResourceName)
+	if err != nil {
+		t.Fatalf("failed to get secrets: %v", err)
+	}
+	cert, err := tls.X509KeyPair(secret.CertificateChain, secret.PrivateKey)
+	if err != nil {
+		t.Fatalf("invalid key pair: %v", err)
+	}
+	if _, ok := cert.PrivateKey.(ed25519.PrivateKey); !ok {
+		t.Fatalf("unexpected private key type %T", cert.PrivateKey)
+	}
+}
+
 func TestOSCACertGenerateSecret(t *testing.T) {
 	fakeCACli, err := mock.NewMockCAClient(time.Hour, false)
 	if err != nil {
@@ -0,0 +1,92 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// TLSOptions configures the TLS connection to a CA using an HTTP API.
+type TLSOptions struct {
+	// RootCert is the file with the roots to verify the CA with. If empty, the system roots are used.
+	RootCert string
+	// Key and Cert are the files with the client certificate to present to the CA, if any.
+	Key  string
+	Cert string
+}
+
+// NewHTTPClient creates an HTTP client for a CA using an HTTP API.
+func NewHTTPClient(tlsOpts *TLSOptions) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if tlsOpts != nil {
+		if tlsOpts.RootCert != "" {
+			rootCert, err := os.ReadFile(tlsOpts.RootCert)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read root cert: %v", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(rootCert) {
+				return nil, fmt.Errorf("no certificates found in %s", tlsOpts.RootCert)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if tlsOpts.Key != "" && tlsOpts.Cert != "" {
+			tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				// Load for each connection, as the certificate may be rotated
+				cert, err := tls.LoadX509KeyPair(tlsOpts.Cert, tlsOpts.Key)
+				if err != nil {
+					return nil, err
+				}
+				return &cert, nil
+			}
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{
+		Transport: transport,
+		Timeout:   30 * time.Second,
+	}, nil
+}
+
+// HTTPError is returned when a CA using an HTTP API responds with an unexpected status.
+type HTTPError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, e.Message)
+}
+
+// IsRetryable returns whether a request to a CA using an HTTP API failing with err should be retried.
+// Network errors, rate limiting and server errors are retried.
+func IsRetryable(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,243 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acme
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+
+	"istio.io/istio/pkg/file"
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/security/pkg/nodeagent/caclient"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
+)
+
+var acmeClientLog = log.RegisterScope("acmeclient", "acme client debugging")
+
+// uriIdentifier is the ACME identifier type used for URI SANs, such as the SPIFFE identity of the workload.
+const uriIdentifier = "uri"
+
+// signTimeout bounds the time to get a certificate issued, including retries.
+const signTimeout = 2 * time.Minute
+
+// ACMEClient gets workload certificates issued by an ACME (RFC 8555) server.
+//
+// Workloads cannot answer challenges for their identity, so the server must consider the authorizations of the
+// orders valid without challenges, for instance based on the external account binding of the account.
+// The certificate lifetime is decided by the server.
+type ACMEClient struct {
+	opts   *security.Options
+	client *acme.Client
+	eab    *acme.ExternalAccountBinding
+
+	mu         sync.Mutex
+	registered bool
+}
+
+// NewACMEClient create a CA client for an ACME server.
+func NewACMEClient(opts *security.Options, tlsOpts *caclient.TLSOptions) (*ACMEClient, error) {
+	httpClient, err := caclient.NewHTTPClient(tlsOpts)
+	if err != nil {
+		return nil, err
+	}
+	key, err := loadAccountKey(opts.ACMECA.AccountKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load account key: %v", err)
+	}
+	c := &ACMEClient{
+		opts: opts,
+		client: &acme.Client{
+			Key:          key,
+			DirectoryURL: opts.CAEndpoint,
+			HTTPClient:   httpClient,
+			UserAgent:    "istio-agent",
+			RetryBackoff: func(n int, _ *http.Request, _ *http.Response) time.Duration {
+				return security.CARetryBackoff(uint(n))
+			},
+		},
+	}
+	if opts.ACMECA.EABKeyID != "" {
+		hmacKey, err := os.ReadFile(opts.ACMECA.EABHMACKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read external account binding key: %v", err)
+		}
+		decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.TrimSpace(string(hmacKey)), "="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid external account binding key: %v", err)
+		}
+		c.eab = &acme.ExternalAccountBinding{KID: opts.ACMECA.EABKeyID, Key: decoded}
+	}
+	return c, nil
+}
+
+func (c *ACMEClient) Close() {
+	c.client.HTTPClient.CloseIdleConnections()
+}
+
+// CSRSign orders a certificate for the identifiers of the CSR. The requested TTL is not used, as not all ACME
+// servers allow clients to choose the certificate lifetime.
+func (c *ACMEClient) CSRSign(csrPEM []byte, _ int64) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), signTimeout)
+	defer cancel()
+
+	chain, err := c.sign(ctx, csrPEM)
+	if err != nil {
+		acmeClientLog.Errorf("failed to sign CSR: %v", err)
+		return nil, err
+	}
+	return chain, nil
+}
+
+func (c *ACMEClient) sign(ctx context.Context, csrPEM []byte) ([]string, error) {
+	csr, err := pkiutil.ParsePemEncodedCSR(csrPEM)
+	if err != nil {
+		return nil, err
+	}
+	ids := identifiers(csr)
+	if len(ids) == 0 {
+		return nil, errors.New("no identifiers in CSR")
+	}
+	if err := c.register(ctx); err != nil {
+		return nil, fmt.Errorf("register account: %v", err)
+	}
+
+	order, err := c.client.AuthorizeOrder(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("create order: %v", err)
+	}
+	for _, u := range order.AuthzURLs {
+		authz, err := c.client.GetAuthorization(ctx, u)
+		if err != nil {
+			return nil, fmt.Errorf("get authorization: %v", err)
+		}
+		if authz.Status != acme.StatusValid {
+			return nil, fmt.Errorf("authorization for %s is %s; the server must authorize workload identifiers without challenges",
+				authz.Identifier.Value, authz.Status)
+		}
+	}
+	order, err = c.client.WaitOrder(ctx, order.URI)
+	if err != nil {
+		return nil, fmt.Errorf("wait for order: %v", err)
+	}
+	der, _, err := c.client.CreateOrderCert(ctx, order.FinalizeURL, csr.Raw, true)
+	if err != nil {
+		return nil, fmt.Errorf("finalize order: %v", err)
+	}
+	if len(der) == 0 {
+		return nil, errors.New("invalid empty CertChain")
+	}
+	chain := make([]string, 0, len(der))
+	for _, d := range der {
+		chain = append(chain, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: d})))
+	}
+	return chain, nil
+}
+
+// register registers the ACME account, once.
+func (c *ACMEClient) register(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.registered {
+		return nil
+	}
+	_, err := c.client.Register(ctx, &acme.Account{ExternalAccountBinding: c.eab}, acme.AcceptTOS)
+	if err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return err
+	}
+	c.registered = true
+	acmeClientLog.Infof("registered ACME account with %s", c.opts.CAEndpoint)
+	return nil
+}
+
+// GetRootCertBundle: ACME does not define an endpoint to retrieve CA certs, so the root is taken from the chain.
+func (c *ACMEClient) GetRootCertBundle() ([]string, error) {
+	return []string{}, nil
+}
+
+// identifiers returns the ACME identifiers to order for the SANs of csr.
+func identifiers(csr *x509.CertificateRequest) []acme.AuthzID {
+	ids := acme.DomainIDs(csr.DNSNames...)
+	for _, ip := range csr.IPAddresses {
+		ids = append(ids, acme.IPIDs(ip.String())...)
+	}
+	for _, u := range csr.URIs {
+		ids = append(ids, acme.AuthzID{Type: uriIdentifier, Value: u.String()})
+	}
+	return ids
+}
+
+// loadAccountKey reads the account key from path. If path does not exist, a new key is generated and written to it.
+// If path is empty, a new key is generated.
+func loadAccountKey(path string) (crypto.Signer, error) {
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err == nil {
+			return parseAccountKey(b)
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if err := file.AtomicWrite(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+			return nil, err
+		}
+		acmeClientLog.Infof("generated ACME account key %s", path)
+	}
+	return key, nil
+}
+
+func parseAccountKey(b []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported key type %T", key)
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
@@ -0,0 +1,234 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acme
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/acme"
+
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/test/util/assert"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
+)
+
+const identity = "spiffe://cluster.local/ns/default/sa/client"
+
+// fakeACME is a minimal ACME server, which does not verify request signatures and authorizes all identifiers.
+type fakeACME struct {
+	t       *testing.T
+	url     string
+	cert    *x509.Certificate
+	key     crypto.PrivateKey
+	certPEM string
+	// authzStatus is the status of the authorizations of new orders
+	authzStatus string
+
+	mu          sync.Mutex
+	accounts    int
+	eabKIDs     []string
+	identifiers []acme.AuthzID
+	issued      []byte
+}
+
+func newFakeACME(t *testing.T) *fakeACME {
+	certPEM, keyPEM, err := pkiutil.GenCertKeyFromOptions(pkiutil.CertOptions{
+		Org:          "acme",
+		TTL:          time.Hour,
+		IsCA:         true,
+		IsSelfSigned: true,
+		ECSigAlg:     pkiutil.EcdsaSigAlg,
+	})
+	assert.NoError(t, err)
+	cert, err := pkiutil.ParsePemEncodedCertificate(certPEM)
+	assert.NoError(t, err)
+	key, err := pkiutil.ParsePemEncodedKey(keyPEM)
+	assert.NoError(t, err)
+	a := &fakeACME{t: t, cert: cert, key: key, certPEM: string(certPEM), authzStatus: acme.StatusValid}
+	server := httptest.NewServer(a)
+	t.Cleanup(server.Close)
+	a.url = server.URL
+	return a
+}
+
+// payload decodes the payload of a JWS request body.
+func payload(r *http.Request, out any) {
+	var jws struct {
+		Payload string `json:"payload"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&jws)
+	b, _ := base64.RawURLEncoding.DecodeString(jws.Payload)
+	if len(b) > 0 {
+		_ = json.Unmarshal(b, out)
+	}
+}
+
+func (a *fakeACME) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	w.Header().Set("Replay-Nonce", "nonce")
+	order := func(status string) map[string]any {
+		o := map[string]any{
+			"status":         status,
+			"identifiers":    a.identifiers,
+			"authorizations": []string{a.url + "/authz/1"},
+			"finalize":       a.url + "/finalize/1",
+		}
+		if status == acme.StatusValid {
+			o["certificate"] = a.url + "/cert/1"
+		}
+		w.Header().Set("Location", a.url+"/order/1")
+		return o
+	}
+	var resp any
+	switch r.URL.Path {
+	case "/directory":
+		resp = map[string]string{
+			"newNonce":   a.url + "/nonce",
+			"newAccount": a.url + "/account",
+			"newOrder":   a.url + "/order",
+		}
+	case "/nonce":
+		return
+	case "/account":
+		var req struct {
+			EAB *struct {
+				Protected string `json:"protected"`
+			} `json:"externalAccountBinding"`
+		}
+		payload(r, &req)
+		if req.EAB != nil {
+			b, _ := base64.RawURLEncoding.DecodeString(req.EAB.Protected)
+			var protected struct {
+				KID string `json:"kid"`
+			}
+			_ = json.Unmarshal(b, &protected)
+			a.eabKIDs = append(a.eabKIDs, protected.KID)
+		}
+		a.accounts++
+		w.Header().Set("Location", a.url+"/account/1")
+		w.WriteHeader(http.StatusCreated)
+		resp = map[string]string{"status": acme.StatusValid}
+	case "/order":
+		var req struct {
+			Identifiers []acme.AuthzID `json:"identifiers"`
+		}
+		payload(r, &req)
+		a.identifiers = req.Identifiers
+		resp = order(acme.StatusReady)
+		w.WriteHeader(http.StatusCreated)
+	case "/authz/1":
+		resp = map[string]any{
+			"status":     a.authzStatus,
+			"identifier": a.identifiers[0],
+		}
+	case "/order/1":
+		resp = order(acme.StatusReady)
+	case "/finalize/1":
+		var req struct {
+			CSR string `json:"csr"`
+		}
+		payload(r, &req)
+		der, _ := base64.RawURLEncoding.DecodeString(req.CSR)
+		csr, err := x509.ParseCertificateRequest(der)
+		assert.NoError(a.t, err)
+		a.issued, err = pkiutil.GenCertFromCSR(csr, a.cert, csr.PublicKey, a.key, []string{identity}, time.Hour, false)
+		assert.NoError(a.t, err)
+		resp = order(acme.StatusValid)
+	case "/cert/1":
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		_, _ = w.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: a.issued}))
+		_, _ = w.Write([]byte(a.certPEM))
+		return
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func newCSR(t *testing.T) []byte {
+	csr, _, err := pkiutil.GenCSR(pkiutil.CertOptions{Host: identity, ECSigAlg: pkiutil.EcdsaSigAlg})
+	assert.NoError(t, err)
+	return csr
+}
+
+func TestACMEClient(t *testing.T) {
+	server := newFakeACME(t)
+	dir := t.TempDir()
+	hmacKey := filepath.Join(dir, "hmac")
+	assert.NoError(t, os.WriteFile(hmacKey, []byte(base64.RawURLEncoding.EncodeToString([]byte("secret"))+"\n"), 0o600))
+	opts := &security.Options{
+		CAEndpoint: server.url + "/directory",
+		ACMECA: security.ACMECAOptions{
+			AccountKeyFile: filepath.Join(dir, "account.pem"),
+			EABKeyID:       "kid-1",
+			EABHMACKeyFile: hmacKey,
+		},
+	}
+	c, err := NewACMEClient(opts, nil)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	for range 2 {
+		chain, err := c.CSRSign(newCSR(t), 3600)
+		assert.NoError(t, err)
+		assert.Equal(t, len(chain), 2)
+		assert.Equal(t, chain[1], server.certPEM)
+		leaf, err := pkiutil.ParsePemEncodedCertificate([]byte(chain[0]))
+		assert.NoError(t, err)
+		assert.Equal(t, leaf.URIs[0].String(), identity)
+	}
+	// The account is registered once, with the external account binding
+	assert.Equal(t, server.accounts, 1)
+	assert.Equal(t, server.eabKIDs, []string{"kid-1"})
+	assert.Equal(t, server.identifiers, []acme.AuthzID{{Type: uriIdentifier, Value: identity}})
+
+	// The account key is persisted, and reused on restart
+	key, err := os.ReadFile(opts.ACMECA.AccountKeyFile)
+	assert.NoError(t, err)
+	c2, err := NewACMEClient(opts, nil)
+	assert.NoError(t, err)
+	defer c2.Close()
+	assert.Equal(t, c2.client.Key.Public(), c.client.Key.Public())
+	after, err := os.ReadFile(opts.ACMECA.AccountKeyFile)
+	assert.NoError(t, err)
+	assert.Equal(t, after, key)
+}
+
+func TestACMEClientPendingAuthorization(t *testing.T) {
+	server := newFakeACME(t)
+	server.authzStatus = acme.StatusPending
+	c, err := NewACMEClient(&security.Options{CAEndpoint: server.url + "/directory"}, nil)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.CSRSign(newCSR(t), 3600)
+	if err == nil || !strings.Contains(err.Error(), "without challenges") {
+		t.Fatalf("expected authorization error, got %v", err)
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/security/pkg/nodeagent/caclient"
+)
+
+var vaultClientLog = log.RegisterScope("vaultclient", "vault client debugging")
+
+// VaultClient signs workload certificates with the PKI secrets engine of a Vault server.
+// It logs in to Vault with the workload token, using an auth method such as the Kubernetes or JWT auth method.
+type VaultClient struct {
+	opts     *security.Options
+	address  string
+	client   *http.Client
+	provider *caclient.DefaultTokenProvider
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// NewVaultClient create a CA client for Vault.
+func NewVaultClient(opts *security.Options, tlsOpts *caclient.TLSOptions) (*VaultClient, error) {
+	if opts.VaultCA.Role == "" {
+		return nil, fmt.Errorf("a Vault role is required")
+	}
+	if opts.VaultCA.SignPath == "" {
+		return nil, fmt.Errorf("a Vault PKI sign path is required")
+	}
+	client, err := caclient.NewHTTPClient(tlsOpts)
+	if err != nil {
+		return nil, err
+	}
+	address := strings.TrimSuffix(opts.CAEndpoint, "/")
+	if !strings.Contains(address, "://") {
+		address = "https://" + address
+	}
+	return &VaultClient{
+		opts:     opts,
+		address:  address,
+		client:   client,
+		provider: caclient.NewDefaultTokenProvider(opts).(*caclient.DefaultTokenProvider),
+	}, nil
+}
+
+func (c *VaultClient) Close() {
+	c.client.CloseIdleConnections()
+}
+
+type signRequest struct {
+	CSR    string `json:"csr"`
+	TTL    string `json:"ttl"`
+	Format string `json:"format"`
+}
+
+type signResponse struct {
+	Data struct {
+		Certificate string   `json:"certificate"`
+		IssuingCA   string   `json:"issuing_ca"`
+		CAChain     []string `json:"ca_chain"`
+	} `json:"data"`
+}
+
+type loginRequest struct {
+	Role string `json:"role"`
+	JWT  string `json:"jwt"`
+}
+
+type loginResponse struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int64  `json:"lease_duration"`
+	} `json:"auth"`
+}
+
+type errorResponse struct {
+	Errors []string `json:"errors"`
+}
+
+// CSRSign calls Vault to sign a CSR.
+func (c *VaultClient) CSRSign(csrPEM []byte, certValidTTLInSec int64) ([]string, error) {
+	req := signRequest{
+		CSR:    string(csrPEM),
+		TTL:    fmt.Sprintf("%ds", certValidTTLInSec),
+		Format: "pem",
+	}
+	var resp signResponse
+	err := security.CARetry(func() error {
+		return c.sign(req, &resp)
+	}, caclient.IsRetryable)
+	if err != nil {
+		vaultClientLog.Errorf("failed to sign CSR: %v", err)
+		return nil, fmt.Errorf("sign certificate: %v", err)
+	}
+	if resp.Data.Certificate == "" {
+		return nil, errors.New("invalid empty certificate")
+	}
+
+	chain := []string{resp.Data.Certificate}
+	if len(resp.Data.CAChain) > 0 {
+		chain = append(chain, resp.Data.CAChain...)
+	} else if resp.Data.IssuingCA != "" {
+		chain = append(chain, resp.Data.IssuingCA)
+	}
+	if len(chain) <= 1 {
+		return nil, errors.New("invalid empty CA chain")
+	}
+	return chain, nil
+}
+
+// sign sends a sign request, logging in again if the token is rejected.
+func (c *VaultClient) sign(req signRequest, resp *signResponse) error {
+	token, err := c.getToken()
+	if err != nil {
+		return err
+	}
+	path := "/v1/" + strings.Trim(c.opts.VaultCA.SignPath, "/")
+	err = c.do(path, token, req, resp)
+	var httpErr *caclient.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusForbidden {
+		// The token may have been revoked before its lease expired
+		vaultClientLog.Infof("token rejected, logging in again")
+		c.invalidateToken(token)
+		if token, err = c.getToken(); err != nil {
+			return err
+		}
+		err = c.do(path, token, req, resp)
+	}
+	return err
+}
+
+// getToken returns a Vault token, logging in if there is no valid one.
+func (c *VaultClient) getToken() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && (c.tokenExpiry.IsZero() || time.Now().Before(c.tokenExpiry)) {
+		return c.token, nil
+	}
+	jwt, err := c.provider.GetToken()
+	if err != nil {
+		return "", err
+	}
+	if jwt == "" {
+		return "", errors.New("no workload token to log in to Vault")
+	}
+	var resp loginResponse
+	path := "/v1/auth/" + strings.Trim(c.opts.VaultCA.AuthPath, "/") + "/login"
+	if err := c.do(path, "", loginRequest{Role: c.opts.VaultCA.Role, JWT: jwt}, &resp); err != nil {
+		return "", fmt.Errorf("login: %v", err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return "", errors.New("login: invalid empty token")
+	}
+	c.token = resp.Auth.ClientToken
+	c.tokenExpiry = time.Time{}
+	if resp.Auth.LeaseDuration > 0 {
+		// Log in again before the token expires, rather than having a request rejected
+		lease := time.Duration(resp.Auth.LeaseDuration) * time.Second
+		c.tokenExpiry = time.Now().Add(lease * 4 / 5)
+	}
+	vaultClientLog.Debugf("logged in as role %s", c.opts.VaultCA.Role)
+	return c.token, nil
+}
+
+func (c *VaultClient) invalidateToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == token {
+		c.token = ""
+	}
+}
+
+// do sends a request to the Vault API, decoding the response into out.
+func (c *VaultClient) do(path string, token string, in any, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.address+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range c.opts.CAHeaders {
+		req.Header.Set(k, v)
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if c.opts.VaultCA.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.opts.VaultCA.Namespace)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg := string(respBody)
+		var errResp errorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && len(errResp.Errors) > 0 {
+			msg = strings.Join(errResp.Errors, "; ")
+		}
+		return &caclient.HTTPError{StatusCode: resp.StatusCode, Message: msg}
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+// GetRootCertBundle: Vault returns the CA chain when signing, so the root is taken from it.
+func (c *VaultClient) GetRootCertBundle() ([]string, error) {
+	return []string{}, nil
+}
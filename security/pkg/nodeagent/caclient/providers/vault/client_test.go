@@ -0,0 +1,196 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/security/pkg/credentialfetcher/plugin"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
+)
+
+const (
+	workloadJWT = "workload-jwt"
+	identity    = "spiffe://cluster.local/ns/default/sa/client"
+)
+
+type fakeVault struct {
+	t       *testing.T
+	cert    *x509.Certificate
+	key     crypto.PrivateKey
+	certPEM string
+
+	mu       sync.Mutex
+	token    string
+	logins   int
+	signs    int
+	failures int
+}
+
+func newFakeVault(t *testing.T) *fakeVault {
+	certPEM, keyPEM, err := pkiutil.GenCertKeyFromOptions(pkiutil.CertOptions{
+		Org:          "vault",
+		TTL:          time.Hour,
+		IsCA:         true,
+		IsSelfSigned: true,
+		ECSigAlg:     pkiutil.EcdsaSigAlg,
+	})
+	assert.NoError(t, err)
+	cert, err := pkiutil.ParsePemEncodedCertificate(certPEM)
+	assert.NoError(t, err)
+	key, err := pkiutil.ParsePemEncodedKey(keyPEM)
+	assert.NoError(t, err)
+	return &fakeVault{t: t, cert: cert, key: key, certPEM: string(certPEM)}
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	fail := func(code int, msg string) {
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(errorResponse{Errors: []string{msg}})
+	}
+	switch r.URL.Path {
+	case "/v1/auth/kubernetes/login":
+		var req loginRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.JWT != workloadJWT || req.Role != "istio" {
+			fail(http.StatusBadRequest, "invalid role or JWT")
+			return
+		}
+		v.logins++
+		v.token = fmt.Sprintf("token-%d", v.logins)
+		var resp loginResponse
+		resp.Auth.ClientToken = v.token
+		resp.Auth.LeaseDuration = 3600
+		_ = json.NewEncoder(w).Encode(resp)
+	case "/v1/pki/sign/istio":
+		v.signs++
+		if r.Header.Get("X-Vault-Token") != v.token {
+			fail(http.StatusForbidden, "permission denied")
+			return
+		}
+		if v.failures > 0 {
+			v.failures--
+			fail(http.StatusServiceUnavailable, "Vault is sealed")
+			return
+		}
+		var req signRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(v.t, req.TTL, "3600s")
+		csr, err := pkiutil.ParsePemEncodedCSR([]byte(req.CSR))
+		if err != nil {
+			fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		certDER, err := pkiutil.GenCertFromCSR(csr, v.cert, csr.PublicKey, v.key, []string{identity}, time.Hour, false)
+		if err != nil {
+			fail(http.StatusInternalServerError, err.Error())
+			return
+		}
+		var resp signResponse
+		resp.Data.Certificate = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}))
+		resp.Data.IssuingCA = v.certPEM
+		resp.Data.CAChain = []string{v.certPEM}
+		_ = json.NewEncoder(w).Encode(resp)
+	default:
+		fail(http.StatusNotFound, "not found")
+	}
+}
+
+func newClient(t *testing.T, address string, role string) *VaultClient {
+	c, err := NewVaultClient(&security.Options{
+		CAEndpoint:  address,
+		CredFetcher: plugin.CreateMockPlugin(workloadJWT),
+		VaultCA: security.VaultCAOptions{
+			AuthPath: "kubernetes",
+			Role:     role,
+			SignPath: "pki/sign/istio",
+		},
+	}, nil)
+	assert.NoError(t, err)
+	t.Cleanup(c.Close)
+	return c
+}
+
+func newCSR(t *testing.T) []byte {
+	csr, _, err := pkiutil.GenCSR(pkiutil.CertOptions{Host: identity, ECSigAlg: pkiutil.EcdsaSigAlg})
+	assert.NoError(t, err)
+	return csr
+}
+
+func TestVaultClient(t *testing.T) {
+	vault := newFakeVault(t)
+	server := httptest.NewServer(vault)
+	t.Cleanup(server.Close)
+	c := newClient(t, server.URL, "istio")
+
+	sign := func() {
+		t.Helper()
+		chain, err := c.CSRSign(newCSR(t), 3600)
+		assert.NoError(t, err)
+		assert.Equal(t, len(chain), 2)
+		assert.Equal(t, chain[1], vault.certPEM)
+		leaf, err := pkiutil.ParsePemEncodedCertificate([]byte(chain[0]))
+		assert.NoError(t, err)
+		assert.Equal(t, leaf.URIs[0].String(), identity)
+	}
+
+	// The token is reused across requests
+	sign()
+	sign()
+	assert.Equal(t, vault.logins, 1)
+
+	// Transient failures are retried
+	vault.mu.Lock()
+	vault.failures = 2
+	vault.signs = 0
+	vault.mu.Unlock()
+	sign()
+	assert.Equal(t, vault.signs, 3)
+
+	// A rejected token causes a new login
+	vault.mu.Lock()
+	vault.token = "revoked"
+	vault.mu.Unlock()
+	sign()
+	assert.Equal(t, vault.logins, 2)
+}
+
+func TestVaultClientLoginFailure(t *testing.T) {
+	vault := newFakeVault(t)
+	server := httptest.NewServer(vault)
+	t.Cleanup(server.Close)
+	c := newClient(t, server.URL, "unknown")
+
+	_, err := c.CSRSign(newCSR(t), 3600)
+	if err == nil || !strings.Contains(err.Error(), "invalid role or JWT") {
+		t.Fatalf("expected login error, got %v", err)
+	}
+	// Client errors are not retried
+	assert.Equal(t, vault.signs, 0)
+}
@@ -16,6 +16,9 @@ package ca
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/x509"
 	"encoding/pem"
@@ -280,26 +283,57 @@ func NewPluggedCertIstioCAOptions(fileBundle SigningCAFileBundle,
 		return nil, fmt.Errorf("failed to create CA KeyCertBundle (%v)", err)
 	}
 
-	// Validate that the passed in signing cert can be used as CA.
-	// The check can't be done inside `KeyCertBundle`, since bundle could also be used to
-	// validate workload certificates (i.e., where the leaf certificate is not a CA).
-	b, err := os.ReadFile(fileBundle.SigningCertFile)
-	if err != nil {
+	if err := verifySigningCert(fileBundle.SigningCertFile); err != nil {
+		return nil, err
+	}
+
+	return caOpts, nil
+}
+
+// NewExternalSignerIstioCAOptions returns a new IstioCAOptions instance using given certificate, whose private key is
+// held by an external signer. The SigningKeyFile of fileBundle is not used.
+func NewExternalSignerIstioCAOptions(fileBundle SigningCAFileBundle, signer crypto.Signer,
+	defaultCertTTL, maxCertTTL time.Duration, caRSAKeySize int,
+) (caOpts *IstioCAOptions, err error) {
+	caOpts = &IstioCAOptions{
+		CAType:         pluggedCertCA,
+		DefaultCertTTL: defaultCertTTL,
+		MaxCertTTL:     maxCertTTL,
+		CARSAKeySize:   caRSAKeySize,
+	}
+
+	if caOpts.KeyCertBundle, err = util.NewVerifiedKeyCertBundleFromSigner(
+		fileBundle.SigningCertFile, signer, fileBundle.CertChainFiles, fileBundle.RootCertFile); err != nil {
+		return nil, fmt.Errorf("failed to create CA KeyCertBundle (%v)", err)
+	}
+
+	if err := verifySigningCert(fileBundle.SigningCertFile); err != nil {
 		return nil, err
 	}
+
+	return caOpts, nil
+}
+
+// verifySigningCert validates that the passed in signing cert can be used as CA.
+// The check can't be done inside `KeyCertBundle`, since bundle could also be used to
+// validate workload certificates (i.e., where the leaf certificate is not a CA).
+func verifySigningCert(signingCertFile string) error {
+	b, err := os.ReadFile(signingCertFile)
+	if err != nil {
+		return err
+	}
 	block, _ := pem.Decode(b)
 	if block == nil {
-		return nil, fmt.Errorf("invalid PEM encoded certificate")
+		return fmt.Errorf("invalid PEM encoded certificate")
 	}
 	cert, err := x509.ParseCertificate(block.Bytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse X.509 certificate")
+		return fmt.Errorf("failed to parse X.509 certificate")
 	}
 	if !cert.IsCA {
-		return nil, fmt.Errorf("certificate is not authorized to sign other certificates")
+		return fmt.Errorf("certificate is not authorized to sign other certificates")
 	}
-
-	return caOpts, nil
+	return nil
 }
 
 // BuildSecret returns a secret struct, contents of which are filled with parameters passed in.
@@ -402,16 +436,20 @@ func (ca *IstioCA) GenKeyCert(hostnames []string, certTTL time.Duration, checkLi
 	}
 
 	// use the type of private key the CA uses to generate an intermediate CA of that type (e.g. CA cert using RSA will
-	// cause intermediate CAs using RSA to be generated)
-	_, signingKey, _, _ := ca.keyCertBundle.GetAll()
-	curve, err := util.GetEllipticCurve(signingKey)
-	if err == nil {
-		opts.ECSigAlg = util.EcdsaSigAlg
-		switch curve {
-		case elliptic.P384():
-			opts.ECCCurve = util.P384Curve
-		default:
-			opts.ECCCurve = util.P256Curve
+	// cause intermediate CAs using RSA to be generated). The type is taken from the signing cert, as the private key
+	// may be held by an external signer.
+	signingCert, _, _, _ := ca.keyCertBundle.GetAll()
+	if signingCert != nil {
+		switch pub := signingCert.PublicKey.(type) {
+		case ed25519.PublicKey:
+			opts.ECSigAlg = util.Ed25519SigAlg
+		case *ecdsa.PublicKey:
+			opts.ECSigAlg = util.EcdsaSigAlg
+			if pub.Curve == elliptic.P384() {
+				opts.ECCCurve = util.P384Curve
+			} else {
+				opts.ECCCurve = util.P256Curve
+			}
 		}
 	}
 
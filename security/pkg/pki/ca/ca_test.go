@@ -17,10 +17,14 @@ package ca
 import (
 	"bytes"
 	"context"
+	"crypto"
+	"crypto/ed25519"
 	"crypto/tls"
 	"crypto/x509"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -30,6 +34,7 @@ import (
 	"k8s.io/client-go/kubernetes/fake"
 
 	caerror "istio.io/istio/security/pkg/pki/error"
+	"istio.io/istio/security/pkg/pki/signer"
 	"istio.io/istio/security/pkg/pki/util"
 )
 
@@ -454,6 +459,78 @@ func TestCreatePluggedCertCA(t *testing.T) {
 	}
 }
 
+func TestCreateExternalSignerCA(t *testing.T) {
+	fileBundle := SigningCAFileBundle{
+		RootCertFile:    "../testdata/multilevelpki/root-cert.pem",
+		CertChainFiles:  []string{"../testdata/multilevelpki/int2-cert-chain.pem"},
+		SigningCertFile: "../testdata/multilevelpki/int2-cert.pem",
+	}
+	startSigner := func(keyFile string) *signer.Signer {
+		keyPEM, err := os.ReadFile(keyFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := util.ParsePemEncodedKey(keyPEM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		address := "unix://" + filepath.Join(t.TempDir(), "signer.sock")
+		l, err := signer.Listen(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		server := signer.NewServer(key.(crypto.Signer))
+		go func() { _ = server.Serve(l) }()
+		t.Cleanup(func() { _ = server.Close() })
+		s, err := signer.NewSigner(context.Background(), address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(s.Close)
+		return s
+	}
+
+	caopts, err := NewExternalSignerIstioCAOptions(fileBundle, startSigner("../testdata/multilevelpki/int2-key.pem"),
+		time.Hour, time.Hour, 2048)
+	if err != nil {
+		t.Fatalf("Failed to create an external signer CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Got error while creating external signer CA: %v", err)
+	}
+	// The signing key is not loaded in memory
+	if _, signingKeyBytes, _, _ := ca.GetCAKeyCertBundle().GetAllPem(); len(signingKeyBytes) != 0 {
+		t.Errorf("Unexpected signing key pem: %s", signingKeyBytes)
+	}
+
+	subjectID := "spiffe://example.com/ns/foo/sa/bar"
+	csrPEM, _, err := util.GenCSR(util.CertOptions{Host: subjectID, RSAKeySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := ca.SignWithCertChain(csrPEM, CertOpts{SubjectIDs: []string{subjectID}, TTL: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to sign CSR: %v", err)
+	}
+	_, _, certChainBytes, rootCertBytes := ca.GetCAKeyCertBundle().GetAllPem()
+	if err := util.VerifyCertificate(nil, []byte(chain[0]), rootCertBytes,
+		&util.VerifyFields{Host: subjectID, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+			KeyUsage: x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment}); err != nil {
+		t.Errorf("Failed to verify the signed cert: %v", err)
+	}
+	if !bytes.HasSuffix([]byte(chain[0]), certChainBytes) {
+		t.Errorf("Signed cert is not followed by the cert chain")
+	}
+
+	// The signer must hold the key of the signing cert
+	_, err = NewExternalSignerIstioCAOptions(fileBundle, startSigner("../testdata/multilevelpki/int-key.pem"),
+		time.Hour, time.Hour, 2048)
+	if err == nil || !strings.Contains(err.Error(), "does not match the public key of the signer") {
+		t.Errorf("Expected key mismatch error, got %v", err)
+	}
+}
+
 func TestSignCSR(t *testing.T) {
 	subjectID := "spiffe://example.com/ns/foo/sa/bar"
 	cases := map[string]struct {
@@ -500,6 +577,24 @@ func TestSignCSR(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		"Workload uses Ed25519": {
+			forCA: false,
+			certOpts: util.CertOptions{
+				// This value is not used, instead, subjectID should be used in certificate.
+				Host:     "spiffe://different.com/test",
+				ECSigAlg: util.Ed25519SigAlg,
+				IsCA:     false,
+			},
+			maxTTL:       time.Hour,
+			requestedTTL: 30 * time.Minute,
+			verifyFields: util.VerifyFields{
+				ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+				KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+				IsCA:        false,
+				Host:        subjectID,
+			},
+			expectedError: "",
+		},
 		"CA uses RSA": {
 			forCA: true,
 			certOpts: util.CertOptions{
@@ -530,6 +625,21 @@ func TestSignCSR(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		"CA uses Ed25519": {
+			forCA: true,
+			certOpts: util.CertOptions{
+				ECSigAlg: util.Ed25519SigAlg,
+				IsCA:     true,
+			},
+			maxTTL:       365 * 24 * time.Hour,
+			requestedTTL: 30 * 24 * time.Hour,
+			verifyFields: util.VerifyFields{
+				KeyUsage: x509.KeyUsageCertSign,
+				IsCA:     true,
+				Host:     subjectID,
+			},
+			expectedError: "",
+		},
 		"CSR uses RSA TTL error": {
 			forCA: false,
 			certOpts: util.CertOptions{
@@ -784,6 +894,25 @@ func TestGenKeyCert(t *testing.T) {
 	}
 }
 
+func TestGenKeyCertEd25519(t *testing.T) {
+	ca, err := createCA(24*time.Hour, util.Ed25519SigAlg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, privPEM, err := ca.GenKeyCert([]string{"host1"}, time.Hour, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, privPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Keys are generated with the algorithm of the CA key
+	if _, ok := cert.PrivateKey.(ed25519.PrivateKey); !ok {
+		t.Fatalf("unexpected private key type %T", cert.PrivateKey)
+	}
+}
+
 // TestBuildSecret verifies that BuildSecret returns expected secret.
 func TestBuildSecret(t *testing.T) {
 	CertPem := []byte(cert1Pem)
@@ -0,0 +1,668 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+var pluggedRootRotatorLog = log.RegisterScope("pluggedrootrotator", "Plugged-in CA root cert rotator log")
+
+// RootRotationStatusConfigMap is the name of the ConfigMap with the status of the plugged-in CA root rotation, in the
+// istiod namespace. It has a key per istiod replica, with the JSON encoded RootRotationStatus of the replica. The keys
+// of replicas whose pod was deleted are removed by the other replicas.
+const RootRotationStatusConfigMap = "istio-ca-root-rotation"
+
+const (
+	// maxPendingProxyIDs is the max number of pending proxies listed in the status.
+	maxPendingProxyIDs = 10
+	// staleReplicaIntervals is the number of check intervals after which the status of a replica in a rotation is
+	// ignored, and after which the status of a replica whose pod was deleted is removed.
+	staleReplicaIntervals = 3
+	statusUpdateTimeout   = 10 * time.Second
+)
+
+// RootRotationStage is the stage of a plugged-in CA root rotation.
+type RootRotationStage string
+
+const (
+	// RootRotationIdle means no rotation is in progress: the CA uses the plugged-in roots.
+	RootRotationIdle RootRotationStage = "Idle"
+	// RootRotationDistributing means the previous and new roots are published, while the CA still signs with the
+	// previous signing cert, until the proxies connected to all the istiod replicas acknowledged them.
+	RootRotationDistributing RootRotationStage = "Distributing"
+	// RootRotationRetiring means the CA signs with the new signing cert, while the previous roots are still
+	// published until the retirement delay, for the workload certs signed by the previous signing cert to be rotated.
+	RootRotationRetiring RootRotationStage = "Retiring"
+	// RootRotationCompleted means the previous roots were removed.
+	RootRotationCompleted RootRotationStage = "Completed"
+	// RootRotationFailed means the rotation could not start, for instance as the new signing cert is not signed by
+	// the new roots. It is retried at each check.
+	RootRotationFailed RootRotationStage = "Failed"
+)
+
+// RootRotationStatus is the status of the plugged-in CA root rotation of an istiod replica.
+type RootRotationStatus struct {
+	Stage   RootRotationStage `json:"stage"`
+	Message string            `json:"message,omitempty"`
+	// TargetRoots are the SHA-256 fingerprints of the roots of the plugged-in CA files, which are rotated to.
+	TargetRoots []string `json:"targetRoots,omitempty"`
+	// PreviousRoots are the SHA-256 fingerprints of the roots which are rotated from.
+	PreviousRoots []string `json:"previousRoots,omitempty"`
+	// PreviousRootsPEM are the PEM encoded roots which are rotated from, for the rotation to be resumed if istiod
+	// restarts, as the plugged-in CA files only have the new roots.
+	PreviousRootsPEM string     `json:"previousRootsPEM,omitempty"`
+	StartTime        *time.Time `json:"startTime,omitempty"`
+	// SwitchTime is the time the CA started signing with the new signing cert.
+	SwitchTime *time.Time `json:"switchTime,omitempty"`
+	// RetireTime is the time the previous roots are, or were, removed.
+	RetireTime *time.Time `json:"retireTime,omitempty"`
+	// SyncedProxies and PendingProxies are the numbers of connected proxies which did, or did not, acknowledge the
+	// new roots. UntrackedProxies is the number of connected proxies which do not get the roots over xDS.
+	SyncedProxies    int `json:"syncedProxies"`
+	PendingProxies   int `json:"pendingProxies"`
+	UntrackedProxies int `json:"untrackedProxies"`
+	// PendingProxyIDs lists some of the pending proxies.
+	PendingProxyIDs []string  `json:"pendingProxyIDs,omitempty"`
+	LastUpdate      time.Time `json:"lastUpdate"`
+}
+
+// ProxySyncTracker tracks the distribution of the trust bundle to the proxies connected to istiod.
+type ProxySyncTracker interface {
+	// PushCount returns the number of pushes so far.
+	PushCount() uint64
+	// TrustBundleSyncStatus returns the proxies which acknowledged the trust bundle of a push after pushCount, the
+	// proxies which did not yet, and the proxies which do not get the trust bundle over xDS.
+	TrustBundleSyncStatus(pushCount uint64) (synced, pending, untracked []string)
+}
+
+// PluggedCARootRotatorConfig is the configuration of a PluggedCARootRotator.
+type PluggedCARootRotatorConfig struct {
+	FileBundle SigningCAFileBundle
+	// Signer holds the signing key, if it is held by an external signer rather than in the signing key file.
+	Signer crypto.Signer
+	Client corev1.CoreV1Interface
+	// Namespace is the namespace of the status ConfigMap.
+	Namespace string
+	// Replica is the name of the istiod replica in the status ConfigMap.
+	Replica       string
+	CheckInterval time.Duration
+	// RetirementDelay is the time the previous roots are still published after the CA signs with the new signing
+	// cert. It should be longer than the TTL of the workload certs.
+	RetirementDelay time.Duration
+}
+
+// PluggedCARootRotator rotates the roots of a plugged-in CA in stages, when the root cert file changes:
+//  1. the previous and new roots are published to the proxies, while the CA still signs with the previous cert;
+//  2. once the proxies connected to all the istiod replicas acknowledged them, the CA signs with the new cert;
+//  3. after the retirement delay, the previous roots are removed.
+//
+// Each replica writes its progress to the RootRotationStatusConfigMap ConfigMap, which replicas read to wait for each
+// other, and to resume the rotation with Restore if istiod restarts during it.
+type PluggedCARootRotator struct {
+	config           PluggedCARootRotatorConfig
+	ca               *IstioCA
+	tracker          ProxySyncTracker
+	onRootCertUpdate func() error
+	trigger          chan struct{}
+	now              func() time.Time
+
+	// rotation is the current or last rotation. It is only accessed by Run.
+	rotation *rootRotation
+	// written is the last status written to the status ConfigMap. It is only accessed by Run.
+	written *RootRotationStatus
+	// inProgress is whether a rotation is in progress, during which the rotator owns the CA certs.
+	inProgress atomic.Bool
+}
+
+type rootRotation struct {
+	status        RootRotationStatus
+	previousRoots []byte
+	targetRoots   []byte
+	// roots are the previous and target roots.
+	roots     []byte
+	pushCount uint64
+}
+
+// NewPluggedCARootRotator returns a root rotator for the plugged-in CA ca. onRootCertUpdate is called when the roots
+// or the signing cert of the CA are updated, to publish them.
+func NewPluggedCARootRotator(config PluggedCARootRotatorConfig, ca *IstioCA, tracker ProxySyncTracker,
+	onRootCertUpdate func() error,
+) *PluggedCARootRotator {
+	return &PluggedCARootRotator{
+		config:           config,
+		ca:               ca,
+		tracker:          tracker,
+		onRootCertUpdate: onRootCertUpdate,
+		trigger:          make(chan struct{}, 1),
+		now:              time.Now,
+	}
+}
+
+// Restore resumes the rotation to the roots of the CA, if it is in progress according to the status of the replicas,
+// as when istiod restarts during a rotation: the CA loaded the new plugged-in CA files, and the previous roots are
+// published again until the retirement delay. It must be called before the roots of the CA are published.
+//
+// The previous signing key is not available anymore, so if the restart happened before the switch to the new signing
+// cert, the replica signs with it while waiting for the proxies to acknowledge the new roots.
+func (r *PluggedCARootRotator) Restore() {
+	statuses, err := r.replicaStatuses()
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			pluggedRootRotatorLog.Errorf("failed to read the root rotation status, not resuming any rotation: %v", err)
+		}
+		return
+	}
+	bundle := r.ca.GetCAKeyCertBundle()
+	target := bundle.GetRootCertPem()
+	targetRoots, err := rootFingerprints(target)
+	if err != nil {
+		return
+	}
+	var resumed *RootRotationStatus
+	var previous []byte
+	for _, replica := range slices.Sort(maps.Keys(statuses)) {
+		status := statuses[replica]
+		if !slices.Equal(status.TargetRoots, targetRoots) {
+			continue
+		}
+		switch status.Stage {
+		case RootRotationCompleted:
+			// The previous roots were already removed.
+			return
+		case RootRotationDistributing, RootRotationRetiring:
+			if status.PreviousRootsPEM == "" || (status.Stage == RootRotationRetiring && status.RetireTime == nil) {
+				continue
+			}
+			previous = mergeRoots(previous, []byte(status.PreviousRootsPEM))
+			// Resume from the most advanced stage, keeping the previous roots until the latest retire time.
+			if resumed == nil || (status.Stage == RootRotationRetiring &&
+				(resumed.Stage != RootRotationRetiring || status.RetireTime.After(*resumed.RetireTime))) {
+				resumed = &status
+			}
+		}
+	}
+	if resumed == nil {
+		return
+	}
+	previousRoots, err := rootFingerprints(previous)
+	if err != nil {
+		pluggedRootRotatorLog.Errorf("invalid previous roots in the root rotation status: %v", err)
+		return
+	}
+	rot := &rootRotation{
+		status: RootRotationStatus{
+			Stage:            resumed.Stage,
+			TargetRoots:      targetRoots,
+			PreviousRoots:    previousRoots,
+			PreviousRootsPEM: string(previous),
+			StartTime:        resumed.StartTime,
+			SwitchTime:       resumed.SwitchTime,
+			RetireTime:       resumed.RetireTime,
+			Message:          "resumed after a restart",
+		},
+		previousRoots: previous,
+		targetRoots:   target,
+		roots:         mergeRoots(previous, target),
+	}
+	if err := bundle.VerifyAndSetRootCert(rot.roots); err != nil {
+		pluggedRootRotatorLog.Errorf("failed to restore the previous roots: %v", err)
+		return
+	}
+	rot.pushCount = r.tracker.PushCount()
+	r.rotation = rot
+	r.inProgress.Store(true)
+	pluggedRootRotatorLog.Infof("resumed rotation of the roots %v to %v in stage %s", previousRoots, targetRoots, rot.status.Stage)
+}
+
+// Run checks the plugged-in CA files and advances the rotation periodically, and when triggered.
+func (r *PluggedCARootRotator) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(r.config.CheckInterval)
+	defer ticker.Stop()
+	r.reconcile()
+	for {
+		select {
+		case <-ticker.C:
+			r.reconcile()
+		case <-r.trigger:
+			r.reconcile()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// InProgress returns whether a rotation is in progress. The plugged-in CA files must not be loaded by other means
+// until it completes.
+func (r *PluggedCARootRotator) InProgress() bool {
+	return r.inProgress.Load()
+}
+
+// Trigger requests a check of the plugged-in CA files, for instance when they changed.
+func (r *PluggedCARootRotator) Trigger() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (r *PluggedCARootRotator) reconcile() {
+	status := r.step()
+	// Settled statuses are not refreshed, see waitingReplicas.
+	if !settled(status.Stage) || r.written == nil || !statusEqual(*r.written, status) {
+		status.LastUpdate = r.now()
+		if err := r.writeStatus(status); err != nil {
+			pluggedRootRotatorLog.Errorf("failed to update root rotation status: %v", err)
+		} else {
+			r.written = &status
+		}
+	}
+	if err := r.removeDeletedReplicas(); err != nil {
+		pluggedRootRotatorLog.Warnf("failed to remove the root rotation status of deleted replicas: %v", err)
+	}
+}
+
+// settled returns whether the status of a replica in stage only changes when the plugged-in CA files do, so it is not
+// written again until then.
+func settled(stage RootRotationStage) bool {
+	return stage == RootRotationIdle || stage == RootRotationCompleted
+}
+
+// statusEqual returns whether a and b are equal, regardless of their update time.
+func statusEqual(a, b RootRotationStatus) bool {
+	a.LastUpdate, b.LastUpdate = time.Time{}, time.Time{}
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return bytes.Equal(ja, jb)
+}
+
+// step advances the rotation, and returns the status of the replica.
+func (r *PluggedCARootRotator) step() RootRotationStatus {
+	target, err := os.ReadFile(r.config.FileBundle.RootCertFile)
+	if err != nil {
+		return r.transientError(fmt.Sprintf("failed to read root cert: %v", err))
+	}
+	targetRoots, err := rootFingerprints(target)
+	if err != nil {
+		return r.transientError(fmt.Sprintf("invalid root cert: %v", err))
+	}
+
+	rot := r.rotation
+	if rot == nil || rot.status.Stage == RootRotationFailed || !slices.Equal(rot.status.TargetRoots, targetRoots) {
+		current := r.ca.GetCAKeyCertBundle().GetRootCertPem()
+		currentRoots, _ := rootFingerprints(current)
+		if slices.Equal(currentRoots, targetRoots) {
+			r.rotation = nil
+			r.inProgress.Store(false)
+			return RootRotationStatus{Stage: RootRotationIdle, TargetRoots: targetRoots}
+		}
+		r.inProgress.Store(true)
+		rot = r.start(current, target)
+		r.rotation = rot
+		// The proxies acknowledge the new roots after the next push.
+		r.inProgress.Store(rot.status.Stage == RootRotationDistributing)
+		return rot.status
+	}
+	switch rot.status.Stage {
+	case RootRotationDistributing:
+		r.distribute(rot)
+	case RootRotationRetiring:
+		r.retire(rot)
+	}
+	r.inProgress.Store(rot.status.Stage == RootRotationDistributing || rot.status.Stage == RootRotationRetiring)
+	return rot.status
+}
+
+// transientError returns the status of the current rotation with an error, which is retried at the next check.
+func (r *PluggedCARootRotator) transientError(msg string) RootRotationStatus {
+	pluggedRootRotatorLog.Warn(msg)
+	if r.rotation == nil {
+		return RootRotationStatus{Stage: RootRotationIdle, Message: msg}
+	}
+	r.rotation.status.Message = msg
+	return r.rotation.status
+}
+
+// start publishes the current and target roots, keeping the current signing cert.
+func (r *PluggedCARootRotator) start(current, target []byte) *rootRotation {
+	now := r.now()
+	targetRoots, _ := rootFingerprints(target)
+	previousRoots, _ := rootFingerprints(current)
+	rot := &rootRotation{
+		status: RootRotationStatus{
+			Stage:            RootRotationFailed,
+			TargetRoots:      targetRoots,
+			PreviousRoots:    previousRoots,
+			PreviousRootsPEM: string(current),
+			StartTime:        &now,
+		},
+		previousRoots: current,
+		targetRoots:   target,
+		roots:         mergeRoots(current, target),
+	}
+	// Check the new signing cert before publishing anything.
+	if err := r.loadSigningCert(util.NewKeyCertBundleFromPem(nil, nil, nil, nil), target); err != nil {
+		rot.status.Message = fmt.Sprintf("invalid signing cert for the new roots: %v", err)
+		pluggedRootRotatorLog.Error(rot.status.Message)
+		return rot
+	}
+	bundle := r.ca.GetCAKeyCertBundle()
+	if err := bundle.VerifyAndSetRootCert(rot.roots); err != nil {
+		rot.status.Message = fmt.Sprintf("failed to add the new roots: %v", err)
+		pluggedRootRotatorLog.Error(rot.status.Message)
+		return rot
+	}
+	if err := r.onRootCertUpdate(); err != nil {
+		_ = bundle.VerifyAndSetRootCert(current)
+		rot.status.Message = fmt.Sprintf("failed to publish the new roots: %v", err)
+		pluggedRootRotatorLog.Error(rot.status.Message)
+		return rot
+	}
+	// Pushes counted from now on have the new roots.
+	rot.pushCount = r.tracker.PushCount()
+	rot.status.Stage = RootRotationDistributing
+	rot.status.Message = "published the previous and new roots"
+	pluggedRootRotatorLog.Infof("started rotation of the roots %v to %v", previousRoots, targetRoots)
+	return rot
+}
+
+// distribute switches to the new signing cert, once all the proxies acknowledged the new roots.
+func (r *PluggedCARootRotator) distribute(rot *rootRotation) {
+	synced, pending, untracked := r.tracker.TrustBundleSyncStatus(rot.pushCount)
+	sort.Strings(pending)
+	rot.status.SyncedProxies = len(synced)
+	rot.status.PendingProxies = len(pending)
+	rot.status.UntrackedProxies = len(untracked)
+	rot.status.PendingProxyIDs = pending[:min(len(pending), maxPendingProxyIDs)]
+	if len(pending) > 0 {
+		rot.status.Message = fmt.Sprintf("waiting for %d proxies to acknowledge the new roots", len(pending))
+		return
+	}
+	if waiting, err := r.waitingReplicas(rot); err != nil {
+		rot.status.Message = fmt.Sprintf("failed to read the status of the other replicas: %v", err)
+		return
+	} else if len(waiting) > 0 {
+		rot.status.Message = fmt.Sprintf("waiting for the proxies of replicas %s to acknowledge the new roots",
+			strings.Join(waiting, ", "))
+		return
+	}
+
+	bundle := r.ca.GetCAKeyCertBundle()
+	if err := r.loadSigningCert(bundle, rot.roots); err != nil {
+		rot.status.Message = fmt.Sprintf("failed to load the new signing cert: %v", err)
+		pluggedRootRotatorLog.Error(rot.status.Message)
+		return
+	}
+	if err := r.onRootCertUpdate(); err != nil {
+		pluggedRootRotatorLog.Errorf("failed to publish the new signing cert: %v", err)
+	}
+	now := r.now()
+	retire := now.Add(r.config.RetirementDelay)
+	rot.status.SwitchTime = &now
+	rot.status.RetireTime = &retire
+	rot.status.Stage = RootRotationRetiring
+	rot.status.Message = "signing with the new signing cert"
+	pluggedRootRotatorLog.Infof("all proxies acknowledged the new roots, signing with the new signing cert; "+
+		"the previous roots will be removed at %v", retire)
+}
+
+// retire removes the previous roots after the retirement delay.
+func (r *PluggedCARootRotator) retire(rot *rootRotation) {
+	bundle := r.ca.GetCAKeyCertBundle()
+	if cert, err := os.ReadFile(r.config.FileBundle.SigningCertFile); err == nil {
+		certInBundle, _, _, _ := bundle.GetAllPem()
+		if !bytes.Equal(cert, certInBundle) {
+			// The signing cert was rotated again, without changing the roots.
+			if err := r.loadSigningCert(bundle, rot.roots); err != nil {
+				pluggedRootRotatorLog.Errorf("failed to load the updated signing cert: %v", err)
+			} else if err := r.onRootCertUpdate(); err != nil {
+				pluggedRootRotatorLog.Errorf("failed to publish the updated signing cert: %v", err)
+			}
+		}
+	}
+	if r.now().Before(*rot.status.RetireTime) {
+		return
+	}
+	if err := bundle.VerifyAndSetRootCert(rot.targetRoots); err != nil {
+		rot.status.Message = fmt.Sprintf("failed to remove the previous roots: %v", err)
+		pluggedRootRotatorLog.Error(rot.status.Message)
+		return
+	}
+	if err := r.onRootCertUpdate(); err != nil {
+		pluggedRootRotatorLog.Errorf("failed to publish the new roots: %v", err)
+	}
+	rot.status.Stage = RootRotationCompleted
+	rot.status.Message = "removed the previous roots"
+	pluggedRootRotatorLog.Infof("completed rotation of the roots to %v", rot.status.TargetRoots)
+}
+
+// loadSigningCert loads the signing cert and key from the plugged-in CA files into bundle, with roots.
+func (r *PluggedCARootRotator) loadSigningCert(bundle *util.KeyCertBundle, roots []byte) error {
+	fb := r.config.FileBundle
+	cert, err := os.ReadFile(fb.SigningCertFile)
+	if err != nil {
+		return err
+	}
+	var chain []byte
+	for _, f := range fb.CertChainFiles {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		chain = append(chain, b...)
+	}
+	if r.config.Signer != nil {
+		return bundle.VerifyAndSetAllWithSigner(cert, r.config.Signer, chain, roots)
+	}
+	key, err := os.ReadFile(fb.SigningKeyFile)
+	if err != nil {
+		return err
+	}
+	return bundle.VerifyAndSetAll(cert, key, chain, roots)
+}
+
+// waitingReplicas returns the other replicas whose proxies did not acknowledge the roots of rot yet. The replicas in a
+// rotation which did not update their status for a few check intervals are ignored. Settled statuses are not refreshed,
+// so they are not ignored, but they are removed with the pod of the replica.
+func (r *PluggedCARootRotator) waitingReplicas(rot *rootRotation) ([]string, error) {
+	statuses, err := r.replicaStatuses()
+	if err != nil {
+		return nil, err
+	}
+	var waiting []string
+	for replica, status := range statuses {
+		if replica == r.config.Replica {
+			continue
+		}
+		if !settled(status.Stage) && r.stale(status) {
+			continue
+		}
+		if !slices.Equal(status.TargetRoots, rot.status.TargetRoots) {
+			// The replica did not see the new roots yet.
+			waiting = append(waiting, replica)
+			continue
+		}
+		switch status.Stage {
+		case RootRotationDistributing:
+			if status.PendingProxies > 0 {
+				waiting = append(waiting, replica)
+			}
+		case RootRotationFailed:
+			waiting = append(waiting, replica)
+		}
+	}
+	sort.Strings(waiting)
+	return waiting, nil
+}
+
+func (r *PluggedCARootRotator) stale(status RootRotationStatus) bool {
+	return r.now().Sub(status.LastUpdate) > staleReplicaIntervals*r.config.CheckInterval
+}
+
+// removeDeletedReplicas removes the statuses of the other replicas which were not updated for a few check intervals,
+// and whose pod was deleted.
+func (r *PluggedCARootRotator) removeDeletedReplicas() error {
+	statuses, err := r.replicaStatuses()
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), statusUpdateTimeout)
+	defer cancel()
+	removed := map[string]any{}
+	for replica, status := range statuses {
+		if replica == r.config.Replica || !r.stale(status) {
+			continue
+		}
+		_, err := r.config.Client.Pods(r.config.Namespace).Get(ctx, replica, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			// A null value removes the key.
+			removed[replica] = nil
+		} else if err != nil {
+			return err
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	patch, err := json.Marshal(map[string]any{"data": removed})
+	if err != nil {
+		return err
+	}
+	_, err = r.config.Client.ConfigMaps(r.config.Namespace).Patch(ctx, RootRotationStatusConfigMap, types.MergePatchType, patch,
+		metav1.PatchOptions{})
+	if err == nil {
+		pluggedRootRotatorLog.Infof("removed the root rotation status of deleted replicas %v", slices.Sort(maps.Keys(removed)))
+	}
+	return err
+}
+
+// replicaStatuses returns the statuses of the replicas in the status ConfigMap.
+func (r *PluggedCARootRotator) replicaStatuses() (map[string]RootRotationStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), statusUpdateTimeout)
+	defer cancel()
+	cm, err := r.config.Client.ConfigMaps(r.config.Namespace).Get(ctx, RootRotationStatusConfigMap, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	statuses := make(map[string]RootRotationStatus, len(cm.Data))
+	for replica, data := range cm.Data {
+		status := RootRotationStatus{}
+		if err := json.Unmarshal([]byte(data), &status); err != nil {
+			pluggedRootRotatorLog.Warnf("invalid root rotation status of replica %s: %v", replica, err)
+			continue
+		}
+		statuses[replica] = status
+	}
+	return statuses, nil
+}
+
+// writeStatus writes the status of the replica to the status ConfigMap.
+func (r *PluggedCARootRotator) writeStatus(status RootRotationStatus) error {
+	b, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), statusUpdateTimeout)
+	defer cancel()
+	configMaps := r.config.Client.ConfigMaps(r.config.Namespace)
+	// Patch the key of the replica only, for replicas not to conflict with each other.
+	patch, err := json.Marshal(map[string]any{"data": map[string]string{r.config.Replica: string(b)}})
+	if err != nil {
+		return err
+	}
+	_, err = configMaps.Patch(ctx, RootRotationStatusConfigMap, types.MergePatchType, patch, metav1.PatchOptions{})
+	if errors.IsNotFound(err) {
+		_, err = configMaps.Create(ctx, &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: RootRotationStatusConfigMap, Namespace: r.config.Namespace},
+			Data:       map[string]string{r.config.Replica: string(b)},
+		}, metav1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			// Created by another replica in the meantime.
+			_, err = configMaps.Patch(ctx, RootRotationStatusConfigMap, types.MergePatchType, patch, metav1.PatchOptions{})
+		}
+	}
+	return err
+}
+
+// rootFingerprints returns the sorted SHA-256 fingerprints of the PEM encoded roots.
+func rootFingerprints(roots []byte) ([]string, error) {
+	var fingerprints []string
+	for _, der := range certsDER(roots) {
+		sum := sha256.Sum256(der)
+		fingerprints = append(fingerprints, hex.EncodeToString(sum[:]))
+	}
+	if len(fingerprints) == 0 {
+		return nil, fmt.Errorf("no certificate found")
+	}
+	sort.Strings(fingerprints)
+	return slices.FilterDuplicatesPresorted(fingerprints), nil
+}
+
+// mergeRoots returns the PEM encoded roots of a, followed by the roots of b not in a.
+func mergeRoots(a, b []byte) []byte {
+	existing := sets.New[string]()
+	for _, der := range certsDER(a) {
+		existing.Insert(string(der))
+	}
+	merged := a
+	for _, der := range certsDER(b) {
+		if existing.InsertContains(string(der)) {
+			continue
+		}
+		merged = util.AppendCertByte(merged, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	}
+	return merged
+}
+
+func certsDER(pemCerts []byte) [][]byte {
+	var certs [][]byte
+	for {
+		var block *pem.Block
+		block, pemCerts = pem.Decode(pemCerts)
+		if block == nil {
+			return certs
+		}
+		if block.Type == "CERTIFICATE" {
+			certs = append(certs, block.Bytes)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+type fakeProxySyncTracker struct {
+	pushCount uint64
+	pending   []string
+}
+
+func (f *fakeProxySyncTracker) PushCount() uint64 {
+	return f.pushCount
+}
+
+func (f *fakeProxySyncTracker) TrustBundleSyncStatus(uint64) (synced, pending, untracked []string) {
+	return []string{"synced.default"}, f.pending, []string{"ztunnel.istio-system"}
+}
+
+// writeCACerts writes the sample CA certs, with the given suffix, as the plugged-in CA files of dir.
+func writeCACerts(t *testing.T, dir string, suffix string) {
+	for dst, src := range map[string]string{
+		CACertFile:       "ca-cert" + suffix + ".pem",
+		CAPrivateKeyFile: "ca-key" + suffix + ".pem",
+		CertChainFile:    "cert-chain" + suffix + ".pem",
+		RootCertFile:     "root-cert" + suffix + ".pem",
+	} {
+		b, err := os.ReadFile(filepath.Join(env.IstioSrc, "samples/certs", src))
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, dst), b, 0o600))
+	}
+}
+
+func readSample(t *testing.T, name string) []byte {
+	b, err := os.ReadFile(filepath.Join(env.IstioSrc, "samples/certs", name))
+	assert.NoError(t, err)
+	return b
+}
+
+type rotatorTest struct {
+	t        *testing.T
+	rotator  *PluggedCARootRotator
+	tracker  *fakeProxySyncTracker
+	client   *fake.Clientset
+	now      time.Time
+	updates  int
+	caBundle func() (cert, roots []byte)
+}
+
+func newRotatorTest(t *testing.T) *rotatorTest {
+	dir := t.TempDir()
+	writeCACerts(t, dir, "")
+	rt := &rotatorTest{
+		t:       t,
+		tracker: &fakeProxySyncTracker{pushCount: 1},
+		client:  fake.NewClientset(),
+		now:     time.Now(),
+	}
+	rt.newRotator(dir)
+	return rt
+}
+
+// newRotator creates the CA from the plugged-in CA files of dir, and its rotator, as when istiod starts.
+func (rt *rotatorTest) newRotator(dir string) {
+	t := rt.t
+	fileBundle := SigningCAFileBundle{
+		RootCertFile:    filepath.Join(dir, RootCertFile),
+		CertChainFiles:  []string{filepath.Join(dir, CertChainFile)},
+		SigningCertFile: filepath.Join(dir, CACertFile),
+		SigningKeyFile:  filepath.Join(dir, CAPrivateKeyFile),
+	}
+	caopts, err := NewPluggedCertIstioCAOptions(fileBundle, time.Hour, 24*time.Hour, 2048)
+	assert.NoError(t, err)
+	ca, err := NewIstioCA(caopts)
+	assert.NoError(t, err)
+
+	rt.rotator = NewPluggedCARootRotator(PluggedCARootRotatorConfig{
+		FileBundle:      fileBundle,
+		Client:          rt.client.CoreV1(),
+		Namespace:       caNamespace,
+		Replica:         "istiod-a",
+		CheckInterval:   time.Minute,
+		RetirementDelay: time.Hour,
+	}, ca, rt.tracker, func() error {
+		rt.updates++
+		return nil
+	})
+	rt.rotator.now = func() time.Time { return rt.now }
+	rt.caBundle = func() (cert, roots []byte) {
+		cert, _, _, roots = ca.GetCAKeyCertBundle().GetAllPem()
+		return cert, roots
+	}
+}
+
+// reconcile reconciles, and returns the status of the replica.
+func (rt *rotatorTest) reconcile() RootRotationStatus {
+	rt.t.Helper()
+	rt.rotator.reconcile()
+	cm, err := rt.client.CoreV1().ConfigMaps(caNamespace).Get(context.Background(), RootRotationStatusConfigMap, metav1.GetOptions{})
+	assert.NoError(rt.t, err)
+	status := RootRotationStatus{}
+	assert.NoError(rt.t, json.Unmarshal([]byte(cm.Data["istiod-a"]), &status))
+	return status
+}
+
+func (rt *rotatorTest) setReplicaStatus(replica string, status RootRotationStatus) {
+	rt.t.Helper()
+	b, err := json.Marshal(status)
+	assert.NoError(rt.t, err)
+	cms := rt.client.CoreV1().ConfigMaps(caNamespace)
+	cm, err := cms.Get(context.Background(), RootRotationStatusConfigMap, metav1.GetOptions{})
+	assert.NoError(rt.t, err)
+	cm.Data[replica] = string(b)
+	_, err = cms.Update(context.Background(), cm, metav1.UpdateOptions{})
+	assert.NoError(rt.t, err)
+}
+
+func TestPluggedCARootRotation(t *testing.T) {
+	rt := newRotatorTest(t)
+	dir := filepath.Dir(rt.rotator.config.FileBundle.RootCertFile)
+	oldCert, oldRoots := rt.caBundle()
+
+	status := rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationIdle)
+	assert.Equal(t, rt.updates, 0)
+	assert.Equal(t, rt.rotator.InProgress(), false)
+
+	// Another replica, which will not see the new roots at first
+	rt.setReplicaStatus("istiod-b", RootRotationStatus{Stage: RootRotationIdle, TargetRoots: status.TargetRoots, LastUpdate: rt.now})
+
+	// The new roots are published, while still signing with the previous cert
+	writeCACerts(t, dir, "-alt")
+	rt.tracker.pending = []string{"pending.default"}
+	status = rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationDistributing)
+	assert.Equal(t, len(status.PreviousRoots), 1)
+	assert.Equal(t, rt.updates, 1)
+	assert.Equal(t, rt.rotator.InProgress(), true)
+	status = rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationDistributing)
+	assert.Equal(t, status.PendingProxyIDs, []string{"pending.default"})
+	assert.Equal(t, status.SyncedProxies, 1)
+	assert.Equal(t, status.UntrackedProxies, 1)
+	cert, roots := rt.caBundle()
+	assert.Equal(t, cert, oldCert)
+	if !bytes.Contains(roots, oldRoots) || !bytes.Contains(roots, readSample(t, "root-cert-alt.pem")) {
+		t.Fatalf("expected previous and new roots, got %s", roots)
+	}
+
+	// The proxies of this replica acknowledged the new roots, but not the ones of the other replica
+	rt.tracker.pending = nil
+	status = rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationDistributing)
+	assert.Equal(t, status.Message, "waiting for the proxies of replicas istiod-b to acknowledge the new roots")
+	rt.setReplicaStatus("istiod-b", RootRotationStatus{
+		Stage: RootRotationDistributing, TargetRoots: status.TargetRoots, PendingProxies: 2, LastUpdate: rt.now,
+	})
+	status = rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationDistributing)
+
+	// All proxies acknowledged the new roots, the CA signs with the new cert
+	rt.setReplicaStatus("istiod-b", RootRotationStatus{
+		Stage: RootRotationDistributing, TargetRoots: status.TargetRoots, LastUpdate: rt.now,
+	})
+	status = rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationRetiring)
+	assert.Equal(t, *status.RetireTime, rt.now.Add(time.Hour).UTC())
+	assert.Equal(t, rt.updates, 2)
+	cert, rootsAfterSwitch := rt.caBundle()
+	assert.Equal(t, cert, readSample(t, "ca-cert-alt.pem"))
+	assert.Equal(t, rootsAfterSwitch, roots)
+
+	// The previous roots are removed after the retirement delay
+	rt.now = rt.now.Add(time.Hour)
+	status = rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationCompleted)
+	assert.Equal(t, rt.updates, 3)
+	assert.Equal(t, rt.rotator.InProgress(), false)
+	_, roots = rt.caBundle()
+	assert.Equal(t, roots, readSample(t, "root-cert-alt.pem"))
+
+	// Completed rotations are not repeated
+	status = rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationCompleted)
+	assert.Equal(t, rt.updates, 3)
+}
+
+func TestPluggedCARootRotationStaleReplica(t *testing.T) {
+	rt := newRotatorTest(t)
+	rt.reconcile()
+	rt.setReplicaStatus("istiod-b", RootRotationStatus{Stage: RootRotationIdle, LastUpdate: rt.now.Add(-time.Hour)})
+
+	writeCACerts(t, filepath.Dir(rt.rotator.config.FileBundle.RootCertFile), "-alt")
+	status := rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationDistributing)
+	// The replica which did not update its status is ignored
+	status = rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationRetiring)
+}
+
+func TestPluggedCARootRotationInvalidSigningCert(t *testing.T) {
+	rt := newRotatorTest(t)
+	dir := filepath.Dir(rt.rotator.config.FileBundle.RootCertFile)
+	_, oldRoots := rt.caBundle()
+
+	// Only the root is updated, the signing cert is not signed by it
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, RootCertFile), readSample(t, "root-cert-alt.pem"), 0o600))
+	status := rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationFailed)
+	assert.Equal(t, rt.updates, 0)
+	_, roots := rt.caBundle()
+	assert.Equal(t, roots, oldRoots)
+
+	// The rotation starts once the signing cert is updated
+	writeCACerts(t, dir, "-alt")
+	status = rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationDistributing)
+}
+
+func TestPluggedCARootRotationRestore(t *testing.T) {
+	rt := newRotatorTest(t)
+	dir := filepath.Dir(rt.rotator.config.FileBundle.RootCertFile)
+	_, oldRoots := rt.caBundle()
+	newRoots := readSample(t, "root-cert-alt.pem")
+	rt.reconcile()
+	writeCACerts(t, dir, "-alt")
+	rt.reconcile()
+	status := rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationRetiring)
+
+	// istiod restarts during the retirement delay: the CA loads the new files, and the previous roots are restored
+	rt.newRotator(dir)
+	_, roots := rt.caBundle()
+	assert.Equal(t, roots, newRoots)
+	rt.rotator.Restore()
+	assert.Equal(t, rt.rotator.InProgress(), true)
+	cert, roots := rt.caBundle()
+	assert.Equal(t, cert, readSample(t, "ca-cert-alt.pem"))
+	if !bytes.Contains(roots, oldRoots) || !bytes.Contains(roots, newRoots) {
+		t.Fatalf("expected previous and new roots, got %s", roots)
+	}
+	status = rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationRetiring)
+	assert.Equal(t, *status.RetireTime, rt.now.Add(time.Hour).UTC())
+
+	rt.now = rt.now.Add(time.Hour)
+	status = rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationCompleted)
+	_, roots = rt.caBundle()
+	assert.Equal(t, roots, newRoots)
+
+	// Completed rotations are not resumed
+	rt.newRotator(dir)
+	rt.rotator.Restore()
+	assert.Equal(t, rt.rotator.InProgress(), false)
+	_, roots = rt.caBundle()
+	assert.Equal(t, roots, newRoots)
+}
+
+func TestPluggedCARootRotationRestoreDistributing(t *testing.T) {
+	rt := newRotatorTest(t)
+	dir := filepath.Dir(rt.rotator.config.FileBundle.RootCertFile)
+	_, oldRoots := rt.caBundle()
+	rt.reconcile()
+	writeCACerts(t, dir, "-alt")
+	rt.tracker.pending = []string{"pending.default"}
+	status := rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationDistributing)
+
+	// istiod restarts before the switch: the previous roots are still published until the proxies acknowledge them
+	rt.newRotator(dir)
+	rt.rotator.Restore()
+	assert.Equal(t, rt.rotator.InProgress(), true)
+	_, roots := rt.caBundle()
+	if !bytes.Contains(roots, oldRoots) {
+		t.Fatalf("expected previous roots, got %s", roots)
+	}
+	status = rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationDistributing)
+
+	rt.tracker.pending = nil
+	status = rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationRetiring)
+	_, roots = rt.caBundle()
+	if !bytes.Contains(roots, oldRoots) {
+		t.Fatalf("expected previous roots, got %s", roots)
+	}
+}
+
+func TestPluggedCARootRotationSettledStatus(t *testing.T) {
+	rt := newRotatorTest(t)
+	status := rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationIdle)
+	written := status.LastUpdate
+
+	// The idle status is not written again while it does not change
+	rt.now = rt.now.Add(time.Hour)
+	status = rt.reconcile()
+	assert.Equal(t, status.LastUpdate.Equal(written), true)
+
+	// Another replica, idle for long, still waits for its proxies as long as its pod exists
+	rt.setReplicaStatus("istiod-b", RootRotationStatus{Stage: RootRotationIdle, LastUpdate: rt.now.Add(-time.Hour)})
+	_, err := rt.client.CoreV1().Pods(caNamespace).Create(context.Background(),
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "istiod-b", Namespace: caNamespace}}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	writeCACerts(t, filepath.Dir(rt.rotator.config.FileBundle.RootCertFile), "-alt")
+	status = rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationDistributing)
+	assert.Equal(t, status.LastUpdate.Equal(rt.now), true)
+	status = rt.reconcile()
+	assert.Equal(t, status.Stage, RootRotationDistributing)
+	assert.Equal(t, status.Message, "waiting for the proxies of replicas istiod-b to acknowledge the new roots")
+}
+
+func TestPluggedCARootRotationRemoveDeletedReplicas(t *testing.T) {
+	rt := newRotatorTest(t)
+	rt.reconcile()
+	for _, replica := range []string{"istiod-b", "istiod-c"} {
+		_, err := rt.client.CoreV1().Pods(caNamespace).Create(context.Background(),
+			&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: replica, Namespace: caNamespace}}, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	rt.setReplicaStatus("istiod-b", RootRotationStatus{Stage: RootRotationIdle, LastUpdate: rt.now})
+	rt.setReplicaStatus("istiod-c", RootRotationStatus{Stage: RootRotationIdle, LastUpdate: rt.now})
+	rt.setReplicaStatus("istiod-d", RootRotationStatus{Stage: RootRotationRetiring, PreviousRootsPEM: "roots", LastUpdate: rt.now})
+	replicas := func() []string {
+		cm, err := rt.client.CoreV1().ConfigMaps(caNamespace).Get(context.Background(), RootRotationStatusConfigMap, metav1.GetOptions{})
+		assert.NoError(t, err)
+		return slices.Sort(maps.Keys(cm.Data))
+	}
+
+	// Recently updated statuses are kept, even if the pod was deleted
+	assert.NoError(t, rt.client.CoreV1().Pods(caNamespace).Delete(context.Background(), "istiod-c", metav1.DeleteOptions{}))
+	rt.reconcile()
+	assert.Equal(t, replicas(), []string{"istiod-a", "istiod-b", "istiod-c", "istiod-d"})
+
+	// Stale statuses are removed once their pod is deleted
+	rt.now = rt.now.Add(time.Hour)
+	rt.reconcile()
+	assert.Equal(t, replicas(), []string{"istiod-a", "istiod-b"})
+}
@@ -0,0 +1,32 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"istio.io/istio/pkg/monitoring"
+)
+
+var (
+	signLatency = monitoring.NewDistribution(
+		"citadel_external_signer_sign_seconds",
+		"Latency in seconds of signing requests to the external CA signer.",
+		[]float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	)
+
+	signErrors = monitoring.NewSum(
+		"citadel_external_signer_sign_err_count",
+		"The number of errors occurred when signing with the external CA signer.",
+	)
+)
@@ -0,0 +1,29 @@
+//go:build !linux
+// +build !linux
+
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"errors"
+	"net"
+)
+
+// peerUID returns the UID of the process at the other end of a Unix socket connection.
+// Peer credentials are only supported on Linux, so all the connections are rejected on other platforms.
+func peerUID(net.Conn) (uint32, error) {
+	return 0, errors.New("peer credentials are not supported on this platform")
+}
@@ -0,0 +1,137 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"istio.io/istio/pkg/util/sets"
+)
+
+// Server serves the external signer protocol for a crypto.Signer.
+// With a private key loaded in memory, it is a reference software signer, for tests and development; HSM or KMS
+// adapters can use it with a crypto.Signer backed by their key store, or implement the protocol themselves.
+type Server struct {
+	signer      crypto.Signer
+	server      *http.Server
+	allowedUIDs sets.Set[uint32]
+}
+
+// NewServer creates a server signing with signer, for the clients running as one of allowedUIDs, or as the UID of
+// the server process if there are none. The UID of the clients is checked with the peer credentials of the socket,
+// which are only supported on Linux.
+func NewServer(signer crypto.Signer, allowedUIDs ...uint32) *Server {
+	if len(allowedUIDs) == 0 {
+		allowedUIDs = []uint32{uint32(os.Getuid())}
+	}
+	s := &Server{signer: signer, allowedUIDs: sets.New(allowedUIDs...)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+publicKeyPath, s.handlePublicKey)
+	mux.HandleFunc("POST "+signPath, s.handleSign)
+	s.server = &http.Server{Handler: mux}
+	return s
+}
+
+// Serve serves requests on l, until the server is closed. Connections from clients that are not allowed are closed.
+func (s *Server) Serve(l net.Listener) error {
+	if err := s.server.Serve(&authListener{Listener: l, allowedUIDs: s.allowedUIDs}); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+// authListener only accepts the connections from the allowed UIDs.
+type authListener struct {
+	net.Listener
+	allowedUIDs sets.Set[uint32]
+}
+
+func (l *authListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		uid, err := peerUID(conn)
+		if err != nil {
+			signerLog.Warnf("rejected external signer connection: failed to get peer credentials: %v", err)
+			_ = conn.Close()
+			continue
+		}
+		if !l.allowedUIDs.Contains(uid) {
+			signerLog.Warnf("rejected external signer connection from UID %d", uid)
+			_ = conn.Close()
+			continue
+		}
+		return conn, nil
+	}
+}
+
+func (s *Server) handlePublicKey(w http.ResponseWriter, _ *http.Request) {
+	der, err := x509.MarshalPKIXPublicKey(s.signer.Public())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, publicKeyResponse{PublicKey: der})
+}
+
+func (s *Server) handleSign(w http.ResponseWriter, r *http.Request) {
+	var req signRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %v", err))
+		return
+	}
+	hash, err := parseHash(req.Hash)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var opts crypto.SignerOpts = hash
+	if req.PSSSaltLength != nil {
+		opts = &rsa.PSSOptions{SaltLength: *req.PSSSaltLength, Hash: hash}
+	}
+	sig, err := s.signer.Sign(rand.Reader, req.Digest, opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, signResponse{Signature: sig})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(errorResponse{Error: err.Error()})
+}
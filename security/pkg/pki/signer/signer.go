@@ -0,0 +1,239 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package signer implements a crypto.Signer whose private key is held by an external signer, such as an adapter for
+// a HSM or KMS running next to istiod, so that the CA signing key is never loaded in istiod.
+//
+// The external signer serves a small JSON over HTTP protocol, not gRPC, on a Unix socket:
+//
+//	GET  /v1/publickey  returns {"publicKey": <PKIX DER>}
+//	POST /v1/sign       takes {"digest": <bytes>, "hash": "SHA-256", "pssSaltLength": <int>}, returns {"signature": <bytes>}
+//
+// Bytes are base64 encoded. The hash is empty when the message is signed without pre-hashing, as with Ed25519, and
+// pssSaltLength is only set for RSA-PSS signatures. Errors are returned with a non-200 status and {"error": <message>}.
+//
+// As anything able to sign with the CA key can mint workload certificates, the external signer must authenticate its
+// clients, for instance with the peer credentials of the socket as done by Server.
+package signer
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"istio.io/istio/pkg/backoff"
+	"istio.io/istio/pkg/log"
+)
+
+var signerLog = log.RegisterScope("extsigner", "external CA signer")
+
+const (
+	publicKeyPath = "/v1/publickey"
+	signPath      = "/v1/sign"
+
+	// unixPrefix is the prefix of Unix socket addresses.
+	unixPrefix = "unix://"
+
+	signTimeout = 10 * time.Second
+)
+
+type publicKeyResponse struct {
+	PublicKey []byte `json:"publicKey"`
+}
+
+type signRequest struct {
+	Digest        []byte `json:"digest"`
+	Hash          string `json:"hash,omitempty"`
+	PSSSaltLength *int   `json:"pssSaltLength,omitempty"`
+}
+
+type signResponse struct {
+	Signature []byte `json:"signature"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// supportedHashes are the hash functions of the digests that can be signed.
+var supportedHashes = []crypto.Hash{crypto.SHA1, crypto.SHA256, crypto.SHA384, crypto.SHA512}
+
+// Signer signs with the private key held by an external signer. It is safe for concurrent use.
+type Signer struct {
+	address string
+	client  *http.Client
+	public  crypto.PublicKey
+}
+
+var _ crypto.Signer = &Signer{}
+
+// NewSigner connects to the external signer at address, and gets its public key, retrying until ctx is done.
+// The address is a Unix socket, as unix:///path/to/socket.
+func NewSigner(ctx context.Context, address string) (*Signer, error) {
+	path, err := socketPath(address)
+	if err != nil {
+		return nil, err
+	}
+	s := &Signer{
+		address: address,
+		client: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", path)
+				},
+			},
+			Timeout: signTimeout,
+		},
+	}
+	b := backoff.NewExponentialBackOff(backoff.DefaultOption())
+	err = b.RetryWithContext(ctx, func() error {
+		var resp publicKeyResponse
+		if err := s.do(http.MethodGet, publicKeyPath, nil, &resp); err != nil {
+			signerLog.Warnf("failed to get public key from external signer %s: %v", address, err)
+			return err
+		}
+		pub, err := x509.ParsePKIXPublicKey(resp.PublicKey)
+		if err != nil {
+			return fmt.Errorf("invalid public key: %v", err)
+		}
+		s.public = pub
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key from external signer %s: %v", address, err)
+	}
+	signerLog.Infof("using external signer %s", address)
+	return s, nil
+}
+
+// Public returns the public key of the external signer.
+func (s *Signer) Public() crypto.PublicKey {
+	return s.public
+}
+
+// Sign signs digest with the private key of the external signer. rand is not used, as the signer has its own
+// source of randomness.
+func (s *Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	req := signRequest{Digest: digest}
+	if h := opts.HashFunc(); h != 0 {
+		req.Hash = h.String()
+	}
+	if pss, ok := opts.(*rsa.PSSOptions); ok {
+		saltLength := pss.SaltLength
+		req.PSSSaltLength = &saltLength
+	}
+
+	t0 := time.Now()
+	var resp signResponse
+	err := s.do(http.MethodPost, signPath, req, &resp)
+	signLatency.Record(time.Since(t0).Seconds())
+	if err != nil {
+		signErrors.Increment()
+		signerLog.Errorf("failed to sign with external signer %s: %v", s.address, err)
+		return nil, fmt.Errorf("external signer: %v", err)
+	}
+	return resp.Signature, nil
+}
+
+// Close closes the idle connections to the external signer.
+func (s *Signer) Close() {
+	s.client.CloseIdleConnections()
+}
+
+// do sends a request to the external signer, decoding the response into out.
+func (s *Signer) do(method, path string, in any, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	// The host is not used, as the connection is made to the signer address.
+	req, err := http.NewRequest(method, "http://signer"+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errResp errorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return fmt.Errorf("%s (status %d)", errResp.Error, resp.StatusCode)
+		}
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+// socketPath returns the path of the Unix socket of a signer address. Only Unix sockets are supported, as TCP
+// connections cannot be authenticated with peer credentials.
+func socketPath(address string) (string, error) {
+	path, ok := strings.CutPrefix(address, unixPrefix)
+	if !ok || path == "" {
+		return "", fmt.Errorf("invalid external signer address %q: must be a Unix socket, as unix:///path/to/socket", address)
+	}
+	return path, nil
+}
+
+// Listen listens on a signer address, which is a Unix socket, as unix:///path/to/socket.
+// The socket can only be connected to by the owner and group of the process.
+func Listen(address string) (net.Listener, error) {
+	path, err := socketPath(address)
+	if err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o660); err != nil {
+		_ = l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func parseHash(name string) (crypto.Hash, error) {
+	if name == "" {
+		return 0, nil
+	}
+	for _, h := range supportedHashes {
+		if h.String() == name {
+			return h, nil
+		}
+	}
+	return 0, errors.New("unsupported hash " + name)
+}
@@ -0,0 +1,164 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/monitoring/monitortest"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func listen(t *testing.T, key crypto.Signer, allowedUIDs ...uint32) string {
+	address := "unix://" + filepath.Join(t.TempDir(), "signer.sock")
+	l, err := Listen(address)
+	assert.NoError(t, err)
+	server := NewServer(key, allowedUIDs...)
+	go func() { _ = server.Serve(l) }()
+	t.Cleanup(func() { _ = server.Close() })
+	return address
+}
+
+func startServer(t *testing.T, key crypto.Signer) *Signer {
+	s, err := NewSigner(context.Background(), listen(t, key))
+	assert.NoError(t, err)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestSigner(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	msg := []byte("to be signed")
+	digest := sha256.Sum256(msg)
+
+	cases := []struct {
+		name   string
+		key    crypto.Signer
+		digest []byte
+		opts   crypto.SignerOpts
+		verify func(pub crypto.PublicKey, sig []byte) bool
+	}{
+		{
+			name:   "RSA PKCS1v15",
+			key:    rsaKey,
+			digest: digest[:],
+			opts:   crypto.SHA256,
+			verify: func(pub crypto.PublicKey, sig []byte) bool {
+				return rsa.VerifyPKCS1v15(pub.(*rsa.PublicKey), crypto.SHA256, digest[:], sig) == nil
+			},
+		},
+		{
+			name:   "RSA PSS",
+			key:    rsaKey,
+			digest: digest[:],
+			opts:   &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256},
+			verify: func(pub crypto.PublicKey, sig []byte) bool {
+				return rsa.VerifyPSS(pub.(*rsa.PublicKey), crypto.SHA256, digest[:], sig,
+					&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+			},
+		},
+		{
+			name:   "ECDSA",
+			key:    ecKey,
+			digest: digest[:],
+			opts:   crypto.SHA256,
+			verify: func(pub crypto.PublicKey, sig []byte) bool {
+				return ecdsa.VerifyASN1(pub.(*ecdsa.PublicKey), digest[:], sig)
+			},
+		},
+		{
+			name:   "Ed25519",
+			key:    edKey,
+			digest: msg,
+			opts:   crypto.Hash(0),
+			verify: func(pub crypto.PublicKey, sig []byte) bool {
+				return ed25519.Verify(pub.(ed25519.PublicKey), msg, sig)
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := startServer(t, tt.key)
+			assert.Equal(t, s.Public(), tt.key.Public())
+			sig, err := s.Sign(rand.Reader, tt.digest, tt.opts)
+			assert.NoError(t, err)
+			if !tt.verify(s.Public(), sig) {
+				t.Fatal("invalid signature")
+			}
+		})
+	}
+}
+
+type failingSigner struct {
+	crypto.Signer
+}
+
+func (failingSigner) Sign(io.Reader, []byte, crypto.SignerOpts) ([]byte, error) {
+	return nil, errors.New("key is disabled")
+}
+
+func TestSignerError(t *testing.T) {
+	mt := monitortest.New(t)
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s := startServer(t, failingSigner{key})
+	digest := sha256.Sum256([]byte("to be signed"))
+
+	_, err := s.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err == nil || !strings.Contains(err.Error(), "key is disabled") {
+		t.Fatalf("expected signing error, got %v", err)
+	}
+	_, err = s.Sign(rand.Reader, digest[:], crypto.MD5)
+	if err == nil || !strings.Contains(err.Error(), "unsupported hash MD5") {
+		t.Fatalf("expected hash error, got %v", err)
+	}
+	mt.Assert(signErrors.Name(), nil, monitortest.Exactly(2))
+}
+
+func TestSignerAddress(t *testing.T) {
+	for _, address := range []string{"127.0.0.1:8080", "unix://"} {
+		if _, err := NewSigner(context.Background(), address); err == nil || !strings.Contains(err.Error(), "must be a Unix socket") {
+			t.Errorf("expected address error for %q, got %v", address, err)
+		}
+		if _, err := Listen(address); err == nil {
+			t.Errorf("expected address error for %q", address)
+		}
+	}
+}
+
+func TestServerRejectsUnknownUID(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	address := listen(t, key, uint32(os.Getuid())+1)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := NewSigner(ctx, address); err == nil {
+		t.Fatal("expected the connection to be rejected")
+	}
+}
@@ -28,7 +28,6 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
-	"errors"
 	"fmt"
 	"math/big"
 	"os"
@@ -47,8 +46,9 @@ type SupportedECSignatureAlgorithms string
 type SupportedEllipticCurves string
 
 const (
-	// only ECDSA is currently supported
 	EcdsaSigAlg SupportedECSignatureAlgorithms = "ECDSA"
+	// Ed25519SigAlg generates Ed25519 keys. They are always encoded with PKCS#8, and the curve option is ignored.
+	Ed25519SigAlg SupportedECSignatureAlgorithms = "ED25519"
 
 	// supported curves when using ECC
 	P256Curve SupportedEllipticCurves = "P256"
@@ -102,13 +102,12 @@ type CertOptions struct {
 	PKCS8Key bool
 
 	// The type of Elliptical Signature algorithm to use
-	// when generating private keys. Currently only ECDSA is supported.
+	// when generating private keys: ECDSA or ED25519.
 	// If empty, RSA is used, otherwise ECC is used.
 	ECSigAlg SupportedECSignatureAlgorithms
 
-	// The type of Elliptical Signature algorithm to use
-	// when generating private keys. Currently only ECDSA is supported.
-	// If empty, RSA is used, otherwise ECC is used.
+	// The curve to use when generating ECDSA private keys.
+	// If empty, P256 is used.
 	ECCCurve SupportedEllipticCurves
 
 	// Subjective Alternative Name values.
@@ -123,27 +122,11 @@ func GenCertKeyFromOptions(options CertOptions) (pemCert []byte, pemKey []byte,
 	// case, otherwise the certificate is signed by the signer private key
 	// as specified in the CertOptions.
 	if options.ECSigAlg != "" {
-		var ecPriv *ecdsa.PrivateKey
-
-		switch options.ECSigAlg {
-		case EcdsaSigAlg:
-			var curve elliptic.Curve
-			switch options.ECCCurve {
-			case P384Curve:
-				curve = elliptic.P384()
-			default:
-				curve = elliptic.P256()
-			}
-
-			ecPriv, err = ecdsa.GenerateKey(curve, rand.Reader)
-			if err != nil {
-				return nil, nil, fmt.Errorf("cert generation fails at EC key generation (%v)", err)
-			}
-
-		default:
-			return nil, nil, errors.New("cert generation fails due to unsupported EC signature algorithm")
+		ecPriv, err := genECKey(options)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cert generation fails at EC key generation (%v)", err)
 		}
-		return genCert(options, ecPriv, &ecPriv.PublicKey)
+		return genCert(options, ecPriv, ecPriv.Public())
 	}
 
 	if options.RSAKeySize < MinimumRsaKeySize {
@@ -156,6 +139,26 @@ func GenCertKeyFromOptions(options CertOptions) (pemCert []byte, pemKey []byte,
 	return genCert(options, rsaPriv, &rsaPriv.PublicKey)
 }
 
+// genECKey generates a private key using the EC signature algorithm of options.
+func genECKey(options CertOptions) (crypto.Signer, error) {
+	switch options.ECSigAlg {
+	case EcdsaSigAlg:
+		var curve elliptic.Curve
+		switch options.ECCCurve {
+		case P384Curve:
+			curve = elliptic.P384()
+		default:
+			curve = elliptic.P256()
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	case Ed25519SigAlg:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		return priv, err
+	default:
+		return nil, fmt.Errorf("unsupported EC signature algorithm %q", options.ECSigAlg)
+	}
+}
+
 func genCert(options CertOptions, priv any, key any) ([]byte, []byte, error) {
 	template, err := genCertTemplateFromOptions(options)
 	if err != nil {
@@ -431,7 +434,8 @@ func encodePem(isCSR bool, csrOrCert []byte, priv any, pkcs8 bool) (
 	csrOrCertPem = pem.EncodeToMemory(&pem.Block{Type: encodeMsg, Bytes: csrOrCert})
 
 	var encodedKey []byte
-	if pkcs8 {
+	// Ed25519 keys have no other encoding than PKCS#8
+	if _, ok := priv.(ed25519.PrivateKey); pkcs8 || ok {
 		if encodedKey, err = x509.MarshalPKCS8PrivateKey(priv); err != nil {
 			return nil, nil, err
 		}
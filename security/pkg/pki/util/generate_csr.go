@@ -20,13 +20,10 @@ package util
 
 import (
 	"crypto"
-	"crypto/ecdsa"
-	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
-	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -43,21 +40,9 @@ func GenCSR(options CertOptions) ([]byte, []byte, error) {
 	var priv any
 	var err error
 	if options.ECSigAlg != "" {
-		switch options.ECSigAlg {
-		case EcdsaSigAlg:
-			var curve elliptic.Curve
-			switch options.ECCCurve {
-			case P384Curve:
-				curve = elliptic.P384()
-			default:
-				curve = elliptic.P256()
-			}
-			priv, err = ecdsa.GenerateKey(curve, rand.Reader)
-			if err != nil {
-				return nil, nil, fmt.Errorf("EC key generation failed (%v)", err)
-			}
-		default:
-			return nil, nil, errors.New("csr cert generation fails due to unsupported EC signature algorithm")
+		priv, err = genECKey(options)
+		if err != nil {
+			return nil, nil, fmt.Errorf("EC key generation failed (%v)", err)
 		}
 	} else {
 		if options.RSAKeySize < MinimumRsaKeySize {
@@ -16,6 +16,7 @@ package util
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -45,13 +46,20 @@ func TestGenCSR(t *testing.T) {
 				ECSigAlg: EcdsaSigAlg,
 			},
 		},
+		"GenCSR with Ed25519": {
+			csrOptions: CertOptions{
+				Host:     "test_ca.com",
+				Org:      "MyOrg",
+				ECSigAlg: Ed25519SigAlg,
+			},
+		},
 		"GenCSR with EC errors due to invalid signature algorithm": {
 			csrOptions: CertOptions{
 				Host:     "test_ca.com",
 				Org:      "MyOrg",
-				ECSigAlg: "ED25519",
+				ECSigAlg: "SM2",
 			},
-			err: errors.New("csr cert generation fails due to unsupported EC signature algorithm"),
+			err: errors.New(`EC key generation failed (unsupported EC signature algorithm "SM2")`),
 		},
 	}
 
@@ -85,14 +93,14 @@ func TestGenCSR(t *testing.T) {
 		if !strings.HasSuffix(string(csr.Extensions[0].Value), "test_ca.com") {
 			t.Errorf("%s: csr host does not match", id)
 		}
-		if tc.csrOptions.ECSigAlg != "" {
-			if tc.csrOptions.ECSigAlg != EcdsaSigAlg {
-				t.Errorf("%s: Only ECDSA signature algorithms are currently supported", id)
-			}
-			if reflect.TypeOf(csr.PublicKey) != reflect.TypeOf(&ecdsa.PublicKey{}) {
-				t.Errorf("%s: decoded PKCS#8 returned unexpected key type: %T", id, csr.PublicKey)
-			}
-		} else if reflect.TypeOf(csr.PublicKey) != reflect.TypeOf(&rsa.PublicKey{}) {
+		var wantKey any = &rsa.PublicKey{}
+		switch tc.csrOptions.ECSigAlg {
+		case EcdsaSigAlg:
+			wantKey = &ecdsa.PublicKey{}
+		case Ed25519SigAlg:
+			wantKey = ed25519.PublicKey{}
+		}
+		if reflect.TypeOf(csr.PublicKey) != reflect.TypeOf(wantKey) {
 			t.Errorf("%s: decoded PKCS#8 returned unexpected key type: %T", id, csr.PublicKey)
 		}
 	}
@@ -119,6 +127,14 @@ func TestGenCSRPKCS8Key(t *testing.T) {
 				PKCS8Key: true,
 			},
 		},
+		"PKCS8Key with Ed25519": {
+			csrOptions: CertOptions{
+				Host:     "test_ca.com",
+				Org:      "MyOrg",
+				ECSigAlg: Ed25519SigAlg,
+				PKCS8Key: true,
+			},
+		},
 	}
 
 	for id, tc := range cases {
@@ -153,14 +169,14 @@ func TestGenCSRPKCS8Key(t *testing.T) {
 		if err != nil {
 			t.Errorf("%s: failed to parse PKCS#8 private key", id)
 		}
-		if tc.csrOptions.ECSigAlg != "" {
-			if tc.csrOptions.ECSigAlg != EcdsaSigAlg {
-				t.Errorf("%s: Only ECDSA signature algorithms are currently supported", id)
-			}
-			if reflect.TypeOf(key) != reflect.TypeOf(&ecdsa.PrivateKey{}) {
-				t.Errorf("%s: decoded PKCS#8 returned unexpected key type: %T", id, key)
-			}
-		} else if reflect.TypeOf(key) != reflect.TypeOf(&rsa.PrivateKey{}) {
+		var wantKey any = &rsa.PrivateKey{}
+		switch tc.csrOptions.ECSigAlg {
+		case EcdsaSigAlg:
+			wantKey = &ecdsa.PrivateKey{}
+		case Ed25519SigAlg:
+			wantKey = ed25519.PrivateKey{}
+		}
+		if reflect.TypeOf(key) != reflect.TypeOf(wantKey) {
 			t.Errorf("%s: decoded PKCS#8 returned unexpected key type: %T", id, key)
 		}
 	}
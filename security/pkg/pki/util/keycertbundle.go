@@ -21,6 +21,7 @@ package util
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
@@ -70,7 +71,7 @@ func NewVerifiedKeyCertBundleFromPem(certBytes, privKeyBytes, certChainBytes, ro
 func NewVerifiedKeyCertBundleFromFile(certFile string, privKeyFile string, certChainFiles []string, rootCertFile string) (
 	*KeyCertBundle, error,
 ) {
-	certBytes, err := os.ReadFile(certFile)
+	certBytes, certChainBytes, rootCertBytes, err := readCertFiles(certFile, certChainFiles, rootCertFile)
 	if err != nil {
 		return nil, err
 	}
@@ -78,23 +79,19 @@ func NewVerifiedKeyCertBundleFromFile(certFile string, privKeyFile string, certC
 	if err != nil {
 		return nil, err
 	}
-	var certChainBytes []byte
-	if len(certChainFiles) > 0 {
-		for _, f := range certChainFiles {
-			var b []byte
-
-			if b, err = os.ReadFile(f); err != nil {
-				return nil, err
-			}
+	return NewVerifiedKeyCertBundleFromPem(certBytes, privKeyBytes, certChainBytes, rootCertBytes)
+}
 
-			certChainBytes = append(certChainBytes, b...)
-		}
-	}
-	rootCertBytes, err := os.ReadFile(rootCertFile)
-	if err != nil {
+// NewVerifiedKeyCertBundleFromSigner returns a new KeyCertBundle whose private key is held by signer, such as an
+// external signer, or error if the provided certs failed the verification. The bundle has no private key PEM.
+func NewVerifiedKeyCertBundleFromSigner(certFile string, signer crypto.Signer, certChainFiles []string, rootCertFile string) (
+	*KeyCertBundle, error,
+) {
+	bundle := &KeyCertBundle{}
+	if err := bundle.UpdateVerifiedKeyCertBundleFromSigner(certFile, signer, certChainFiles, rootCertFile); err != nil {
 		return nil, err
 	}
-	return NewVerifiedKeyCertBundleFromPem(certBytes, privKeyBytes, certChainBytes, rootCertBytes)
+	return bundle, nil
 }
 
 // NewKeyCertBundleWithRootCertFromFile returns a new KeyCertBundle with the root cert without verification.
@@ -203,15 +200,14 @@ func (b *KeyCertBundle) CertOptions() (*CertOptions, error) {
 		IsDualUse: ids[0] == b.cert.Subject.CommonName,
 	}
 
-	switch (*b.privKey).(type) {
-	case *rsa.PrivateKey:
-		size, err := GetRSAKeySize(*b.privKey)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get RSA key size: %v", err)
-		}
-		opts.RSAKeySize = size
-	case *ecdsa.PrivateKey:
+	// The key type is taken from the cert, as the private key may be held by an external signer.
+	switch pub := b.cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		opts.RSAKeySize = pub.N.BitLen()
+	case *ecdsa.PublicKey:
 		opts.ECSigAlg = EcdsaSigAlg
+	case ed25519.PublicKey:
+		opts.ECSigAlg = Ed25519SigAlg
 	default:
 		return nil, errors.New("unknown private key type")
 	}
@@ -221,7 +217,7 @@ func (b *KeyCertBundle) CertOptions() (*CertOptions, error) {
 
 // UpdateVerifiedKeyCertBundleFromFile Verifies and updates KeyCertBundle with new certs
 func (b *KeyCertBundle) UpdateVerifiedKeyCertBundleFromFile(certFile string, privKeyFile string, certChainFiles []string, rootCertFile string) error {
-	certBytes, err := os.ReadFile(certFile)
+	certBytes, certChainBytes, rootCertBytes, err := readCertFiles(certFile, certChainFiles, rootCertFile)
 	if err != nil {
 		return err
 	}
@@ -229,30 +225,82 @@ func (b *KeyCertBundle) UpdateVerifiedKeyCertBundleFromFile(certFile string, pri
 	if err != nil {
 		return err
 	}
-	certChainBytes := []byte{}
-	if len(certChainFiles) != 0 {
-		for _, f := range certChainFiles {
-			var b []byte
-			if b, err = os.ReadFile(f); err != nil {
-				return err
-			}
-
-			certChainBytes = append(certChainBytes, b...)
-		}
+
+	err = b.VerifyAndSetAll(certBytes, privKeyBytes, certChainBytes, rootCertBytes)
+	if err != nil {
+		return err
 	}
-	rootCertBytes, err := os.ReadFile(rootCertFile)
+
+	return nil
+}
+
+// UpdateVerifiedKeyCertBundleFromSigner verifies and updates KeyCertBundle with new certs, whose private key is
+// held by signer.
+func (b *KeyCertBundle) UpdateVerifiedKeyCertBundleFromSigner(certFile string, signer crypto.Signer, certChainFiles []string,
+	rootCertFile string,
+) error {
+	certBytes, certChainBytes, rootCertBytes, err := readCertFiles(certFile, certChainFiles, rootCertFile)
 	if err != nil {
 		return err
 	}
+	return b.VerifyAndSetAllWithSigner(certBytes, signer, certChainBytes, rootCertBytes)
+}
 
-	err = b.VerifyAndSetAll(certBytes, privKeyBytes, certChainBytes, rootCertBytes)
+// VerifyAndSetAllWithSigner verifies the certs, and sets all certs in KeyCertBundle together, with the private key
+// held by signer.
+func (b *KeyCertBundle) VerifyAndSetAllWithSigner(certBytes []byte, signer crypto.Signer, certChainBytes, rootCertBytes []byte) error {
+	cert, err := verifyCertChain(certBytes, certChainBytes, rootCertBytes)
 	if err != nil {
 		return err
 	}
+	pub, ok := cert.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(signer.Public()) {
+		return errors.New("the cert does not match the public key of the signer")
+	}
 
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.certBytes = copyBytes(certBytes)
+	b.privKeyBytes = []byte{}
+	b.certChainBytes = copyBytes(certChainBytes)
+	b.rootCertBytes = copyBytes(rootCertBytes)
+	b.cert = cert
+	var privKey crypto.PrivateKey = signer
+	b.privKey = &privKey
 	return nil
 }
 
+// VerifyAndSetRootCert verifies the current cert chain against rootCertBytes, and replaces the root certs,
+// keeping the signing key and cert.
+func (b *KeyCertBundle) VerifyAndSetRootCert(rootCertBytes []byte) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, err := verifyCertChain(b.certBytes, b.certChainBytes, rootCertBytes); err != nil {
+		return err
+	}
+	b.rootCertBytes = copyBytes(rootCertBytes)
+	return nil
+}
+
+// readCertFiles reads the cert, the concatenated cert chain and the root cert from files.
+func readCertFiles(certFile string, certChainFiles []string, rootCertFile string) (certBytes, certChainBytes, rootCertBytes []byte, err error) {
+	if certBytes, err = os.ReadFile(certFile); err != nil {
+		return nil, nil, nil, err
+	}
+	certChainBytes = []byte{}
+	for _, f := range certChainFiles {
+		var b []byte
+		if b, err = os.ReadFile(f); err != nil {
+			return nil, nil, nil, err
+		}
+		certChainBytes = append(certChainBytes, b...)
+	}
+	if rootCertBytes, err = os.ReadFile(rootCertFile); err != nil {
+		return nil, nil, nil, err
+	}
+	return certBytes, certChainBytes, rootCertBytes, nil
+}
+
 // ExtractRootCertExpiryTimestamp returns the expiration of the first root cert
 func (b *KeyCertBundle) ExtractRootCertExpiryTimestamp() (time.Time, error) {
 	return extractCertExpiryTimestamp("root cert", b.GetRootCertPem())
@@ -282,7 +330,25 @@ func TimeBeforeCertExpires(certBytes []byte, now time.Time) (time.Duration, erro
 
 // Verify that the cert chain, root cert and key/cert match.
 func Verify(certBytes, privKeyBytes, certChainBytes, rootCertBytes []byte) error {
-	// Verify the cert can be verified from the root cert through the cert chain.
+	if _, err := verifyCertChain(certBytes, certChainBytes, rootCertBytes); err != nil {
+		return err
+	}
+
+	// Verify that the key can be correctly parsed.
+	if _, err := ParsePemEncodedKey(privKeyBytes); err != nil {
+		return fmt.Errorf("failed to parse private key PEM: %v", err)
+	}
+
+	// Verify the cert and key match.
+	if _, err := tls.X509KeyPair(certBytes, privKeyBytes); err != nil {
+		return fmt.Errorf("the cert does not match the key: %v", err)
+	}
+
+	return nil
+}
+
+// verifyCertChain verifies the cert can be verified from the root cert through the cert chain, and returns it.
+func verifyCertChain(certBytes, certChainBytes, rootCertBytes []byte) (*x509.Certificate, error) {
 	rcp := x509.NewCertPool()
 	rcp.AppendCertsFromPEM(rootCertBytes)
 
@@ -295,27 +361,16 @@ func Verify(certBytes, privKeyBytes, certChainBytes, rootCertBytes []byte) error
 	}
 	cert, err := ParsePemEncodedCertificate(certBytes)
 	if err != nil {
-		return fmt.Errorf("failed to parse cert PEM: %v", err)
+		return nil, fmt.Errorf("failed to parse cert PEM: %v", err)
 	}
 	chains, err := cert.Verify(opts)
 
 	if len(chains) == 0 || err != nil {
-		return fmt.Errorf(
+		return nil, fmt.Errorf(
 			"cannot verify the cert with the provided root chain and cert "+
 				"pool with error: %v", err)
 	}
-
-	// Verify that the key can be correctly parsed.
-	if _, err = ParsePemEncodedKey(privKeyBytes); err != nil {
-		return fmt.Errorf("failed to parse private key PEM: %v", err)
-	}
-
-	// Verify the cert and key match.
-	if _, err := tls.X509KeyPair(certBytes, privKeyBytes); err != nil {
-		return fmt.Errorf("the cert does not match the key: %v", err)
-	}
-
-	return nil
+	return cert, nil
 }
 
 func extractCertExpiryTimestamp(certType string, certPem []byte) (time.Time, error) {
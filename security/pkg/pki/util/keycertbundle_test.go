@@ -15,7 +15,9 @@
 package util
 
 import (
+	"bytes"
 	"fmt"
+	"os"
 	"strings"
 	"testing"
 	"time"
@@ -342,6 +344,38 @@ func TestNewVerifiedKeyCertBundleFromFile(t *testing.T) {
 }
 
 // Test the root cert expiry timestamp can be extracted correctly.
+func TestVerifyAndSetRootCert(t *testing.T) {
+	bundle, err := NewVerifiedKeyCertBundleFromFile(intCertFile, intKeyFile, []string{intCertChainFile}, rootCertFile)
+	if err != nil {
+		t.Fatalf("failed to create bundle: %v", err)
+	}
+	root, err := os.ReadFile(rootCertFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := os.ReadFile(anotherRootCertFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _, _, _ := bundle.GetAllPem()
+
+	// Roots which do not sign the cert chain are rejected
+	if err := bundle.VerifyAndSetRootCert(other); err == nil {
+		t.Fatal("expected error setting roots not signing the cert chain")
+	}
+	combined := AppendCertByte(root, other)
+	if err := bundle.VerifyAndSetRootCert(combined); err != nil {
+		t.Fatalf("failed to set roots: %v", err)
+	}
+	newCert, _, _, newRoots := bundle.GetAllPem()
+	if !bytes.Equal(newRoots, combined) {
+		t.Errorf("expected roots %s, got %s", combined, newRoots)
+	}
+	if !bytes.Equal(newCert, cert) {
+		t.Error("expected the cert to be unchanged")
+	}
+}
+
 func TestExtractRootCertExpiryTimestamp(t *testing.T) {
 	testCases := map[string]struct {
 		notBefore time.Time
@@ -0,0 +1,168 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit records the certificate signing decisions of the CA server, for auditing which certificates were
+// issued to which identity, by which caller, and when.
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"istio.io/istio/pkg/log"
+)
+
+var auditLog = log.RegisterScope("caaudit", "CA audit log")
+
+// Outcome is the outcome of a certificate request.
+type Outcome string
+
+const (
+	// Issued means a certificate was issued.
+	Issued Outcome = "ISSUED"
+	// Denied means the request was rejected, by authentication, impersonation checks or the issuance policy.
+	Denied Outcome = "DENIED"
+	// Failed means the certificate could not be signed.
+	Failed Outcome = "FAILED"
+)
+
+// Record is the audit record of a certificate signing decision.
+type Record struct {
+	Time    time.Time `json:"time"`
+	Outcome Outcome   `json:"outcome"`
+	// Reason is the reason of the outcome, for requests which were not issued a certificate.
+	Reason        string `json:"reason,omitempty"`
+	ClientAddress string `json:"clientAddress,omitempty"`
+	// Authenticator is the type of the authenticator which authenticated the caller.
+	Authenticator    string   `json:"authenticator,omitempty"`
+	CallerIdentities []string `json:"callerIdentities,omitempty"`
+	// ImpersonatedIdentity is the identity a node requested a certificate for, on behalf of a workload.
+	ImpersonatedIdentity string   `json:"impersonatedIdentity,omitempty"`
+	RequestedSANs        []string `json:"requestedSANs,omitempty"`
+	GrantedSANs          []string `json:"grantedSANs,omitempty"`
+	RequestedTTL         string   `json:"requestedTTL,omitempty"`
+	GrantedTTL           string   `json:"grantedTTL,omitempty"`
+	// SerialNumber is the hex encoded serial number of the issued certificate.
+	SerialNumber string     `json:"serialNumber,omitempty"`
+	NotAfter     *time.Time `json:"notAfter,omitempty"`
+}
+
+// Identities returns all the identities of the record: of the caller, impersonated, requested and granted.
+func (r *Record) Identities() []string {
+	ids := append([]string{}, r.CallerIdentities...)
+	if r.ImpersonatedIdentity != "" {
+		ids = append(ids, r.ImpersonatedIdentity)
+	}
+	ids = append(ids, r.RequestedSANs...)
+	return append(ids, r.GrantedSANs...)
+}
+
+// Sink writes audit records. Sinks must be safe for concurrent use.
+type Sink interface {
+	// Write writes a record. It should not block on slow destinations.
+	Write(r *Record) error
+	// Close flushes the pending records and releases the resources of the sink.
+	Close() error
+}
+
+// Auditor writes audit records to sinks.
+type Auditor struct {
+	sinks []sinkWithName
+}
+
+type sinkWithName struct {
+	name string
+	Sink
+}
+
+// NewAuditor creates an auditor from a comma separated list of sinks:
+//
+//	stdout                  JSON records on stdout
+//	file:<path>             JSON records in a local file, rotated when it reaches 100MB and never deleted
+//	otlp:<host:port>        OTLP logs, exported over gRPC to a collector
+func NewAuditor(spec string) (*Auditor, error) {
+	a := &Auditor{}
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		kind, arg, _ := strings.Cut(s, ":")
+		var sink Sink
+		var err error
+		switch kind {
+		case "stdout":
+			sink = NewStdoutSink()
+		case "file":
+			if arg == "" {
+				err = errors.New("a path is required")
+				break
+			}
+			sink = NewFileSink(arg)
+		case "otlp":
+			if arg == "" {
+				err = errors.New("an address is required")
+				break
+			}
+			sink, err = NewOTLPSink(arg)
+		default:
+			err = errors.New("unknown sink type")
+		}
+		if err != nil {
+			_ = a.Close()
+			return nil, fmt.Errorf("invalid audit sink %q: %v", s, err)
+		}
+		a.AddSink(kind, sink)
+	}
+	if len(a.sinks) == 0 {
+		return nil, errors.New("no audit sink")
+	}
+	return a, nil
+}
+
+// AddSink adds a sink to the auditor. name identifies the sink in logs and metrics.
+func (a *Auditor) AddSink(name string, sink Sink) {
+	a.sinks = append(a.sinks, sinkWithName{name: name, Sink: sink})
+}
+
+// Write writes a record to all the sinks. Errors are logged and counted, rather than failing the request.
+func (a *Auditor) Write(r *Record) {
+	for _, s := range a.sinks {
+		if err := s.Write(r); err != nil {
+			writeErrors.With(sinkTag.Value(s.name)).Increment()
+			auditLog.Errorf("failed to write audit record to %s: %v", s.name, err)
+		}
+	}
+}
+
+// Close closes all the sinks.
+func (a *Auditor) Close() error {
+	var errs []error
+	for _, s := range a.sinks {
+		errs = append(errs, s.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Marshal encodes a record as a single line of JSON.
+func Marshal(r *Record) ([]byte, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
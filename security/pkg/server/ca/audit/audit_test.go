@@ -0,0 +1,168 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/grpc"
+
+	"istio.io/istio/pkg/monitoring/monitortest"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+var testRecord = &Record{
+	Time:             time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	Outcome:          Issued,
+	Authenticator:    "KubeJWTAuthenticator",
+	CallerIdentities: []string{"spiffe://cluster.local/ns/default/sa/app"},
+	RequestedSANs:    []string{"spiffe://cluster.local/ns/default/sa/app"},
+	GrantedSANs:      []string{"spiffe://cluster.local/ns/default/sa/app"},
+	RequestedTTL:     "24h0m0s",
+	GrantedTTL:       "24h0m0s",
+	SerialNumber:     "5f3a910c",
+}
+
+func TestNewAuditor(t *testing.T) {
+	dir := t.TempDir()
+	cases := []struct {
+		spec  string
+		sinks []string
+		err   string
+	}{
+		{spec: "stdout", sinks: []string{"stdout"}},
+		{spec: "stdout, file:" + filepath.Join(dir, "audit.log"), sinks: []string{"stdout", "file"}},
+		{spec: "otlp:localhost:4317", sinks: []string{"otlp"}},
+		{spec: "", err: "no audit sink"},
+		{spec: "file:", err: "a path is required"},
+		{spec: "otlp", err: "an address is required"},
+		{spec: "stdout,syslog", err: `invalid audit sink "syslog": unknown sink type`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.spec, func(t *testing.T) {
+			a, err := NewAuditor(tc.spec)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			defer a.Close()
+			var names []string
+			for _, s := range a.sinks {
+				names = append(names, s.name)
+			}
+			assert.Equal(t, names, tc.sinks)
+		})
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	a, err := NewAuditor("file:" + path)
+	assert.NoError(t, err)
+	a.Write(testRecord)
+	denied := &Record{Time: testRecord.Time, Outcome: Denied, Reason: "authentication failure"}
+	a.Write(denied)
+	assert.NoError(t, a.Close())
+
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	assert.Equal(t, len(lines), 2)
+	for i, want := range []*Record{testRecord, denied} {
+		got := &Record{}
+		assert.NoError(t, json.Unmarshal([]byte(lines[i]), got))
+		assert.Equal(t, got, want)
+	}
+}
+
+type failingSink struct{}
+
+func (failingSink) Write(*Record) error { return errors.New("unavailable") }
+
+func (failingSink) Close() error { return nil }
+
+func TestWriteErrors(t *testing.T) {
+	mt := monitortest.New(t)
+	a := &Auditor{}
+	a.AddSink("test", failingSink{})
+	a.Write(testRecord)
+	mt.Assert(writeErrors.Name(), map[string]string{"sink": "test"}, monitortest.Exactly(1))
+}
+
+type fakeCollector struct {
+	collogspb.UnimplementedLogsServiceServer
+
+	mu       sync.Mutex
+	requests []*collogspb.ExportLogsServiceRequest
+}
+
+func (c *fakeCollector) Export(_ context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, req)
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+func TestOTLPSink(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	collector := &fakeCollector{}
+	server := grpc.NewServer()
+	collogspb.RegisterLogsServiceServer(server, collector)
+	go server.Serve(l)
+	t.Cleanup(server.Stop)
+
+	sink, err := NewOTLPSink(l.Addr().String())
+	assert.NoError(t, err)
+	assert.NoError(t, sink.Write(testRecord))
+	assert.NoError(t, sink.Write(&Record{Time: testRecord.Time, Outcome: Denied, Reason: "authentication failure"}))
+	// Pending records are exported on close
+	assert.NoError(t, sink.Close())
+	if err := sink.Write(testRecord); err == nil {
+		t.Fatal("expected error writing to a closed sink")
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	var records []*Record
+	for _, req := range collector.requests {
+		for _, rl := range req.ResourceLogs {
+			for _, sl := range rl.ScopeLogs {
+				assert.Equal(t, sl.Scope.Name, "istio.io/ca-audit")
+				for _, lr := range sl.LogRecords {
+					r := &Record{}
+					assert.NoError(t, json.Unmarshal([]byte(lr.Body.GetStringValue()), r))
+					records = append(records, r)
+				}
+			}
+		}
+	}
+	assert.Equal(t, len(records), 2)
+	assert.Equal(t, records[0], testRecord)
+	assert.Equal(t, records[1].Outcome, Denied)
+}
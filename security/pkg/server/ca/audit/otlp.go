@@ -0,0 +1,186 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	otlpQueueSize     = 4096
+	otlpBatchSize     = 512
+	otlpFlushInterval = time.Second
+	otlpExportTimeout = 10 * time.Second
+)
+
+// otlpSink exports records as OTLP logs to a collector, in batches.
+type otlpSink struct {
+	conn   *grpc.ClientConn
+	client collogspb.LogsServiceClient
+
+	mu      sync.RWMutex
+	closed  bool
+	records chan *Record
+	done    chan struct{}
+}
+
+// NewOTLPSink creates a sink exporting records as OTLP logs to the collector at address, over gRPC.
+// Records are exported asynchronously; they are dropped if the collector cannot keep up.
+func NewOTLPSink(address string) (Sink, error) {
+	// The collector is expected to be local, such as a sidecar or node agent.
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	s := &otlpSink{
+		conn:    conn,
+		client:  collogspb.NewLogsServiceClient(conn),
+		records: make(chan *Record, otlpQueueSize),
+		done:    make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+func (s *otlpSink) Write(r *Record) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return errors.New("sink is closed")
+	}
+	select {
+	case s.records <- r:
+		return nil
+	default:
+		return errors.New("export queue is full, dropping record")
+	}
+}
+
+func (s *otlpSink) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.records)
+	}
+	s.mu.Unlock()
+	<-s.done
+	return s.conn.Close()
+}
+
+func (s *otlpSink) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(otlpFlushInterval)
+	defer ticker.Stop()
+	var batch []*Record
+	for {
+		select {
+		case r, ok := <-s.records:
+			if !ok {
+				s.export(batch)
+				return
+			}
+			batch = append(batch, r)
+			if len(batch) >= otlpBatchSize {
+				s.export(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			s.export(batch)
+			batch = nil
+		}
+	}
+}
+
+func (s *otlpSink) export(batch []*Record) {
+	if len(batch) == 0 {
+		return
+	}
+	logs := make([]*logspb.LogRecord, 0, len(batch))
+	for _, r := range batch {
+		lr, err := toLogRecord(r)
+		if err != nil {
+			writeErrors.With(sinkTag.Value("otlp")).Increment()
+			auditLog.Errorf("failed to encode audit record: %v", err)
+			continue
+		}
+		logs = append(logs, lr)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), otlpExportTimeout)
+	defer cancel()
+	_, err := s.client.Export(ctx, &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			Resource: &resourcepb.Resource{
+				Attributes: []*commonpb.KeyValue{stringAttribute("service.name", "istiod")},
+			},
+			ScopeLogs: []*logspb.ScopeLogs{{
+				Scope:      &commonpb.InstrumentationScope{Name: "istio.io/ca-audit"},
+				LogRecords: logs,
+			}},
+		}},
+	})
+	if err != nil {
+		writeErrors.With(sinkTag.Value("otlp")).Record(float64(len(logs)))
+		auditLog.Errorf("failed to export %d audit records: %v", len(logs), err)
+	}
+}
+
+// toLogRecord converts a record to an OTLP log record, with the JSON record as body. The outcome, serial number and
+// granted SANs are also set as attributes, for filtering.
+func toLogRecord(r *Record) (*logspb.LogRecord, error) {
+	b, err := Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	severity := logspb.SeverityNumber_SEVERITY_NUMBER_INFO
+	if r.Outcome != Issued {
+		severity = logspb.SeverityNumber_SEVERITY_NUMBER_WARN
+	}
+	attrs := []*commonpb.KeyValue{stringAttribute("istio.ca.outcome", string(r.Outcome))}
+	if r.SerialNumber != "" {
+		attrs = append(attrs, stringAttribute("istio.ca.serial_number", r.SerialNumber))
+	}
+	if len(r.GrantedSANs) > 0 {
+		values := make([]*commonpb.AnyValue, 0, len(r.GrantedSANs))
+		for _, san := range r.GrantedSANs {
+			values = append(values, &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: san}})
+		}
+		attrs = append(attrs, &commonpb.KeyValue{
+			Key:   "istio.ca.granted_sans",
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}},
+		})
+	}
+	return &logspb.LogRecord{
+		TimeUnixNano:         uint64(r.Time.UnixNano()),
+		ObservedTimeUnixNano: uint64(time.Now().UnixNano()),
+		SeverityNumber:       severity,
+		SeverityText:         severity.String()[len("SEVERITY_NUMBER_"):],
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: string(b[:len(b)-1])}},
+		Attributes:           attrs,
+	}, nil
+}
+
+func stringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
@@ -0,0 +1,65 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	lj "gopkg.in/natefinch/lumberjack.v2"
+)
+
+// fileMaxSizeMB is the size at which the audit file is rotated.
+const fileMaxSizeMB = 100
+
+// writerSink writes JSON records to a writer, one per line.
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewStdoutSink creates a sink writing JSON records on stdout.
+func NewStdoutSink() Sink {
+	return &writerSink{w: os.Stdout}
+}
+
+// NewFileSink creates a sink appending JSON records to a local file. The file is rotated when it reaches 100MB.
+// Rotated files are never deleted, as dropping audit records must be a decision of the operator; they are expected
+// to be shipped or pruned externally.
+func NewFileSink(path string) Sink {
+	return &writerSink{w: &lj.Logger{
+		Filename: path,
+		MaxSize:  fileMaxSizeMB,
+	}}
+}
+
+func (s *writerSink) Write(r *Record) error {
+	b, err := Marshal(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(b)
+	return err
+}
+
+func (s *writerSink) Close() error {
+	if c, ok := s.w.(io.Closer); ok && s.w != os.Stdout {
+		return c.Close()
+	}
+	return nil
+}
@@ -19,11 +19,13 @@ import (
 )
 
 const (
-	errorlabel = "error"
+	errorlabel  = "error"
+	reasonlabel = "reason"
 )
 
 var (
-	errorTag = monitoring.CreateLabel(errorlabel)
+	errorTag  = monitoring.CreateLabel(errorlabel)
+	reasonTag = monitoring.CreateLabel(reasonlabel)
 
 	csrCounts = monitoring.NewSum(
 		"citadel_server_csr_count",
@@ -50,6 +52,11 @@ var (
 		"The number of errors occurred when signing the CSR.",
 	)
 
+	policyViolationCounts = monitoring.NewSum(
+		"citadel_server_issuance_policy_violation_count",
+		"The number of CSRs rejected by the issuance policy.",
+	)
+
 	successCounts = monitoring.NewSum(
 		"citadel_server_success_cert_issuance_count",
 		"The number of certificates issuances that have succeeded.",
@@ -83,6 +90,7 @@ type monitoringMetrics struct {
 	CSRError          monitoring.Metric
 	IDExtractionError monitoring.Metric
 	certSignErrors    monitoring.Metric
+	policyViolations  monitoring.Metric
 }
 
 // newMonitoringMetrics creates a new monitoringMetrics.
@@ -94,9 +102,14 @@ func newMonitoringMetrics() monitoringMetrics {
 		CSRError:          csrParsingErrorCounts,
 		IDExtractionError: idExtractionErrorCounts,
 		certSignErrors:    certSignErrorCounts,
+		policyViolations:  policyViolationCounts,
 	}
 }
 
 func (m *monitoringMetrics) GetCertSignError(err string) monitoring.Metric {
 	return m.certSignErrors.With(errorTag.Value(err))
 }
+
+func (m *monitoringMetrics) GetPolicyViolation(reason string) monitoring.Metric {
+	return m.policyViolations.With(reasonTag.Value(reason))
+}
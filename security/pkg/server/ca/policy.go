@@ -0,0 +1,279 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// Key algorithms of IssuanceRule.KeyAlgorithms.
+const (
+	KeyAlgorithmRSA     = "RSA"
+	KeyAlgorithmECDSA   = "ECDSA"
+	KeyAlgorithmEd25519 = "ED25519"
+)
+
+// Reasons of issuance policy violations, used as metric label.
+const (
+	violationTTL          = "ttl"
+	violationDNSName      = "dns_name"
+	violationKeyAlgorithm = "key_algorithm"
+	violationKeySize      = "key_size"
+	violationRateLimit    = "rate_limit"
+)
+
+// maxRateLimitedIdentities bounds the number of identities whose request rate is tracked.
+const maxRateLimitedIdentities = 100000
+
+// IssuancePolicy restricts the certificates issued to workloads, per namespace or service account.
+//
+// The rule of the service account of an identity applies, or else the rule of its namespace, or else the default
+// rule, without namespace. Identities without a rule are not restricted, but are never issued the DNS names of the CSR.
+type IssuancePolicy struct {
+	Rules []IssuanceRule `json:"rules"`
+
+	// rules indexes the rules by namespace/service account.
+	rules map[string]*IssuanceRule
+	// limiters are the rate limiters of the identities.
+	limiters *lru.Cache[string, *rate.Limiter]
+}
+
+// IssuanceRule restricts the certificates issued to the identities of a namespace or service account.
+type IssuanceRule struct {
+	// Namespace the rule applies to. If empty, the rule is the default rule.
+	Namespace string `json:"namespace,omitempty"`
+	// ServiceAccount the rule applies to. If empty, the rule applies to all the service accounts of the namespace.
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+	// MaxTTL is the maximum TTL of the certificates. Requests without TTL are issued certificates with this TTL.
+	MaxTTL *metav1.Duration `json:"maxTTL,omitempty"`
+	// DNSNames are the DNS names that may be requested as SANs in the CSR, in addition to the identity.
+	// A wildcard such as *.example.com matches a single label. CSRs with other DNS names are rejected.
+	// The DNS names are only issued if the rules of all the identities of the request allow them.
+	DNSNames []string `json:"dnsNames,omitempty"`
+	// KeyAlgorithms are the allowed key algorithms of the CSR: RSA, ECDSA or ED25519. If empty, all are allowed.
+	KeyAlgorithms []string `json:"keyAlgorithms,omitempty"`
+	// MinRSAKeySize is the minimum size of RSA keys.
+	MinRSAKeySize int `json:"minRSAKeySize,omitempty"`
+	// RateLimit limits the certificate requests of each identity.
+	RateLimit *RateLimit `json:"rateLimit,omitempty"`
+}
+
+// RateLimit is a token bucket rate limit.
+type RateLimit struct {
+	// RequestsPerMinute is the sustained request rate.
+	RequestsPerMinute float64 `json:"requestsPerMinute"`
+	// Burst is the number of requests allowed at once. Defaults to 1.
+	Burst int `json:"burst,omitempty"`
+}
+
+// policyViolation is a certificate request rejected by the issuance policy.
+type policyViolation struct {
+	reason string
+	code   codes.Code
+	msg    string
+}
+
+func (v *policyViolation) Error() string {
+	return v.msg
+}
+
+func violation(reason string, code codes.Code, format string, args ...any) *policyViolation {
+	return &policyViolation{reason: reason, code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+// ParseIssuancePolicy parses and validates an issuance policy.
+func ParseIssuancePolicy(b []byte) (*IssuancePolicy, error) {
+	p := &IssuancePolicy{}
+	if err := yaml.UnmarshalStrict(b, p); err != nil {
+		return nil, fmt.Errorf("invalid issuance policy: %v", err)
+	}
+	p.rules = make(map[string]*IssuanceRule, len(p.Rules))
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("invalid issuance policy rule %d: %v", i, err)
+		}
+		key := ruleKey(r.Namespace, r.ServiceAccount)
+		if _, f := p.rules[key]; f {
+			return nil, fmt.Errorf("invalid issuance policy rule %d: duplicate rule for %q", i, key)
+		}
+		p.rules[key] = r
+	}
+	p.limiters, _ = lru.New[string, *rate.Limiter](maxRateLimitedIdentities)
+	return p, nil
+}
+
+func (r *IssuanceRule) validate() error {
+	if r.ServiceAccount != "" && r.Namespace == "" {
+		return fmt.Errorf("service account %s requires a namespace", r.ServiceAccount)
+	}
+	if r.MaxTTL != nil && r.MaxTTL.Duration <= 0 {
+		return fmt.Errorf("maxTTL must be positive")
+	}
+	for _, name := range r.DNSNames {
+		if strings.Contains(strings.TrimPrefix(name, "*."), "*") {
+			return fmt.Errorf("invalid DNS name %q: only a leading wildcard label is supported", name)
+		}
+	}
+	for _, alg := range r.KeyAlgorithms {
+		switch alg {
+		case KeyAlgorithmRSA, KeyAlgorithmECDSA, KeyAlgorithmEd25519:
+		default:
+			return fmt.Errorf("unknown key algorithm %q", alg)
+		}
+	}
+	if r.MinRSAKeySize < 0 {
+		return fmt.Errorf("minRSAKeySize must not be negative")
+	}
+	if r.RateLimit != nil && r.RateLimit.RequestsPerMinute <= 0 {
+		return fmt.Errorf("rateLimit.requestsPerMinute must be positive")
+	}
+	return nil
+}
+
+func ruleKey(namespace, serviceAccount string) string {
+	return namespace + "/" + serviceAccount
+}
+
+// ruleFor returns the rule of identity, or nil if there is none.
+func (p *IssuancePolicy) ruleFor(identity string) *IssuanceRule {
+	id, err := spiffe.ParseIdentity(identity)
+	if err == nil {
+		if r, f := p.rules[ruleKey(id.Namespace, id.ServiceAccount)]; f {
+			return r
+		}
+		if r, f := p.rules[ruleKey(id.Namespace, "")]; f {
+			return r
+		}
+	}
+	return p.rules[ruleKey("", "")]
+}
+
+// check checks a request for the identities, with the given CSR and requested TTL, against the policy.
+// It returns the TTL and the extra DNS names to issue the certificate with. The DNS names of the CSR are only
+// returned if every identity has a rule allowing them.
+func (p *IssuancePolicy) check(identities []string, csr *x509.CertificateRequest, ttl time.Duration) (
+	time.Duration, []string, *policyViolation,
+) {
+	var dnsNames []string
+	limited := map[string]*RateLimit{}
+	allowDNSNames := len(identities) > 0
+	for _, identity := range identities {
+		r := p.ruleFor(identity)
+		if r == nil {
+			// Identities without a rule keep the certificates issued without policy, with the identities only.
+			allowDNSNames = false
+			continue
+		}
+		if r.MaxTTL != nil {
+			if ttl > r.MaxTTL.Duration {
+				return 0, nil, violation(violationTTL, codes.InvalidArgument,
+					"requested TTL %s is greater than the max TTL %s allowed for %s", ttl, r.MaxTTL.Duration, identity)
+			}
+			if ttl <= 0 {
+				ttl = r.MaxTTL.Duration
+			}
+		}
+		for _, name := range csr.DNSNames {
+			if !matchesDNSName(r.DNSNames, name) {
+				return 0, nil, violation(violationDNSName, codes.PermissionDenied,
+					"DNS name %s is not allowed for %s", name, identity)
+			}
+		}
+		if v := r.checkKey(identity, csr); v != nil {
+			return 0, nil, v
+		}
+		if r.RateLimit != nil {
+			limited[identity] = r.RateLimit
+		}
+	}
+	// The rate is only counted for requests allowed by the rest of the policy.
+	for identity, limit := range limited {
+		if !p.limiter(identity, limit).Allow() {
+			return 0, nil, violation(violationRateLimit, codes.ResourceExhausted,
+				"certificate request rate limit exceeded for %s", identity)
+		}
+	}
+	if allowDNSNames && len(csr.DNSNames) > 0 {
+		dnsNames = sets.SortedList(sets.New(csr.DNSNames...))
+	}
+	return ttl, dnsNames, nil
+}
+
+func (r *IssuanceRule) checkKey(identity string, csr *x509.CertificateRequest) *policyViolation {
+	var alg string
+	switch pub := csr.PublicKey.(type) {
+	case *rsa.PublicKey:
+		alg = KeyAlgorithmRSA
+		if size := pub.N.BitLen(); size < r.MinRSAKeySize {
+			return violation(violationKeySize, codes.InvalidArgument,
+				"RSA key size %d is smaller than the min size %d allowed for %s", size, r.MinRSAKeySize, identity)
+		}
+	case *ecdsa.PublicKey:
+		alg = KeyAlgorithmECDSA
+	case ed25519.PublicKey:
+		alg = KeyAlgorithmEd25519
+	default:
+		alg = fmt.Sprintf("%T", pub)
+	}
+	if len(r.KeyAlgorithms) > 0 && !sets.New(r.KeyAlgorithms...).Contains(alg) {
+		return violation(violationKeyAlgorithm, codes.InvalidArgument,
+			"key algorithm %s is not allowed for %s, allowed: %s", alg, identity, strings.Join(r.KeyAlgorithms, ", "))
+	}
+	return nil
+}
+
+// limiter returns the rate limiter of identity.
+func (p *IssuancePolicy) limiter(identity string, limit *RateLimit) *rate.Limiter {
+	if l, f := p.limiters.Get(identity); f {
+		return l
+	}
+	l := rate.NewLimiter(rate.Limit(limit.RequestsPerMinute/60), max(limit.Burst, 1))
+	if prev, f, _ := p.limiters.PeekOrAdd(identity, l); f {
+		return prev
+	}
+	return l
+}
+
+// matchesDNSName returns whether name matches one of the allowed DNS names.
+func matchesDNSName(allowed []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if suffix, ok := strings.CutPrefix(a, "*."); ok {
+			if first, rest, f := strings.Cut(name, "."); f && first != "" && rest == suffix {
+				return true
+			}
+		} else if a == name {
+			return true
+		}
+	}
+	return false
+}
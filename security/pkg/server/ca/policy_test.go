@@ -0,0 +1,251 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const testPolicy = `
+rules:
+- maxTTL: 24h
+- namespace: payments
+  maxTTL: 1h
+  keyAlgorithms: [ECDSA, RSA]
+  minRSAKeySize: 3072
+  dnsNames: [payments.example.com, "*.payments.svc.cluster.local"]
+- namespace: payments
+  serviceAccount: batch
+  rateLimit:
+    requestsPerMinute: 1
+    burst: 2
+`
+
+func newCSR(t *testing.T, opts util.CertOptions) *x509.CertificateRequest {
+	t.Helper()
+	csrPEM, _, err := util.GenCSR(opts)
+	assert.NoError(t, err)
+	csr, err := util.ParsePemEncodedCSR(csrPEM)
+	assert.NoError(t, err)
+	return csr
+}
+
+func TestParseIssuancePolicy(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy string
+		err    string
+	}{
+		{name: "valid", policy: testPolicy},
+		{name: "unknown field", policy: "rules:\n- namespace: a\n  ttl: 1h", err: "unknown field"},
+		{name: "service account without namespace", policy: "rules:\n- serviceAccount: a", err: "requires a namespace"},
+		{name: "duplicate", policy: "rules:\n- namespace: a\n- namespace: a", err: "duplicate rule"},
+		{name: "invalid TTL", policy: "rules:\n- maxTTL: -1h", err: "maxTTL must be positive"},
+		{name: "invalid wildcard", policy: "rules:\n- dnsNames: [a.*.com]", err: "leading wildcard"},
+		{name: "unknown algorithm", policy: "rules:\n- keyAlgorithms: [DSA]", err: "unknown key algorithm"},
+		{name: "invalid rate", policy: "rules:\n- rateLimit: {requestsPerMinute: 0}", err: "must be positive"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseIssuancePolicy([]byte(tt.policy))
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestIssuancePolicyCheck(t *testing.T) {
+	policy, err := ParseIssuancePolicy([]byte(testPolicy))
+	assert.NoError(t, err)
+
+	const (
+		defaultID = "spiffe://cluster.local/ns/default/sa/default"
+		paymentID = "spiffe://cluster.local/ns/payments/sa/api"
+		batchID   = "spiffe://cluster.local/ns/payments/sa/batch"
+	)
+	ecCSR := newCSR(t, util.CertOptions{Host: paymentID, ECSigAlg: util.EcdsaSigAlg})
+	cases := []struct {
+		name     string
+		identity string
+		csr      *x509.CertificateRequest
+		ttl      time.Duration
+		wantTTL  time.Duration
+		wantDNS  []string
+		reason   string
+		code     codes.Code
+	}{
+		{
+			name:     "default rule",
+			identity: defaultID,
+			csr:      newCSR(t, util.CertOptions{Host: defaultID, ECSigAlg: util.Ed25519SigAlg}),
+			ttl:      12 * time.Hour,
+			wantTTL:  12 * time.Hour,
+		},
+		{
+			name:     "default TTL",
+			identity: defaultID,
+			csr:      ecCSR,
+			wantTTL:  24 * time.Hour,
+		},
+		{
+			name:     "TTL too long",
+			identity: paymentID,
+			csr:      ecCSR,
+			ttl:      2 * time.Hour,
+			reason:   violationTTL,
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "allowed DNS names",
+			identity: paymentID,
+			csr: newCSR(t, util.CertOptions{
+				Host:     paymentID + ",payments.example.com,api.payments.svc.cluster.local",
+				ECSigAlg: util.EcdsaSigAlg,
+			}),
+			ttl:     time.Hour,
+			wantTTL: time.Hour,
+			wantDNS: []string{"api.payments.svc.cluster.local", "payments.example.com"},
+		},
+		{
+			name:     "DNS name not allowed",
+			identity: paymentID,
+			csr:      newCSR(t, util.CertOptions{Host: paymentID + ",a.b.payments.svc.cluster.local", ECSigAlg: util.EcdsaSigAlg}),
+			reason:   violationDNSName,
+			code:     codes.PermissionDenied,
+		},
+		{
+			name:     "DNS name with default rule",
+			identity: defaultID,
+			csr:      newCSR(t, util.CertOptions{Host: defaultID + ",default.example.com", ECSigAlg: util.EcdsaSigAlg}),
+			reason:   violationDNSName,
+			code:     codes.PermissionDenied,
+		},
+		{
+			name:     "key algorithm not allowed",
+			identity: paymentID,
+			csr:      newCSR(t, util.CertOptions{Host: paymentID, ECSigAlg: util.Ed25519SigAlg}),
+			reason:   violationKeyAlgorithm,
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "RSA key too small",
+			identity: paymentID,
+			csr:      newCSR(t, util.CertOptions{Host: paymentID, RSAKeySize: 2048}),
+			reason:   violationKeySize,
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "service account rule",
+			identity: batchID,
+			csr:      newCSR(t, util.CertOptions{Host: batchID, RSAKeySize: 2048}),
+			ttl:      48 * time.Hour,
+			wantTTL:  48 * time.Hour,
+		},
+		{
+			name:     "not a SPIFFE identity",
+			identity: "test-identity",
+			csr:      ecCSR,
+			ttl:      48 * time.Hour,
+			reason:   violationTTL,
+			code:     codes.InvalidArgument,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ttl, dns, v := policy.check([]string{tt.identity}, tt.csr, tt.ttl)
+			if tt.reason != "" {
+				if v == nil {
+					t.Fatalf("expected %s violation", tt.reason)
+				}
+				assert.Equal(t, v.reason, tt.reason)
+				assert.Equal(t, v.code, tt.code)
+				return
+			}
+			if v != nil {
+				t.Fatalf("unexpected violation: %v", v)
+			}
+			assert.Equal(t, ttl, tt.wantTTL)
+			assert.Equal(t, dns, tt.wantDNS)
+		})
+	}
+}
+
+func TestIssuancePolicyCheckWithoutRule(t *testing.T) {
+	policy, err := ParseIssuancePolicy([]byte(`
+rules:
+- namespace: payments
+  dnsNames: [payments.example.com]
+`))
+	assert.NoError(t, err)
+
+	const (
+		defaultID = "spiffe://cluster.local/ns/default/sa/default"
+		paymentID = "spiffe://cluster.local/ns/payments/sa/api"
+	)
+	csr := newCSR(t, util.CertOptions{Host: defaultID + ",payments.example.com", ECSigAlg: util.EcdsaSigAlg})
+	// An identity without a rule is not restricted, but is not issued the DNS names of the CSR either.
+	_, dns, v := policy.check([]string{defaultID}, csr, time.Hour)
+	if v != nil {
+		t.Fatalf("unexpected violation: %v", v)
+	}
+	assert.Equal(t, dns, nil)
+
+	// The DNS names are only issued if all the identities allow them.
+	_, dns, v = policy.check([]string{paymentID, defaultID}, csr, time.Hour)
+	if v != nil {
+		t.Fatalf("unexpected violation: %v", v)
+	}
+	assert.Equal(t, dns, nil)
+
+	_, dns, v = policy.check([]string{paymentID}, csr, time.Hour)
+	if v != nil {
+		t.Fatalf("unexpected violation: %v", v)
+	}
+	assert.Equal(t, dns, []string{"payments.example.com"})
+}
+
+func TestIssuancePolicyRateLimit(t *testing.T) {
+	policy, err := ParseIssuancePolicy([]byte(testPolicy))
+	assert.NoError(t, err)
+	batch := "spiffe://cluster.local/ns/payments/sa/batch"
+	other := "spiffe://cluster.local/ns/payments/sa/other-batch"
+	csr := newCSR(t, util.CertOptions{Host: batch, ECSigAlg: util.EcdsaSigAlg})
+
+	// The burst is allowed, then requests are limited per identity
+	for range 2 {
+		if _, _, v := policy.check([]string{batch}, csr, time.Hour); v != nil {
+			t.Fatalf("unexpected violation: %v", v)
+		}
+	}
+	_, _, v := policy.check([]string{batch}, csr, time.Hour)
+	if v == nil || v.reason != violationRateLimit || v.code != codes.ResourceExhausted {
+		t.Fatalf("expected rate limit violation, got %v", v)
+	}
+	// Other identities are not limited by this rule
+	if _, _, v := policy.check([]string{other}, csr, time.Hour); v != nil {
+		t.Fatalf("unexpected violation: %v", v)
+	}
+}
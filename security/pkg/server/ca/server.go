@@ -16,6 +16,8 @@ package ca
 
 import (
 	"context"
+	"fmt"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
@@ -28,9 +30,11 @@ import (
 	"istio.io/istio/pkg/kube/multicluster"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/slices"
 	"istio.io/istio/security/pkg/pki/ca"
 	caerror "istio.io/istio/security/pkg/pki/error"
 	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/istio/security/pkg/server/ca/audit"
 )
 
 var serverCaLog = log.RegisterScope("serverca", "Citadel server log")
@@ -55,6 +59,11 @@ type Server struct {
 	serverCertTTL  time.Duration
 
 	nodeAuthorizer *MulticlusterNodeAuthorizor
+
+	// policy restricts the issued certificates, if set.
+	policy atomic.Pointer[IssuancePolicy]
+	// auditor records the signing decisions, if set.
+	auditor atomic.Pointer[audit.Auditor]
 }
 
 type SaNode struct {
@@ -72,15 +81,26 @@ func (s SaNode) String() string {
 // the subject public key is the public key in the CSR.
 // the validity duration is the ValidityDuration in request, or default value if the given duration is invalid.
 // it is signed by the CA signing key.
+// The decision is recorded by the auditor, if set.
 func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertificateRequest) (
 	*pb.IstioCertificateResponse, error,
 ) {
 	s.monitoring.CSR.Increment()
+	rec := &audit.Record{
+		Time:          time.Now(),
+		Outcome:       audit.Denied,
+		ClientAddress: security.GetConnectionAddress(ctx),
+		RequestedTTL:  (time.Duration(request.ValidityDuration) * time.Second).String(),
+	}
+	defer s.writeAuditRecord(rec, request.Csr)
 	caller, err := security.Authenticate(ctx, s.Authenticators)
 	if caller == nil || err != nil {
 		s.monitoring.AuthnError.Increment()
+		rec.Reason = "authentication failure"
 		return nil, status.Error(codes.Unauthenticated, "request authenticate failure")
 	}
+	rec.Authenticator = caller.AuthenticatorType
+	rec.CallerIdentities = caller.Identities
 
 	serverCaLog := serverCaLog.WithLabels("client", security.GetConnectionAddress(ctx))
 	// By default, we will use the callers identity for the certificate
@@ -88,6 +108,7 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 	crMetadata := request.Metadata.GetFields()
 	impersonatedIdentity := crMetadata[security.ImpersonatedIdentity].GetStringValue()
 	if impersonatedIdentity != "" {
+		rec.ImpersonatedIdentity = impersonatedIdentity
 		serverCaLog.Debugf("impersonated identity: %s", impersonatedIdentity)
 		// If there is an impersonated identity, we will override to use that identity (only single value
 		// supported), if the real caller is authorized.
@@ -95,6 +116,7 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 			s.monitoring.AuthnError.Increment()
 			// Return an opaque error (for security purposes) but log the full reason
 			serverCaLog.Warnf("impersonation not allowed, as node authorizer (CA_TRUSTED_NODE_ACCOUNTS) is not configured")
+			rec.Reason = "impersonation not allowed"
 			return nil, status.Error(codes.Unauthenticated, "request impersonation authentication failure")
 
 		}
@@ -102,6 +124,7 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 			s.monitoring.AuthnError.Increment()
 			// Return an opaque error (for security purposes) but log the full reason
 			serverCaLog.Warnf("impersonation failed for identity %s, error: %v", impersonatedIdentity, err)
+			rec.Reason = fmt.Sprintf("impersonation failure: %v", err)
 			return nil, status.Error(codes.Unauthenticated, "request impersonation authentication failure")
 		}
 		// Node is authorized to impersonate; overwrite the SAN to the impersonated identity.
@@ -116,6 +139,20 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 		ForCA:      false,
 		CertSigner: certSigner,
 	}
+	if policy := s.policy.Load(); policy != nil {
+		// A CSR that cannot be parsed is rejected when signing.
+		if csr, err := util.ParsePemEncodedCSR([]byte(request.Csr)); err == nil {
+			ttl, dnsNames, v := policy.check(sans, csr, certOpts.TTL)
+			if v != nil {
+				serverCaLog.Warnf("CSR rejected by issuance policy: %v", v)
+				s.monitoring.GetPolicyViolation(v.reason).Increment()
+				rec.Reason = fmt.Sprintf("issuance policy: %v", v)
+				return nil, status.Errorf(v.code, "CSR rejected by issuance policy (%v)", v)
+			}
+			certOpts.TTL = ttl
+			certOpts.SubjectIDs = append(slices.Clone(sans), dnsNames...)
+		}
+	}
 	var signErr error
 	var cert []byte
 	var respCertChain []string
@@ -128,8 +165,23 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 	if signErr != nil {
 		serverCaLog.Errorf("CSR signing error: %v", signErr.Error())
 		s.monitoring.GetCertSignError(signErr.(*caerror.Error).ErrorType()).Increment()
+		rec.Outcome = audit.Failed
+		rec.Reason = signErr.Error()
 		return nil, status.Errorf(signErr.(*caerror.Error).HTTPErrorCode(), "CSR signing error (%v)", signErr.(*caerror.Error))
 	}
+	rec.Outcome = audit.Issued
+	rec.GrantedSANs = certOpts.SubjectIDs
+	if s.auditor.Load() != nil {
+		leafPEM := cert
+		if certSigner != "" && len(respCertChain) > 0 {
+			leafPEM = []byte(respCertChain[0])
+		}
+		if leaf, err := util.ParsePemEncodedCertificate(leafPEM); err == nil {
+			rec.SerialNumber = leaf.SerialNumber.Text(16)
+			rec.GrantedTTL = leaf.NotAfter.Sub(rec.Time).Round(time.Second).String()
+			rec.NotAfter = &leaf.NotAfter
+		}
+	}
 	if certSigner == "" {
 		respCertChain = []string{string(cert)}
 		if len(certChainBytes) != 0 {
@@ -181,6 +233,34 @@ func RecordCertsExpiry(keyCertBundle *util.KeyCertBundle) {
 	}
 }
 
+// SetAuditor sets the auditor recording the certificate signing decisions. A nil auditor disables the audit.
+func (s *Server) SetAuditor(auditor *audit.Auditor) {
+	s.auditor.Store(auditor)
+}
+
+// writeAuditRecord completes the record with the SANs requested in the CSR, and writes it.
+func (s *Server) writeAuditRecord(rec *audit.Record, csrPEM string) {
+	auditor := s.auditor.Load()
+	if auditor == nil {
+		return
+	}
+	if csr, err := util.ParsePemEncodedCSR([]byte(csrPEM)); err == nil {
+		for _, u := range csr.URIs {
+			rec.RequestedSANs = append(rec.RequestedSANs, u.String())
+		}
+		rec.RequestedSANs = append(rec.RequestedSANs, csr.DNSNames...)
+		for _, ip := range csr.IPAddresses {
+			rec.RequestedSANs = append(rec.RequestedSANs, ip.String())
+		}
+	}
+	auditor.Write(rec)
+}
+
+// SetIssuancePolicy sets the policy restricting the issued certificates. A nil policy removes the restrictions.
+func (s *Server) SetIssuancePolicy(policy *IssuancePolicy) {
+	s.policy.Store(policy)
+}
+
 // Register registers a GRPC server on the specified port.
 func (s *Server) Register(grpcServer *grpc.Server) {
 	pb.RegisterIstioCertificateServiceServer(grpcServer, s)
@@ -19,8 +19,10 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
 	"net"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -46,6 +48,7 @@ import (
 	mockca "istio.io/istio/security/pkg/pki/ca/mock"
 	caerror "istio.io/istio/security/pkg/pki/error"
 	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/istio/security/pkg/server/ca/audit"
 	"istio.io/istio/security/pkg/server/ca/authenticate"
 )
 
@@ -314,6 +317,154 @@ func TestCreateCertificate(t *testing.T) {
 	}
 }
 
+func TestCreateCertificateWithIssuancePolicy(t *testing.T) {
+	mt := monitortest.New(t)
+	identity := "spiffe://cluster.local/ns/payments/sa/api"
+	fakeCA := &mockca.FakeCA{
+		SignedCert:    []byte(testCert),
+		KeyCertBundle: util.NewKeyCertBundleFromPem(nil, nil, []byte(testCertChain), []byte(testRootCert)),
+	}
+	server := &Server{
+		ca:             fakeCA,
+		Authenticators: []security.Authenticator{&mockAuthenticator{identities: []string{identity}}},
+		monitoring:     newMonitoringMetrics(),
+	}
+	policy, err := ParseIssuancePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetIssuancePolicy(policy)
+
+	p := &peer.Peer{Addr: &net.IPAddr{IP: net.IPv4(192, 168, 1, 1)}, AuthInfo: credentials.TLSInfo{}}
+	ctx := peer.NewContext(context.Background(), p)
+	csr, _, err := util.GenCSR(util.CertOptions{Host: identity + ",payments.example.com", ECSigAlg: util.EcdsaSigAlg})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Allowed DNS names are added to the identity
+	if _, err := server.CreateCertificate(ctx, &pb.IstioCertificateRequest{Csr: string(csr), ValidityDuration: 3600}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{identity, "payments.example.com"}; !reflect.DeepEqual(fakeCA.ReceivedIDs, want) {
+		t.Errorf("expected SANs %v, got %v", want, fakeCA.ReceivedIDs)
+	}
+
+	// Violations are rejected with the reason
+	_, err = server.CreateCertificate(ctx, &pb.IstioCertificateRequest{Csr: string(csr), ValidityDuration: 7200})
+	if s, _ := status.FromError(err); s.Code() != codes.InvalidArgument || !strings.Contains(s.Message(), "max TTL 1h0m0s") {
+		t.Errorf("expected TTL violation, got %v", err)
+	}
+	mt.Assert(policyViolationCounts.Name(), map[string]string{"reason": violationTTL}, monitortest.Exactly(1))
+
+	// Without policy, the DNS names of the CSR are not used
+	server.SetIssuancePolicy(nil)
+	if _, err := server.CreateCertificate(ctx, &pb.IstioCertificateRequest{Csr: string(csr), ValidityDuration: 7200}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{identity}; !reflect.DeepEqual(fakeCA.ReceivedIDs, want) {
+		t.Errorf("expected SANs %v, got %v", want, fakeCA.ReceivedIDs)
+	}
+}
+
+type recordingSink struct {
+	records []*audit.Record
+}
+
+func (s *recordingSink) Write(r *audit.Record) error {
+	s.records = append(s.records, r)
+	return nil
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+func TestCreateCertificateAudit(t *testing.T) {
+	identity := "spiffe://cluster.local/ns/default/sa/app"
+	caCert, caKey, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Org:          "ca",
+		TTL:          time.Hour,
+		IsCA:         true,
+		IsSelfSigned: true,
+		ECSigAlg:     util.EcdsaSigAlg,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := util.ParsePemEncodedCertificate(caCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := util.ParsePemEncodedKey(caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, _, err := util.GenCSR(util.CertOptions{Host: identity, ECSigAlg: util.EcdsaSigAlg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedCSR, err := util.ParsePemEncodedCSR(csr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The fake CA returns a real certificate, for the serial number to be recorded
+	leaf, err := util.GenCertFromCSR(parsedCSR, cert, parsedCSR.PublicKey, key, []string{identity}, time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf})
+	leafCert, err := util.ParsePemEncodedCertificate(leafPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &Server{
+		ca: &mockca.FakeCA{
+			SignedCert:    leafPEM,
+			KeyCertBundle: util.NewKeyCertBundleFromPem(nil, nil, []byte(testCertChain), []byte(testRootCert)),
+		},
+		Authenticators: []security.Authenticator{&mockAuthenticator{identities: []string{identity}}},
+		monitoring:     newMonitoringMetrics(),
+	}
+	sink := &recordingSink{}
+	auditor := &audit.Auditor{}
+	auditor.AddSink("test", sink)
+	server.SetAuditor(auditor)
+
+	p := &peer.Peer{Addr: &net.IPAddr{IP: net.IPv4(192, 168, 1, 1)}, AuthInfo: credentials.TLSInfo{}}
+	ctx := peer.NewContext(context.Background(), p)
+	if _, err := server.CreateCertificate(ctx, &pb.IstioCertificateRequest{Csr: string(csr), ValidityDuration: 3600}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.Authenticators = []security.Authenticator{&mockAuthenticator{errMsg: "not authorized"}}
+	if _, err := server.CreateCertificate(ctx, &pb.IstioCertificateRequest{Csr: string(csr), ValidityDuration: 3600}); err == nil {
+		t.Fatal("expected authentication error")
+	}
+
+	if len(sink.records) != 2 {
+		t.Fatalf("expected 2 audit records, got %d", len(sink.records))
+	}
+	issued := sink.records[0]
+	if issued.Outcome != audit.Issued || issued.Authenticator != "mockAuthenticator" || issued.ClientAddress != "192.168.1.1" {
+		t.Errorf("unexpected issued record: %+v", issued)
+	}
+	if issued.SerialNumber != leafCert.SerialNumber.Text(16) || !issued.NotAfter.Equal(leafCert.NotAfter) {
+		t.Errorf("expected serial %s, got %s", leafCert.SerialNumber.Text(16), issued.SerialNumber)
+	}
+	if want := []string{identity}; !reflect.DeepEqual(issued.GrantedSANs, want) || !reflect.DeepEqual(issued.RequestedSANs, want) {
+		t.Errorf("expected SANs %v, got requested %v, granted %v", want, issued.RequestedSANs, issued.GrantedSANs)
+	}
+	if issued.RequestedTTL != "1h0m0s" {
+		t.Errorf("expected requested TTL 1h0m0s, got %s", issued.RequestedTTL)
+	}
+	denied := sink.records[1]
+	if denied.Outcome != audit.Denied || denied.Reason != "authentication failure" || denied.SerialNumber != "" {
+		t.Errorf("unexpected denied record: %+v", denied)
+	}
+	if want := []string{identity}; !reflect.DeepEqual(denied.RequestedSANs, want) {
+		t.Errorf("expected requested SANs %v, got %v", want, denied.RequestedSANs)
+	}
+}
+
 func TestCreateCertificateE2EWithImpersonateIdentity(t *testing.T) {
 	allowZtunnel := sets.Set[types.NamespacedName]{
 		{Name: "ztunnel", Namespace: "istio-system"}: {},
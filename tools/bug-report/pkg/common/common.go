@@ -63,6 +63,7 @@ var versionMap = map[string]*resourceNames{
 			"debug/endpointz",
 			"debug/inject",
 			"debug/krtz",
+			"debug/krt_tracez",
 			"debug/instancesz",
 			"debug/mcsz",
 			"debug/mesh",